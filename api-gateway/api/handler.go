@@ -2,6 +2,7 @@ package api
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
 	"google.golang.org/grpc"
 
 	swaggerFiles "github.com/swaggo/files"
@@ -11,46 +12,120 @@ import (
 	_ "api-gateway/api/docs"
 	"api-gateway/api/handlers"
 	"api-gateway/api/middleware"
+	"api-gateway/config"
 	"api-gateway/config/logger"
 )
 
 // @securityDefinitions.apikey BearerAuth
 // @in header
 // @name Authorization
-func NewRouter(connF *grpc.ClientConn, logger logger.Logger) *gin.Engine {
+// @description JWT ("Bearer <token>") or API key ("ApiKey <key>")
+func NewRouter(connF *grpc.ClientConn, logger logger.Logger, cfg config.Config) *gin.Engine {
 	h := handlers.NewHandler(connF, logger)
+	h.AttachmentMaxSize = cfg.ATTACHMENT_MAX_SIZE
+	h.Roles = middleware.NewUserRoles(cfg.AUTH_SERVICE_URL)
 	router := gin.Default()
+	// Handlers pass the gin.Context to gRPC calls; let it carry the request
+	// context's deadline and cancellation.
+	router.ContextWithFallback = true
+	router.Use(middleware.Timeout(cfg.REQUEST_TIMEOUTS))
 
 	router.GET("/api/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
-	protected := router.Group("/", middleware.JWTMiddleware())
+	// Probes need no credentials and are not rate limited.
+	router.GET("/healthz", h.Healthz)
+	router.GET("/readyz", h.Readyz)
+
+	keys := middleware.NewAPIKeyVerifier(cfg.AUTH_SERVICE_URL, cfg.API_KEY_CACHE_TTL)
+	sessions := middleware.NewSessionChecker(cfg.AUTH_SERVICE_URL, cfg.SESSION_CACHE_TTL)
+	limitIP, limit := rateLimiters(cfg, logger)
+	protected := router.Group("/", limitIP, middleware.JWTMiddleware(keys, sessions), limit, middleware.ForwardCaller(),
+		middleware.ReadYourWrites(cfg.READ_PRIMARY_WINDOW))
+
+	read := middleware.RequireScope(middleware.ScopeRead)
+	writePost := middleware.RequireScope(middleware.ScopePost)
+	writeComment := middleware.RequireScope(middleware.ScopeComment)
+	userOnly := middleware.RequireScope("")
 
 	// Category routes
 	category := protected.Group("/category")
-	router.POST("/category", h.CategoryCreate)
-	router.GET("/category/:id", h.CategoryGet)
-	category.PUT("/:id", h.CategoryUpdate)
-	category.DELETE("/:id", h.CategoryDelete)
-	protected.GET("/categories", h.CategoryGetAll)
+	router.POST("/category", limit, h.CategoryCreate)
+	router.GET("/category/:id", limit, h.CategoryGet)
+	category.PUT("/:id", userOnly, h.CategoryUpdate)
+	category.DELETE("/:id", userOnly, h.CategoryDelete)
+	protected.GET("/categories", read, h.CategoryGetAll)
 
 	// Post routes
 	post := protected.Group("/post")
-	post.POST("/", h.PostCreate)
-	post.GET("/:id", h.PostGet)
-	post.PUT("/:id", h.PostUpdate)
-	post.DELETE("/:id", h.PostDelete)
-	protected.GET("/posts", h.PostGetAll)
+	post.POST("/", writePost, h.PostCreate)
+	post.GET("/:id", read, h.PostGet)
+	post.PUT("/:id", writePost, h.PostUpdate)
+	post.DELETE("/:id", writePost, h.PostDelete)
+	post.POST("/:id/attachments", writePost, h.AttachmentUploadPost)
+	post.GET("/:id/attachments", read, h.AttachmentListPost)
+	post.POST("/:id/poll", writePost, h.PollCreate)
+	post.GET("/:id/poll", read, h.PollGet)
+	post.POST("/:id/poll/vote", userOnly, h.PollVote)
+	post.POST("/:id/poll/close", writePost, h.PollClose)
+	post.POST("/:id/answer/:comment_id", writePost, h.PostAcceptAnswer)
+	post.DELETE("/:id/answer", writePost, h.PostUnacceptAnswer)
+	protected.GET("/posts", read, h.PostGetAll)
 
 	// Comment routes
 	comment := protected.Group("/comment")
-	comment.POST("/", h.CommentCreate)
-	comment.GET("/:id", h.CommentGet)
-	comment.PUT("/:id", h.CommentUpdate)
-	comment.DELETE("/:id", h.CommentDelete)
-	protected.GET("/comments", h.CommentGetAll)
+	comment.POST("/", writeComment, h.CommentCreate)
+	comment.GET("/:id", read, h.CommentGet)
+	comment.PUT("/:id", writeComment, h.CommentUpdate)
+	comment.DELETE("/:id", writeComment, h.CommentDelete)
+	comment.POST("/:id/attachments", writeComment, h.AttachmentUploadComment)
+	comment.GET("/:id/attachments", read, h.AttachmentListComment)
+	protected.GET("/comments", read, h.CommentGetAll)
+
+	// Attachment routes
+	attachment := protected.Group("/attachment")
+	attachment.GET("/:id", read, h.AttachmentGet)
+	attachment.GET("/:id/file", read, h.AttachmentDownload)
+	attachment.DELETE("/:id", userOnly, h.AttachmentDelete)
 
 	// Tag routes
-	protected.GET("/popular-tags", h.PopularTagsGet)
+	protected.GET("/popular-tags", read, h.PopularTagsGet)
+
+	// Report routes
+	protected.POST("/report", userOnly, h.ReportCreate)
+
+	// Moderation routes
+	moderation := protected.Group("/moderation", userOnly, middleware.RequireRole("moderator", "admin"))
+	moderation.GET("/reports", h.ModerationReports)
+	moderation.POST("/reports/:id/resolve", h.ModerationResolveReport)
+	moderation.GET("/log", h.ModerationLog)
+	moderation.POST("/posts/:id/approve", h.ModerationApprovePost)
+	moderation.POST("/posts/:id/reject", h.ModerationRejectPost)
+	moderation.POST("/comments/:id/approve", h.ModerationApproveComment)
+	moderation.POST("/comments/:id/reject", h.ModerationRejectComment)
+	moderation.POST("/posts/:id/lock", h.ModerationLockPost)
+	moderation.DELETE("/posts/:id/lock", h.ModerationUnlockPost)
+	moderation.POST("/posts/:id/pin", h.ModerationPinPost)
+	moderation.DELETE("/posts/:id/pin", h.ModerationUnpinPost)
+	moderation.POST("/posts/:id/feature", h.ModerationFeaturePost)
+	moderation.DELETE("/posts/:id/feature", h.ModerationUnfeaturePost)
+	moderation.POST("/users/:id/mute", h.ModerationMuteUser)
+	moderation.DELETE("/users/:id/mute", h.ModerationUnmuteUser)
 
 	return router
 }
+
+// rateLimiters returns the per-IP limiter that guards authentication and
+// the per-client limiter that runs after it.
+func rateLimiters(cfg config.Config, logger logger.Logger) (gin.HandlerFunc, gin.HandlerFunc) {
+	if !cfg.RATE_LIMIT_ENABLED {
+		next := func(c *gin.Context) { c.Next() }
+		return next, next
+	}
+
+	var store middleware.RateLimitStore = middleware.NewMemoryRateLimitStore()
+	if cfg.RATE_LIMIT_REDIS_ADDR != "" {
+		store = middleware.NewRedisRateLimitStore(redis.NewClient(&redis.Options{Addr: cfg.RATE_LIMIT_REDIS_ADDR}))
+	}
+	return middleware.RateLimitIP(store, cfg.RATE_LIMIT_POLICIES, logger),
+		middleware.RateLimit(store, cfg.RATE_LIMIT_POLICIES, logger)
+}
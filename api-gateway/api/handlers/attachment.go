@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	pb "api-gateway/forum-protos/genprotos"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt"
+)
+
+// AttachmentUploadPost handles attaching a file to a post.
+// @Summary Attach file to post
+// @Description Upload a screenshot, log or other file to one of your posts. The type is detected from the contents.
+// @Tags attachment
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path string true "Post ID"
+// @Param file formData file true "File to attach"
+// @Success 200 {object} pb.AttachmentRes
+// @Failure 400 {object} string "Missing file or type not allowed"
+// @Failure 403 {object} string "Not the author"
+// @Failure 404 {object} string "Post not found"
+// @Failure 413 {object} string "File too large"
+// @Failure 500 {object} string "Server error"
+// @Security BearerAuth
+// @Router /post/{id}/attachments [POST]
+func (h *HTTPHandler) AttachmentUploadPost(c *gin.Context) {
+	h.upload(c, "post")
+}
+
+// AttachmentUploadComment handles attaching a file to a comment.
+// @Summary Attach file to comment
+// @Description Upload a screenshot, log or other file to one of your comments. The type is detected from the contents.
+// @Tags attachment
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path string true "Comment ID"
+// @Param file formData file true "File to attach"
+// @Success 200 {object} pb.AttachmentRes
+// @Failure 400 {object} string "Missing file or type not allowed"
+// @Failure 403 {object} string "Not the author"
+// @Failure 404 {object} string "Comment not found"
+// @Failure 413 {object} string "File too large"
+// @Failure 500 {object} string "Server error"
+// @Security BearerAuth
+// @Router /comment/{id}/attachments [POST]
+func (h *HTTPHandler) AttachmentUploadComment(c *gin.Context) {
+	h.upload(c, "comment")
+}
+
+func (h *HTTPHandler) upload(c *gin.Context, contentType string) {
+	// Allow for the multipart framing around the file itself.
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.AttachmentMaxSize+1<<20)
+	header, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "File too large"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing file", "details": err.Error()})
+		return
+	}
+	if header.Size > h.AttachmentMaxSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "File too large", "details": fmt.Sprintf("limit is %d bytes", h.AttachmentMaxSize)})
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Couldn't read file", "details": err.Error()})
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Couldn't read file", "details": err.Error()})
+		return
+	}
+
+	res, err := h.Attachment.Upload(c, &pb.AttachmentCReq{
+		UserId:      c.MustGet("claims").(jwt.MapClaims)["user_id"].(string),
+		ContentType: contentType,
+		ContentId:   c.Param("id"),
+		Filename:    header.Filename,
+		Data:        data,
+	})
+	if err != nil {
+		c.JSON(httpStatus(err), gin.H{"error": "Couldn't upload attachment", "details": errorMessage(err)})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// AttachmentListPost handles listing the attachments of a post.
+// @Summary List post attachments
+// @Description List the files attached to a post
+// @Tags attachment
+// @Produce json
+// @Param id path string true "Post ID"
+// @Success 200 {object} pb.AttachmentGARes
+// @Failure 500 {object} string "Server error"
+// @Security BearerAuth
+// @Router /post/{id}/attachments [GET]
+func (h *HTTPHandler) AttachmentListPost(c *gin.Context) {
+	h.listAttachments(c, "post")
+}
+
+// AttachmentListComment handles listing the attachments of a comment.
+// @Summary List comment attachments
+// @Description List the files attached to a comment
+// @Tags attachment
+// @Produce json
+// @Param id path string true "Comment ID"
+// @Success 200 {object} pb.AttachmentGARes
+// @Failure 500 {object} string "Server error"
+// @Security BearerAuth
+// @Router /comment/{id}/attachments [GET]
+func (h *HTTPHandler) AttachmentListComment(c *gin.Context) {
+	h.listAttachments(c, "comment")
+}
+
+func (h *HTTPHandler) listAttachments(c *gin.Context, contentType string) {
+	res, err := h.Attachment.GetAll(c, &pb.AttachmentGAReq{ContentType: contentType, ContentId: c.Param("id")})
+	if err != nil {
+		c.JSON(httpStatus(err), gin.H{"error": "Couldn't get attachments", "details": errorMessage(err)})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// AttachmentGet handles getting attachment metadata.
+// @Summary Get attachment
+// @Description Get the metadata of an attachment, including the thumbnails of images
+// @Tags attachment
+// @Produce json
+// @Param id path string true "Attachment ID"
+// @Success 200 {object} pb.AttachmentRes
+// @Failure 404 {object} string "Attachment not found"
+// @Failure 500 {object} string "Server error"
+// @Security BearerAuth
+// @Router /attachment/{id} [GET]
+func (h *HTTPHandler) AttachmentGet(c *gin.Context) {
+	res, err := h.Attachment.GetByID(c, &pb.AttachmentGReqOrDReq{AttachmentId: c.Param("id")})
+	if err != nil {
+		c.JSON(httpStatus(err), gin.H{"error": "Couldn't get attachment", "details": errorMessage(err)})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// AttachmentDownload handles downloading an attachment.
+// @Summary Download attachment
+// @Description Download the bytes of an attachment. Images are served inline, everything else as a download.
+// @Description Images become available once their metadata has been stripped.
+// @Tags attachment
+// @Produce octet-stream
+// @Param id path string true "Attachment ID"
+// @Param variant query string false "Image variant, e.g. thumb_160; the original by default"
+// @Success 200 {file} file
+// @Failure 404 {object} string "Attachment or variant not found"
+// @Failure 409 {object} string "Image could not be processed"
+// @Failure 500 {object} string "Server error"
+// @Failure 503 {object} string "Image still processing"
+// @Security BearerAuth
+// @Router /attachment/{id}/file [GET]
+func (h *HTTPHandler) AttachmentDownload(c *gin.Context) {
+	res, err := h.Attachment.Download(c, &pb.AttachmentGReqOrDReq{AttachmentId: c.Param("id"), Variant: c.Query("variant")})
+	if err != nil {
+		c.JSON(httpStatus(err), gin.H{"error": "Couldn't get attachment", "details": errorMessage(err)})
+		return
+	}
+	serveFile(c, res.Attachment.Filename, res.Attachment.MimeType, res.Data)
+}
+
+// AttachmentDelete handles deleting an attachment.
+// @Summary Delete attachment
+// @Description Delete one of your attachments
+// @Tags attachment
+// @Produce json
+// @Param id path string true "Attachment ID"
+// @Success 200 {object} string "Attachment deleted"
+// @Failure 403 {object} string "Not the uploader"
+// @Failure 404 {object} string "Attachment not found"
+// @Failure 500 {object} string "Server error"
+// @Security BearerAuth
+// @Router /attachment/{id} [DELETE]
+func (h *HTTPHandler) AttachmentDelete(c *gin.Context) {
+	_, err := h.Attachment.Delete(c, &pb.AttachmentGReqOrDReq{
+		AttachmentId: c.Param("id"),
+		UserId:       c.MustGet("claims").(jwt.MapClaims)["user_id"].(string),
+	})
+	if err != nil {
+		c.JSON(httpStatus(err), gin.H{"error": "Couldn't delete attachment", "details": errorMessage(err)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Attachment deleted"})
+}
+
+// serveFile writes stored bytes with the sniffed type. Only images render in
+// the browser; anything else is forced to download so uploaded HTML or SVG
+// never runs on our origin.
+func serveFile(c *gin.Context, filename, mimeType string, data []byte) {
+	disposition := "attachment"
+	if strings.HasPrefix(mimeType, "image/") && mimeType != "image/svg+xml" {
+		disposition = "inline"
+	}
+	c.Header("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": filename}))
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Data(http.StatusOK, mimeType, data)
+}
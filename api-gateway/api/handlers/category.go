@@ -32,7 +32,7 @@ func (h *HTTPHandler) CategoryCreate(c *gin.Context) {
 	}
 	res, err := h.Category.Create(c, &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(httpStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, res)
@@ -54,7 +54,7 @@ func (h *HTTPHandler) CategoryGet(c *gin.Context) {
 	id := &pb.CategoryGReqOrDReq{CategoryId: c.Param("id")}
 	res, err := h.Category.GetByID(c, id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Couldn't get category", "details": err.Error()})
+		c.JSON(httpStatus(err), gin.H{"error": "Couldn't get category", "details": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, res)
@@ -62,7 +62,7 @@ func (h *HTTPHandler) CategoryGet(c *gin.Context) {
 
 // CategoryUpdate handles updating an existing category .
 // @Summary Update category
-// @Description Update an existing category
+// @Description Update an existing category. qa is left unchanged when omitted.
 // @Tags category
 // @Accept json
 // @Produce json
@@ -76,7 +76,7 @@ func (h *HTTPHandler) CategoryGet(c *gin.Context) {
 // @Router /category/{id} [put]
 func (h *HTTPHandler) CategoryUpdate(c *gin.Context) {
 	id := c.Param("id")
-	var req pb.CategoryCReqOrCResOrGResOrUReqOrURes
+	var req pb.CategoryUReq
 
 	if err := c.BindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
@@ -86,7 +86,7 @@ func (h *HTTPHandler) CategoryUpdate(c *gin.Context) {
 	req.CategoryId = id
 	res, err := h.Category.Update(c, &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Couldn't update category ", "details": err.Error()})
+		c.JSON(httpStatus(err), gin.H{"error": "Couldn't update category ", "details": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, res)
@@ -109,7 +109,7 @@ func (h *HTTPHandler) CategoryDelete(c *gin.Context) {
 	id := &pb.CategoryGReqOrDReq{CategoryId: c.Param("id")}
 	_, err := h.Category.Delete(c, id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Couldn't delete category ", "details": err.Error()})
+		c.JSON(httpStatus(err), gin.H{"error": "Couldn't delete category ", "details": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "Category deleted"})
@@ -164,7 +164,7 @@ func (h *HTTPHandler) CategoryGetAll(c *gin.Context) {
 		},
 	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Couldn't get categories", "details": err.Error()})
+		c.JSON(httpStatus(err), gin.H{"error": "Couldn't get categories", "details": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, res)
@@ -2,7 +2,6 @@
 package handlers
 
 import (
-	"context"
 	"net/http"
 	"strconv"
 
@@ -21,6 +20,8 @@ import (
 // @Param comment body pb.CommentCReqForSwagger true "Comment data"
 // @Success 200 {object} pb.CommentCReqOrCResOrGResOrURes
 // @Failure 400 {object} string "Invalid request payload"
+// @Failure 409 {object} string "Rejected by moderation or post locked"
+// @Failure 403 {object} string "User is muted"
 // @Failure 500 {object} string "Server error"
 // @Security BearerAuth
 // @Router /comment [post]
@@ -40,7 +41,7 @@ func (h *HTTPHandler) CommentCreate(c *gin.Context) {
 	req.UserId = user_id
 	res, err := h.Comment.Create(c, &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(httpStatus(err), gin.H{"error": errorMessage(err)})
 		return
 	}
 	c.JSON(http.StatusOK, res)
@@ -53,16 +54,18 @@ func (h *HTTPHandler) CommentCreate(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param id path string true "Comment ID"
+// @Param format query string false "Body format: markdown (default) or html"
 // @Success 200 {object} pb.CommentCReqOrCResOrGResOrURes
 // @Failure 400 {object} string "Invalid comment  ID"
+// @Failure 404 {object} string "Comment not found"
 // @Failure 500 {object} string "Server error"
 // @Security BearerAuth
 // @Router /comment/{id} [get]
 func (h *HTTPHandler) CommentGet(c *gin.Context) {
-	id := &pb.CommentGReqOrDReq{CommentId: c.Param("id")}
+	id := &pb.CommentGReqOrDReq{CommentId: c.Param("id"), Format: c.Query("format")}
 	res, err := h.Comment.GetByID(c, id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Couldn't get comment", "details": err.Error()})
+		c.JSON(httpStatus(err), gin.H{"error": "Couldn't get comment", "details": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, res)
@@ -78,6 +81,7 @@ func (h *HTTPHandler) CommentGet(c *gin.Context) {
 // @Param comment body pb.CommentCReqForSwagger true "Updated comment data"
 // @Success 200 {object} pb.CommentCReqOrCResOrGResOrURes
 // @Failure 400 {object} string "Invalid request payload"
+// @Failure 403 {object} string "User is muted"
 // @Failure 404 {object} string "Comment not found"
 // @Failure 500 {object} string "Server error"
 // @Security BearerAuth
@@ -94,7 +98,7 @@ func (h *HTTPHandler) CommentUpdate(c *gin.Context) {
 	req.CommentId = id
 	res, err := h.Comment.Update(c, &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Couldn't update comment ", "details": err.Error()})
+		c.JSON(httpStatus(err), gin.H{"error": "Couldn't update comment ", "details": errorMessage(err)})
 		return
 	}
 	c.JSON(http.StatusOK, res)
@@ -117,7 +121,7 @@ func (h *HTTPHandler) CommentDelete(c *gin.Context) {
 	id := &pb.CommentGReqOrDReq{CommentId: c.Param("id")}
 	_, err := h.Comment.Delete(c, id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Couldn't delete comment ", "details": err.Error()})
+		c.JSON(httpStatus(err), gin.H{"error": "Couldn't delete comment ", "details": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "Comment deleted"})
@@ -133,6 +137,7 @@ func (h *HTTPHandler) CommentDelete(c *gin.Context) {
 // @Param post_id query string false "post_id"
 // @Param limit query integer false "Limit"
 // @Param offset query integer false "Offset"
+// @Param format query string false "Body format: markdown (default) or html"
 // @Success 200 {object} pb.CommentGARes
 // @Failure 400 {object} string "Invalid parameters"
 // @Failure 500 {object} string "Server error"
@@ -164,7 +169,7 @@ func (h *HTTPHandler) CommentGetAll(c *gin.Context) {
 		}
 	}
 
-	res, err := h.Comment.GetAll(context.Background(), &pb.CommentGAReq{
+	res, err := h.Comment.GetAll(c, &pb.CommentGAReq{
 		Filter: &pb.CommentFilter{
 			PostId: postId,
 			UserId: userId,
@@ -173,9 +178,10 @@ func (h *HTTPHandler) CommentGetAll(c *gin.Context) {
 			Limit:  int64(limit),
 			Offset: int64(offset),
 		},
+		Format: c.Query("format"),
 	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Couldn't get comments", "details": err.Error()})
+		c.JSON(httpStatus(err), gin.H{"error": "Couldn't get comments", "details": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, res)
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+)
+
+// readyTimeout bounds how long /readyz waits for the forum service
+// connection to come up.
+const readyTimeout = 2 * time.Second
+
+// Healthz godoc
+// @Summary Liveness
+// @Description Answer as long as the process serves requests
+// @Tags health
+// @Produce json
+// @Success 200 {object} string "ok"
+// @Router /healthz [get]
+func (h *HTTPHandler) Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Readyz godoc
+// @Summary Readiness
+// @Description Report whether the gateway can serve requests, i.e. its connection to the forum service is up
+// @Tags health
+// @Produce json
+// @Success 200 {object} string "ready"
+// @Failure 503 {object} string "Forum service unavailable"
+// @Router /readyz [get]
+func (h *HTTPHandler) Readyz(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c, readyTimeout)
+	defer cancel()
+	if err := connReady(ctx, h.ForumConn); err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Forum service unavailable", "err": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ready"})
+}
+
+// connReady waits until conn is connected or ctx is done. An idle
+// connection, one nothing used lately, is asked to connect first.
+func connReady(ctx context.Context, conn *grpc.ClientConn) error {
+	for {
+		state := conn.GetState()
+		switch state {
+		case connectivity.Ready:
+			return nil
+		case connectivity.Idle:
+			conn.Connect()
+		case connectivity.Shutdown:
+			return fmt.Errorf("connection is %s", state)
+		}
+		if !conn.WaitForStateChange(ctx, state) {
+			return fmt.Errorf("connection is %s", state)
+		}
+	}
+}
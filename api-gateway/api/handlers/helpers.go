@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	pb "api-gateway/forum-protos/genprotos"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// httpStatus maps the gRPC status forum-service returned to an HTTP status.
+func httpStatus(err error) int {
+	switch status.Code(err) {
+	case codes.InvalidArgument:
+		return http.StatusBadRequest
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.FailedPrecondition:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// errorMessage is the message of a gRPC status, or the whole error otherwise.
+func errorMessage(err error) string {
+	if s, ok := status.FromError(err); ok {
+		return s.Message()
+	}
+	return err.Error()
+}
+
+// parsePagination reads the limit and offset query parameters. It writes a
+// 400 response and returns false if either is malformed.
+func parsePagination(c *gin.Context) (*pb.Pagination, bool) {
+	p := &pb.Pagination{}
+	if s := c.Query("limit"); s != "" {
+		limit, err := strconv.Atoi(s)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit parameter"})
+			return nil, false
+		}
+		p.Limit = int64(limit)
+	}
+	if s := c.Query("offset"); s != "" {
+		offset, err := strconv.Atoi(s)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid offset parameter"})
+			return nil, false
+		}
+		p.Offset = int64(offset)
+	}
+	return p, true
+}
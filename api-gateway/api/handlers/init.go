@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"api-gateway/api/middleware"
 	"api-gateway/config/logger"
 	pb "api-gateway/forum-protos/genprotos"
 
@@ -8,19 +9,35 @@ import (
 )
 
 type HTTPHandler struct {
-	Comment  pb.CommentServiceClient
-	Post     pb.PostServiceClient
-	Category pb.CategoryServiceClient
-	Tag      pb.TagServiceClient
-	Logger   logger.Logger
+	Comment    pb.CommentServiceClient
+	Post       pb.PostServiceClient
+	Category   pb.CategoryServiceClient
+	Tag        pb.TagServiceClient
+	Report     pb.ReportServiceClient
+	Moderation pb.ModerationServiceClient
+	Attachment pb.AttachmentServiceClient
+	Logger     logger.Logger
+
+	// ForumConn is the connection the clients above share, for readiness.
+	ForumConn *grpc.ClientConn
+
+	// AttachmentMaxSize is the largest upload accepted, in bytes.
+	AttachmentMaxSize int64
+
+	// Roles looks up the role of a user staff act on.
+	Roles *middleware.UserRoles
 }
 
 func NewHandler(connF *grpc.ClientConn, l logger.Logger) *HTTPHandler {
 	return &HTTPHandler{
-		Comment:  pb.NewCommentServiceClient(connF),
-		Post:     pb.NewPostServiceClient(connF),
-		Category: pb.NewCategoryServiceClient(connF),
-		Tag:      pb.NewTagServiceClient(connF),
-		Logger:   l,
+		Comment:    pb.NewCommentServiceClient(connF),
+		Post:       pb.NewPostServiceClient(connF),
+		Category:   pb.NewCategoryServiceClient(connF),
+		Tag:        pb.NewTagServiceClient(connF),
+		Report:     pb.NewReportServiceClient(connF),
+		Moderation: pb.NewModerationServiceClient(connF),
+		Attachment: pb.NewAttachmentServiceClient(connF),
+		Logger:     l,
+		ForumConn:  connF,
 	}
 }
@@ -0,0 +1,386 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"api-gateway/api/middleware"
+	pb "api-gateway/forum-protos/genprotos"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt"
+	"google.golang.org/grpc"
+)
+
+type ModerationReasonReq struct {
+	Reason string `json:"reason"`
+}
+
+// ModerationReports handles listing the moderation queue.
+// @Summary Moderation queue
+// @Description List reports, open ones oldest first by default
+// @Tags moderation
+// @Produce json
+// @Param status query string false "open or resolved"
+// @Param content_type query string false "post or comment"
+// @Param content_id query string false "content_id"
+// @Param limit query integer false "limit"
+// @Param offset query integer false "offset"
+// @Success 200 {object} pb.ReportGARes
+// @Failure 400 {object} string "Invalid parameters"
+// @Failure 403 {object} string "Moderators only"
+// @Failure 500 {object} string "Server error"
+// @Security BearerAuth
+// @Router /moderation/reports [GET]
+func (h *HTTPHandler) ModerationReports(c *gin.Context) {
+	pagination, ok := parsePagination(c)
+	if !ok {
+		return
+	}
+	res, err := h.Moderation.GetReports(c, &pb.ReportGAReq{
+		Filter: &pb.ReportFilter{
+			Status:      c.Query("status"),
+			ContentType: c.Query("content_type"),
+			ContentId:   c.Query("content_id"),
+		},
+		Pagination: pagination,
+	})
+	if err != nil {
+		c.JSON(httpStatus(err), gin.H{"error": "Couldn't get reports", "details": errorMessage(err)})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// ModerationResolveReport handles resolving a report.
+// @Summary Resolve report
+// @Description Resolve a report and every other open report on the same content
+// @Tags moderation
+// @Accept json
+// @Produce json
+// @Param id path string true "Report ID"
+// @Param resolution body pb.ReportResolveReqForSwagger true "action is dismiss, hide, delete or warn"
+// @Success 200 {object} string "Report resolved"
+// @Failure 400 {object} string "Invalid request payload"
+// @Failure 403 {object} string "Moderators only"
+// @Failure 409 {object} string "Report already resolved"
+// @Failure 500 {object} string "Server error"
+// @Security BearerAuth
+// @Router /moderation/reports/{id}/resolve [POST]
+func (h *HTTPHandler) ModerationResolveReport(c *gin.Context) {
+	var req pb.ReportResolveReq
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	req.ReportId = c.Param("id")
+	req.ModeratorId = c.MustGet("claims").(jwt.MapClaims)["user_id"].(string)
+
+	_, err := h.Moderation.ResolveReport(c, &req)
+	if err != nil {
+		c.JSON(httpStatus(err), gin.H{"error": "Couldn't resolve report", "details": errorMessage(err)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Report resolved"})
+}
+
+// ModerationLog handles listing moderation actions.
+// @Summary Moderation log
+// @Description List moderation actions, newest first
+// @Tags moderation
+// @Produce json
+// @Param moderator_id query string false "moderator_id"
+// @Param user_id query string false "Author of the moderated content"
+// @Param content_id query string false "content_id"
+// @Param action query string false "action"
+// @Param limit query integer false "limit"
+// @Param offset query integer false "offset"
+// @Success 200 {object} pb.ModerationLogGARes
+// @Failure 400 {object} string "Invalid parameters"
+// @Failure 403 {object} string "Moderators only"
+// @Failure 500 {object} string "Server error"
+// @Security BearerAuth
+// @Router /moderation/log [GET]
+func (h *HTTPHandler) ModerationLog(c *gin.Context) {
+	pagination, ok := parsePagination(c)
+	if !ok {
+		return
+	}
+	res, err := h.Moderation.GetLog(c, &pb.ModerationLogGAReq{
+		Filter: &pb.ModerationLogFilter{
+			ModeratorId: c.Query("moderator_id"),
+			UserId:      c.Query("user_id"),
+			ContentId:   c.Query("content_id"),
+			Action:      c.Query("action"),
+		},
+		Pagination: pagination,
+	})
+	if err != nil {
+		c.JSON(httpStatus(err), gin.H{"error": "Couldn't get moderation log", "details": errorMessage(err)})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// ModerationApprovePost handles publishing a held post.
+// @Summary Approve post
+// @Description Publish a post the moderation pipeline held for review
+// @Tags moderation
+// @Accept json
+// @Produce json
+// @Param id path string true "Post ID"
+// @Param reason body ModerationReasonReq false "Reason"
+// @Success 200 {object} string "Post approved"
+// @Failure 403 {object} string "Moderators only"
+// @Failure 500 {object} string "Server error"
+// @Security BearerAuth
+// @Router /moderation/posts/{id}/approve [POST]
+func (h *HTTPHandler) ModerationApprovePost(c *gin.Context) {
+	h.moderate(c, h.Moderation.ApprovePost, "Post approved")
+}
+
+// ModerationRejectPost handles rejecting a post.
+// @Summary Reject post
+// @Description Reject a post held for review or take down a published one
+// @Tags moderation
+// @Accept json
+// @Produce json
+// @Param id path string true "Post ID"
+// @Param reason body ModerationReasonReq false "Reason"
+// @Success 200 {object} string "Post rejected"
+// @Failure 403 {object} string "Moderators only"
+// @Failure 500 {object} string "Server error"
+// @Security BearerAuth
+// @Router /moderation/posts/{id}/reject [POST]
+func (h *HTTPHandler) ModerationRejectPost(c *gin.Context) {
+	h.moderate(c, h.Moderation.RejectPost, "Post rejected")
+}
+
+// ModerationApproveComment handles publishing a held comment.
+// @Summary Approve comment
+// @Description Publish a comment the moderation pipeline held for review
+// @Tags moderation
+// @Accept json
+// @Produce json
+// @Param id path string true "Comment ID"
+// @Param reason body ModerationReasonReq false "Reason"
+// @Success 200 {object} string "Comment approved"
+// @Failure 403 {object} string "Moderators only"
+// @Failure 500 {object} string "Server error"
+// @Security BearerAuth
+// @Router /moderation/comments/{id}/approve [POST]
+func (h *HTTPHandler) ModerationApproveComment(c *gin.Context) {
+	h.moderate(c, h.Moderation.ApproveComment, "Comment approved")
+}
+
+// ModerationRejectComment handles rejecting a comment.
+// @Summary Reject comment
+// @Description Reject a comment held for review or take down a published one
+// @Tags moderation
+// @Accept json
+// @Produce json
+// @Param id path string true "Comment ID"
+// @Param reason body ModerationReasonReq false "Reason"
+// @Success 200 {object} string "Comment rejected"
+// @Failure 403 {object} string "Moderators only"
+// @Failure 500 {object} string "Server error"
+// @Security BearerAuth
+// @Router /moderation/comments/{id}/reject [POST]
+func (h *HTTPHandler) ModerationRejectComment(c *gin.Context) {
+	h.moderate(c, h.Moderation.RejectComment, "Comment rejected")
+}
+
+type moderateFunc func(context.Context, *pb.ModerationDecision, ...grpc.CallOption) (*pb.Void, error)
+
+func (h *HTTPHandler) moderate(c *gin.Context, decide moderateFunc, message string) {
+	var body ModerationReasonReq
+	if c.Request.ContentLength > 0 {
+		if err := c.BindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+			return
+		}
+	}
+	_, err := decide(c, &pb.ModerationDecision{
+		Id:          c.Param("id"),
+		ModeratorId: c.MustGet("claims").(jwt.MapClaims)["user_id"].(string),
+		Reason:      body.Reason,
+	})
+	if err != nil {
+		c.JSON(httpStatus(err), gin.H{"error": "Couldn't moderate content", "details": errorMessage(err)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": message})
+}
+
+// ModerationMuteUser handles making a user read-only.
+// @Summary Mute user
+// @Description Stop a user from creating or editing posts and comments
+// @Tags moderation
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param mute body pb.MuteReqForSwagger true "Reason and optional duration like \"72h\"; without one the mute is permanent"
+// @Success 200 {object} string "User muted"
+// @Failure 400 {object} string "Invalid request payload"
+// @Failure 403 {object} string "Moderators only, and only for users ranked below the caller"
+// @Failure 404 {object} string "User not found"
+// @Failure 500 {object} string "Server error"
+// @Security BearerAuth
+// @Router /moderation/users/{id}/mute [POST]
+func (h *HTTPHandler) ModerationMuteUser(c *gin.Context) {
+	var req pb.MuteReq
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	req.UserId = c.Param("id")
+	req.ModeratorId = c.MustGet("claims").(jwt.MapClaims)["user_id"].(string)
+
+	// forum-service compares the user's role with the caller's.
+	role, err := h.Roles.Role(req.UserId)
+	if errors.Is(err, middleware.ErrUnknownUser) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Couldn't look up user", "details": err.Error()})
+		return
+	}
+	req.TargetRole = role
+
+	_, err = h.Moderation.MuteUser(c, &req)
+	if err != nil {
+		c.JSON(httpStatus(err), gin.H{"error": "Couldn't mute user", "details": errorMessage(err)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "User muted"})
+}
+
+// ModerationUnmuteUser handles lifting a mute.
+// @Summary Unmute user
+// @Description Let a muted user write again
+// @Tags moderation
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} string "User unmuted"
+// @Failure 403 {object} string "Moderators only"
+// @Failure 500 {object} string "Server error"
+// @Security BearerAuth
+// @Router /moderation/users/{id}/mute [DELETE]
+func (h *HTTPHandler) ModerationUnmuteUser(c *gin.Context) {
+	_, err := h.Moderation.UnmuteUser(c, &pb.MuteReq{
+		UserId:      c.Param("id"),
+		ModeratorId: c.MustGet("claims").(jwt.MapClaims)["user_id"].(string),
+	})
+	if err != nil {
+		c.JSON(httpStatus(err), gin.H{"error": "Couldn't unmute user", "details": errorMessage(err)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "User unmuted"})
+}
+
+// ModerationLockPost handles locking a post.
+// @Summary Lock post
+// @Description Stop new comments on a post
+// @Tags moderation
+// @Produce json
+// @Param id path string true "Post ID"
+// @Success 200 {object} pb.PostCReqOrCResOrGResOrUResp
+// @Failure 403 {object} string "Moderators only"
+// @Failure 500 {object} string "Server error"
+// @Security BearerAuth
+// @Router /moderation/posts/{id}/lock [POST]
+func (h *HTTPHandler) ModerationLockPost(c *gin.Context) {
+	h.setPostFlag(c, h.Moderation.LockPost, true)
+}
+
+// ModerationUnlockPost handles unlocking a post.
+// @Summary Unlock post
+// @Description Allow new comments on a locked post
+// @Tags moderation
+// @Produce json
+// @Param id path string true "Post ID"
+// @Success 200 {object} pb.PostCReqOrCResOrGResOrUResp
+// @Failure 403 {object} string "Moderators only"
+// @Failure 500 {object} string "Server error"
+// @Security BearerAuth
+// @Router /moderation/posts/{id}/lock [DELETE]
+func (h *HTTPHandler) ModerationUnlockPost(c *gin.Context) {
+	h.setPostFlag(c, h.Moderation.LockPost, false)
+}
+
+// ModerationPinPost handles pinning a post.
+// @Summary Pin post
+// @Description List a post first in its category
+// @Tags moderation
+// @Produce json
+// @Param id path string true "Post ID"
+// @Success 200 {object} pb.PostCReqOrCResOrGResOrUResp
+// @Failure 403 {object} string "Moderators only"
+// @Failure 500 {object} string "Server error"
+// @Security BearerAuth
+// @Router /moderation/posts/{id}/pin [POST]
+func (h *HTTPHandler) ModerationPinPost(c *gin.Context) {
+	h.setPostFlag(c, h.Moderation.PinPost, true)
+}
+
+// ModerationUnpinPost handles unpinning a post.
+// @Summary Unpin post
+// @Description Stop listing a post first in its category
+// @Tags moderation
+// @Produce json
+// @Param id path string true "Post ID"
+// @Success 200 {object} pb.PostCReqOrCResOrGResOrUResp
+// @Failure 403 {object} string "Moderators only"
+// @Failure 500 {object} string "Server error"
+// @Security BearerAuth
+// @Router /moderation/posts/{id}/pin [DELETE]
+func (h *HTTPHandler) ModerationUnpinPost(c *gin.Context) {
+	h.setPostFlag(c, h.Moderation.PinPost, false)
+}
+
+// ModerationFeaturePost handles featuring a post.
+// @Summary Feature post
+// @Description Mark a post as featured
+// @Tags moderation
+// @Produce json
+// @Param id path string true "Post ID"
+// @Success 200 {object} pb.PostCReqOrCResOrGResOrUResp
+// @Failure 403 {object} string "Moderators only"
+// @Failure 500 {object} string "Server error"
+// @Security BearerAuth
+// @Router /moderation/posts/{id}/feature [POST]
+func (h *HTTPHandler) ModerationFeaturePost(c *gin.Context) {
+	h.setPostFlag(c, h.Moderation.FeaturePost, true)
+}
+
+// ModerationUnfeaturePost handles unfeaturing a post.
+// @Summary Unfeature post
+// @Description Remove the featured mark from a post
+// @Tags moderation
+// @Produce json
+// @Param id path string true "Post ID"
+// @Success 200 {object} pb.PostCReqOrCResOrGResOrUResp
+// @Failure 403 {object} string "Moderators only"
+// @Failure 500 {object} string "Server error"
+// @Security BearerAuth
+// @Router /moderation/posts/{id}/feature [DELETE]
+func (h *HTTPHandler) ModerationUnfeaturePost(c *gin.Context) {
+	h.setPostFlag(c, h.Moderation.FeaturePost, false)
+}
+
+type postFlagFunc func(context.Context, *pb.PostFlagReq, ...grpc.CallOption) (*pb.PostCReqOrCResOrGResOrUResp, error)
+
+func (h *HTTPHandler) setPostFlag(c *gin.Context, set postFlagFunc, value bool) {
+	res, err := set(c, &pb.PostFlagReq{
+		PostId:      c.Param("id"),
+		ModeratorId: c.MustGet("claims").(jwt.MapClaims)["user_id"].(string),
+		Value:       value,
+	})
+	if err != nil {
+		c.JSON(httpStatus(err), gin.H{"error": "Couldn't update post", "details": errorMessage(err)})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
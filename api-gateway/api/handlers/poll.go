@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"net/http"
+
+	pb "api-gateway/forum-protos/genprotos"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt"
+)
+
+// PollCreate handles adding a poll to a post.
+// @Summary Create poll
+// @Description Attach a poll to one of your posts. closes_at is optional (RFC 3339); with hide_results, counts stay hidden until the reader votes or the poll closes.
+// @Tags poll
+// @Accept json
+// @Produce json
+// @Param id path string true "Post ID"
+// @Param poll body pb.PollCReqForSwagger true "Poll data"
+// @Success 200 {object} pb.PollRes
+// @Failure 400 {object} string "Invalid poll"
+// @Failure 403 {object} string "Not the author"
+// @Failure 409 {object} string "Post already has a poll"
+// @Failure 500 {object} string "Server error"
+// @Security BearerAuth
+// @Router /post/{id}/poll [POST]
+func (h *HTTPHandler) PollCreate(c *gin.Context) {
+	var req pb.PollCReq
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	req.PostId = c.Param("id")
+	req.UserId = c.MustGet("claims").(jwt.MapClaims)["user_id"].(string)
+
+	res, err := h.Post.CreatePoll(c, &req)
+	if err != nil {
+		c.JSON(httpStatus(err), gin.H{"error": "Couldn't create poll", "details": errorMessage(err)})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// PollGet handles getting the poll of a post.
+// @Summary Get poll
+// @Description Get the poll of a post with its current results
+// @Tags poll
+// @Produce json
+// @Param id path string true "Post ID"
+// @Success 200 {object} pb.PollRes
+// @Failure 404 {object} string "Poll not found"
+// @Failure 500 {object} string "Server error"
+// @Security BearerAuth
+// @Router /post/{id}/poll [GET]
+func (h *HTTPHandler) PollGet(c *gin.Context) {
+	userID, _ := c.MustGet("claims").(jwt.MapClaims)["user_id"].(string)
+	res, err := h.Post.GetPoll(c, &pb.PollGReq{PostId: c.Param("id"), UserId: userID})
+	if err != nil {
+		c.JSON(httpStatus(err), gin.H{"error": "Couldn't get poll", "details": errorMessage(err)})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// PollVote handles voting in a poll.
+// @Summary Vote in poll
+// @Description Vote once; single choice polls take exactly one option
+// @Tags poll
+// @Accept json
+// @Produce json
+// @Param id path string true "Post ID"
+// @Param vote body pb.PollVoteReqForSwagger true "Chosen options"
+// @Success 200 {object} pb.PollRes
+// @Failure 400 {object} string "Invalid vote"
+// @Failure 404 {object} string "Poll not found"
+// @Failure 409 {object} string "Already voted or poll closed"
+// @Failure 500 {object} string "Server error"
+// @Security BearerAuth
+// @Router /post/{id}/poll/vote [POST]
+func (h *HTTPHandler) PollVote(c *gin.Context) {
+	var req pb.PollVoteReq
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	req.PostId = c.Param("id")
+	req.UserId = c.MustGet("claims").(jwt.MapClaims)["user_id"].(string)
+
+	res, err := h.Post.VotePoll(c, &req)
+	if err != nil {
+		c.JSON(httpStatus(err), gin.H{"error": "Couldn't vote", "details": errorMessage(err)})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// PollClose handles closing a poll.
+// @Summary Close poll
+// @Description End voting on the poll of one of your posts
+// @Tags poll
+// @Produce json
+// @Param id path string true "Post ID"
+// @Success 200 {object} pb.PollRes
+// @Failure 403 {object} string "Not the author"
+// @Failure 404 {object} string "Poll not found"
+// @Failure 500 {object} string "Server error"
+// @Security BearerAuth
+// @Router /post/{id}/poll/close [POST]
+func (h *HTTPHandler) PollClose(c *gin.Context) {
+	res, err := h.Post.ClosePoll(c, &pb.PollGReq{
+		PostId: c.Param("id"),
+		UserId: c.MustGet("claims").(jwt.MapClaims)["user_id"].(string),
+	})
+	if err != nil {
+		c.JSON(httpStatus(err), gin.H{"error": "Couldn't close poll", "details": errorMessage(err)})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
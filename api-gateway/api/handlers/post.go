@@ -2,7 +2,6 @@
 package handlers
 
 import (
-	"context"
 	"net/http"
 	"strconv"
 
@@ -21,6 +20,8 @@ import (
 // @Param post body pb.PostCReqForSwagger true "Post data"
 // @Success 200 {object} pb.PostCReqOrCResOrGResOrUResp
 // @Failure 400 {object} string "Invalid request payload"
+// @Failure 409 {object} string "Rejected by moderation"
+// @Failure 403 {object} string "User is muted"
 // @Failure 500 {object} string "Server error"
 // @Security BearerAuth
 // @Router /post [POST]
@@ -40,7 +41,7 @@ func (h *HTTPHandler) PostCreate(c *gin.Context) {
 	req.UserId = user_id
 	res, err := h.Post.Create(c, &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(httpStatus(err), gin.H{"error": errorMessage(err)})
 		return
 	}
 	c.JSON(http.StatusOK, res)
@@ -53,16 +54,18 @@ func (h *HTTPHandler) PostCreate(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param id path string true "Post ID"
+// @Param format query string false "Body format: markdown (default) or html"
 // @Success 200 {object} pb.PostCReqOrCResOrGResOrUResp
 // @Failure 400 {object} string "Invalid post  ID"
+// @Failure 404 {object} string "Post not found"
 // @Failure 500 {object} string "Server error"
 // @Security BearerAuth
 // @Router /post/{id} [GET]
 func (h *HTTPHandler) PostGet(c *gin.Context) {
-	id := &pb.PostGReqOrDReq{PostId: c.Param("id")}
+	id := &pb.PostGReqOrDReq{PostId: c.Param("id"), Format: c.Query("format")}
 	res, err := h.Post.GetByID(c, id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Couldn't get post", "details": err.Error()})
+		c.JSON(httpStatus(err), gin.H{"error": "Couldn't get post", "details": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, res)
@@ -78,6 +81,7 @@ func (h *HTTPHandler) PostGet(c *gin.Context) {
 // @Param post body pb.PostCReqForSwagger true "Updated post data"
 // @Success 200 {object} pb.PostCReqOrCResOrGResOrUResp
 // @Failure 400 {object} string "Invalid request payload"
+// @Failure 403 {object} string "User is muted"
 // @Failure 404 {object} string "Post not found"
 // @Failure 500 {object} string "Server error"
 // @Security BearerAuth
@@ -94,7 +98,7 @@ func (h *HTTPHandler) PostUpdate(c *gin.Context) {
 	req.PostId = id
 	res, err := h.Post.Update(c, &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Couldn't update post ", "details": err.Error()})
+		c.JSON(httpStatus(err), gin.H{"error": "Couldn't update post ", "details": errorMessage(err)})
 		return
 	}
 	c.JSON(http.StatusOK, res)
@@ -117,7 +121,7 @@ func (h *HTTPHandler) PostDelete(c *gin.Context) {
 	id := &pb.PostGReqOrDReq{PostId: c.Param("id")}
 	_, err := h.Post.Delete(c, id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Couldn't delete post ", "details": err.Error()})
+		c.JSON(httpStatus(err), gin.H{"error": "Couldn't delete post ", "details": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "Post deleted"})
@@ -125,7 +129,7 @@ func (h *HTTPHandler) PostDelete(c *gin.Context) {
 
 // PostGetAll handles getting all post s.
 // @Summary Get all posts
-// @Description Get all posts
+// @Description Get all posts, newest first. With category_id, pinned posts come first.
 // @Tags post
 // @Accept json
 // @Produce json
@@ -134,8 +138,11 @@ func (h *HTTPHandler) PostDelete(c *gin.Context) {
 // @Param title query string false "title"
 // @Param body query string false "body"
 // @Param tags query string false "tags"
+// @Param featured query boolean false "Only featured posts"
+// @Param answered query boolean false "Only questions in Q&A categories with (true) or without (false) an accepted answer"
 // @Param limit query integer false "limit"
 // @Param offset query integer false "offset"
+// @Param format query string false "Body format: markdown (default) or html"
 // @Success 200 {object} pb.PostGARes
 // @Failure 400 {object} string "Invalid parameters"
 // @Failure 500 {object} string "Server error"
@@ -149,6 +156,7 @@ func (h *HTTPHandler) PostGetAll(c *gin.Context) {
 	title := c.Query("title")
 	body := c.Query("body")
 	tags := c.Query("tags")
+	featured := c.Query("featured") == "true"
 
 	var limit, offset int
 	var err error
@@ -171,23 +179,78 @@ func (h *HTTPHandler) PostGetAll(c *gin.Context) {
 		}
 	}
 
-	res, err := h.Post.GetAll(context.Background(), &pb.PostGAReq{
+	res, err := h.Post.GetAll(c, &pb.PostGAReq{
 		Filter: &pb.PostFilter{
 			UserId:     userId,
 			CategoryId: categoryId,
 			Title:      title,
 			Body:       body,
 			Tags:       tags,
+			Featured:   featured,
+			Answered:   c.Query("answered"),
 		},
 		Pagination: &pb.Pagination{
 			Limit:  int64(limit),
 			Offset: int64(offset),
 		},
+		Format: c.Query("format"),
 	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Couldn't get posts", "details": err.Error()})
+		c.JSON(httpStatus(err), gin.H{"error": "Couldn't get posts", "details": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, res)
 }
+
+// PostAcceptAnswer handles accepting an answer to a question.
+// @Summary Accept answer
+// @Description Mark a comment as the answer to your question. Only posts in Q&A categories have answers.
+// @Tags post
+// @Produce json
+// @Param id path string true "Post ID"
+// @Param comment_id path string true "Comment ID"
+// @Success 200 {object} pb.PostCReqOrCResOrGResOrUResp
+// @Failure 400 {object} string "Comment doesn't belong to the post"
+// @Failure 403 {object} string "Not the author"
+// @Failure 404 {object} string "Post or comment not found"
+// @Failure 409 {object} string "Not a Q&A category"
+// @Failure 500 {object} string "Server error"
+// @Security BearerAuth
+// @Router /post/{id}/answer/{comment_id} [POST]
+func (h *HTTPHandler) PostAcceptAnswer(c *gin.Context) {
+	res, err := h.Post.AcceptAnswer(c, &pb.AcceptAnswerReq{
+		PostId:    c.Param("id"),
+		CommentId: c.Param("comment_id"),
+		UserId:    c.MustGet("claims").(jwt.MapClaims)["user_id"].(string),
+	})
+	if err != nil {
+		c.JSON(httpStatus(err), gin.H{"error": "Couldn't accept answer", "details": errorMessage(err)})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// PostUnacceptAnswer handles clearing the accepted answer.
+// @Summary Unaccept answer
+// @Description Clear the accepted answer of your question
+// @Tags post
+// @Produce json
+// @Param id path string true "Post ID"
+// @Success 200 {object} pb.PostCReqOrCResOrGResOrUResp
+// @Failure 403 {object} string "Not the author"
+// @Failure 404 {object} string "Post not found"
+// @Failure 500 {object} string "Server error"
+// @Security BearerAuth
+// @Router /post/{id}/answer [DELETE]
+func (h *HTTPHandler) PostUnacceptAnswer(c *gin.Context) {
+	res, err := h.Post.UnacceptAnswer(c, &pb.AcceptAnswerReq{
+		PostId: c.Param("id"),
+		UserId: c.MustGet("claims").(jwt.MapClaims)["user_id"].(string),
+	})
+	if err != nil {
+		c.JSON(httpStatus(err), gin.H{"error": "Couldn't clear answer", "details": errorMessage(err)})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
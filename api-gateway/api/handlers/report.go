@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"net/http"
+
+	pb "api-gateway/forum-protos/genprotos"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt"
+)
+
+// ReportCreate handles reporting a post or comment.
+// @Summary Report content
+// @Description Report an abusive post or comment. Each user can report the same content once.
+// @Tags report
+// @Accept json
+// @Produce json
+// @Param report body pb.ReportCReqForSwagger true "content_type is post or comment"
+// @Success 200 {object} pb.ReportRes
+// @Failure 400 {object} string "Invalid request payload"
+// @Failure 409 {object} string "Already reported"
+// @Failure 500 {object} string "Server error"
+// @Security BearerAuth
+// @Router /report [POST]
+func (h *HTTPHandler) ReportCreate(c *gin.Context) {
+	var req pb.ReportCReq
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	req.ReporterId = c.MustGet("claims").(jwt.MapClaims)["user_id"].(string)
+
+	res, err := h.Report.Create(c, &req)
+	if err != nil {
+		c.JSON(httpStatus(err), gin.H{"error": "Couldn't report content", "details": errorMessage(err)})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
@@ -48,7 +48,7 @@ func (h *HTTPHandler) PopularTagsGet(c *gin.Context) {
 
 	tags, err := h.Tag.GetPopular(c, &pb.Pagination{Limit: int64(limit), Offset: int64(offset)})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get popular tags", "details": err.Error()})
+		c.JSON(httpStatus(err), gin.H{"error": "Failed to get popular tags", "details": err.Error()})
 		return
 	}
 
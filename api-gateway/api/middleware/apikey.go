@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// Scopes an API key can be granted.
+const (
+	ScopeRead    = "read"
+	ScopePost    = "post"
+	ScopeComment = "comment"
+)
+
+var ErrInvalidAPIKey = errors.New("invalid api key")
+
+// APIKeyVerifier resolves API keys through auth-service and caches the result
+// for a short time, so a revoked key stops working within the cache TTL.
+type APIKeyVerifier struct {
+	authURL string
+	ttl     time.Duration
+	client  *http.Client
+
+	mu    sync.Mutex
+	cache map[[sha256.Size]byte]cachedKey
+}
+
+type cachedKey struct {
+	claims  jwt.MapClaims
+	expires time.Time
+}
+
+type introspectResp struct {
+	KeyID    string   `json:"api_key_id"`
+	UserID   string   `json:"user_id"`
+	Email    string   `json:"email"`
+	Username string   `json:"username"`
+	Role     string   `json:"role"`
+	Scopes   []string `json:"scopes"`
+}
+
+func NewAPIKeyVerifier(authURL string, ttl time.Duration) *APIKeyVerifier {
+	return &APIKeyVerifier{
+		authURL: authURL,
+		ttl:     ttl,
+		client:  &http.Client{Timeout: 5 * time.Second},
+		cache:   map[[sha256.Size]byte]cachedKey{},
+	}
+}
+
+// Verify returns claims equivalent to those of a JWT for the key's owner,
+// plus the key's scopes.
+func (v *APIKeyVerifier) Verify(key string) (jwt.MapClaims, error) {
+	sum := sha256.Sum256([]byte(key))
+
+	v.mu.Lock()
+	cached, ok := v.cache[sum]
+	v.mu.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.claims, nil
+	}
+
+	var res introspectResp
+	status, err := postJSON(v.client, v.authURL+"/api-keys/introspect", map[string]string{"key": key}, &res)
+	if err != nil {
+		return nil, fmt.Errorf("introspecting api key: %w", err)
+	}
+	if status == http.StatusUnauthorized {
+		return nil, ErrInvalidAPIKey
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("introspecting api key: auth-service returned %d", status)
+	}
+
+	scopes := make([]interface{}, len(res.Scopes))
+	for i, s := range res.Scopes {
+		scopes[i] = s
+	}
+	claims := jwt.MapClaims{
+		"user_id":    res.UserID,
+		"email":      res.Email,
+		"username":   res.Username,
+		"role":       res.Role,
+		"api_key_id": res.KeyID,
+		"scopes":     scopes,
+	}
+
+	v.mu.Lock()
+	now := time.Now()
+	for k, c := range v.cache {
+		if now.After(c.expires) {
+			delete(v.cache, k)
+		}
+	}
+	v.cache[sum] = cachedKey{claims: claims, expires: now.Add(v.ttl)}
+	v.mu.Unlock()
+
+	return claims, nil
+}
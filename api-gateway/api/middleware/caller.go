@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt"
+	"google.golang.org/grpc/metadata"
+)
+
+// forum-service reads the user a request is made for from these metadata
+// keys, to show held content only to its author and moderators.
+const (
+	UserIDHeader   = "x-user-id"
+	UserRoleHeader = "x-user-role"
+)
+
+// ForwardCaller passes the authenticated user on to forum-service with
+// every gRPC call the handler makes. It has to run after JWTMiddleware.
+func ForwardCaller() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := c.MustGet("claims").(jwt.MapClaims)
+		userID, _ := claims["user_id"].(string)
+		role, _ := claims["role"].(string)
+		ctx := metadata.AppendToOutgoingContext(c.Request.Context(), UserIDHeader, userID, UserRoleHeader, role)
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
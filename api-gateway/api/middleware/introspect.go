@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+)
+
+// postJSON sends in to an auth-service endpoint and decodes a 200 response
+// into out. It returns the response status code.
+func postJSON(client *http.Client, url string, in, out interface{}) (int, error) {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	return decodeJSON(resp, out)
+}
+
+// getJSON fetches an auth-service endpoint and decodes a 200 response into
+// out. It returns the response status code.
+func getJSON(client *http.Client, url string, out interface{}) (int, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return 0, err
+	}
+	return decodeJSON(resp, out)
+}
+
+func decodeJSON(resp *http.Response, out interface{}) (int, error) {
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return resp.StatusCode, nil
+	}
+	return resp.StatusCode, json.NewDecoder(resp.Body).Decode(out)
+}
@@ -2,12 +2,18 @@ package middleware
 
 import (
 	"api-gateway/api/token"
+	"errors"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt"
 )
 
-func JWTMiddleware() gin.HandlerFunc {
+// JWTMiddleware authenticates requests with either a JWT ("Bearer <token>"
+// or the bare token) or an API key ("ApiKey <key>"). JWTs bound to a session
+// are refused once the session is revoked or the user banned.
+func JWTMiddleware(keys *APIKeyVerifier, sessions *SessionChecker) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -15,6 +21,25 @@ func JWTMiddleware() gin.HandlerFunc {
 			c.Abort()
 			return
 		}
+
+		if key, ok := strings.CutPrefix(authHeader, "ApiKey "); ok {
+			claims, err := keys.Verify(strings.TrimSpace(key))
+			if errors.Is(err, ErrInvalidAPIKey) {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
+				c.Abort()
+				return
+			}
+			if err != nil {
+				c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Couldn't verify API key", "details": err.Error()})
+				c.Abort()
+				return
+			}
+			c.Set("claims", claims)
+			c.Next()
+			return
+		}
+
+		authHeader = strings.TrimPrefix(authHeader, "Bearer ")
 		valid, err := token.ValidateToken(authHeader)
 		if err != nil || !valid {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token", "details": err.Error()})
@@ -28,7 +53,77 @@ func JWTMiddleware() gin.HandlerFunc {
 			c.Abort()
 			return
 		}
+		if claims["type"] == token.ChallengeType {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Two-factor authentication required"})
+			c.Abort()
+			return
+		}
+		if claims["type"] == token.RefreshType {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh tokens can't be used as access tokens"})
+			c.Abort()
+			return
+		}
+		if sessionID, ok := claims["session_id"].(string); ok {
+			state, err := sessions.Check(sessionID)
+			if err != nil {
+				c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Couldn't verify session", "details": err.Error()})
+				c.Abort()
+				return
+			}
+			if state.Banned {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Account is banned"})
+				c.Abort()
+				return
+			}
+			if !state.Active {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Session has been revoked"})
+				c.Abort()
+				return
+			}
+		}
 		c.Set("claims", claims)
 		c.Next()
 	}
 }
+
+// RequireScope limits requests authenticated with an API key to keys granted
+// scope. Requests authenticated with a JWT act as the user and pass. Without
+// a scope, API keys are refused altogether.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, exists := c.Get("claims")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			c.Abort()
+			return
+		}
+		scopes, isAPIKey := claims.(jwt.MapClaims)["scopes"].([]interface{})
+		if !isAPIKey {
+			c.Next()
+			return
+		}
+		for _, s := range scopes {
+			if scope != "" && s == scope {
+				c.Next()
+				return
+			}
+		}
+		c.JSON(http.StatusForbidden, gin.H{"error": "API key is missing the required scope", "scope": scope})
+		c.Abort()
+	}
+}
+
+// RequireRole only lets through users whose role is one of roles.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role, _ := c.MustGet("claims").(jwt.MapClaims)["role"].(string)
+		for _, r := range roles {
+			if role == r {
+				c.Next()
+				return
+			}
+		}
+		c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
+		c.Abort()
+	}
+}
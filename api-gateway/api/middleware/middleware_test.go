@@ -0,0 +1,96 @@
+package middleware_test
+
+import (
+	"api-gateway/api/middleware"
+	"api-gateway/api/token"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestRefreshTokenIsNotAnAccessToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	// Neither verifier is reached for a token refused on its type.
+	router.GET("/protected", middleware.JWTMiddleware(nil, nil), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	tokens := token.GenerateJWTToken("user1", "user@example.com", "user")
+	get := func(auth string) int {
+		req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+		req.Header.Set("Authorization", auth)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, get("Bearer "+tokens.AccessToken))
+	assert.Equal(t, http.StatusUnauthorized, get("Bearer "+tokens.RefreshToken))
+}
+
+func TestForwardCaller(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	// Handlers pass the gin.Context to gRPC calls, as in NewRouter.
+	router.ContextWithFallback = true
+	var md metadata.MD
+	router.GET("/protected", middleware.JWTMiddleware(nil, nil), middleware.ForwardCaller(), func(c *gin.Context) {
+		md, _ = metadata.FromOutgoingContext(c)
+		c.Status(http.StatusOK)
+	})
+
+	tokens := token.GenerateJWTToken("user1", "user@example.com", "user")
+	req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+	req.Header.Set("Authorization", "Bearer "+tokens.AccessToken)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, []string{"user1"}, md.Get(middleware.UserIDHeader))
+}
+
+func TestReadYourWrites(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.ContextWithFallback = true
+	group := router.Group("/", middleware.JWTMiddleware(nil, nil), middleware.ReadYourWrites(time.Minute))
+	var md metadata.MD
+	group.GET("/post", func(c *gin.Context) {
+		md, _ = metadata.FromOutgoingContext(c)
+		c.Status(http.StatusOK)
+	})
+	group.POST("/post", func(c *gin.Context) {
+		if c.Query("fail") != "" {
+			c.Status(http.StatusBadRequest)
+			return
+		}
+		c.Status(http.StatusCreated)
+	})
+
+	author := token.GenerateJWTToken("author", "author@example.com", "user")
+	reader := token.GenerateJWTToken("reader", "reader@example.com", "user")
+	do := func(method, target string, tokens *token.Tokens) {
+		md = nil
+		req := httptest.NewRequest(method, target, nil)
+		req.Header.Set("Authorization", "Bearer "+tokens.AccessToken)
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	primary := func() bool { return len(md.Get(middleware.ReadPrimaryHeader)) > 0 }
+
+	do(http.MethodGet, "/post", author)
+	assert.False(t, primary(), "no write yet")
+
+	do(http.MethodPost, "/post?fail=1", author)
+	do(http.MethodGet, "/post", author)
+	assert.False(t, primary(), "a failed write changes nothing")
+
+	do(http.MethodPost, "/post", author)
+	do(http.MethodGet, "/post", author)
+	assert.True(t, primary(), "the author reads their own write")
+	do(http.MethodGet, "/post", reader)
+	assert.False(t, primary(), "other users keep using replicas")
+}
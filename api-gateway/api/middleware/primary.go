@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt"
+	"google.golang.org/grpc/metadata"
+)
+
+// ReadPrimaryHeader makes forum-service answer reads from its primary
+// database instead of a read replica that may lag behind.
+const ReadPrimaryHeader = "x-read-primary"
+
+// ReadYourWrites sends a user's requests to forum-service's primary for
+// window after that user's last successful write through this gateway, so
+// they see what they just posted even while the replicas catch up. It has to
+// run after JWTMiddleware. A zero window turns it off.
+func ReadYourWrites(window time.Duration) gin.HandlerFunc {
+	if window <= 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+	w := &recentWrites{window: window, last: map[string]time.Time{}}
+	return func(c *gin.Context) {
+		claims := c.MustGet("claims").(jwt.MapClaims)
+		userID, _ := claims["user_id"].(string)
+		if w.recent(userID, time.Now()) {
+			ctx := metadata.AppendToOutgoingContext(c.Request.Context(), ReadPrimaryHeader, "true")
+			c.Request = c.Request.WithContext(ctx)
+		}
+		c.Next()
+		if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead && c.Writer.Status() < 400 {
+			w.wrote(userID, time.Now())
+		}
+	}
+}
+
+// recentWrites remembers when users last wrote. Entries older than window
+// are dropped at most once per window.
+type recentWrites struct {
+	window time.Duration
+
+	mu    sync.Mutex
+	last  map[string]time.Time
+	swept time.Time
+}
+
+func (w *recentWrites) recent(userID string, now time.Time) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	at, ok := w.last[userID]
+	return ok && now.Sub(at) < w.window
+}
+
+func (w *recentWrites) wrote(userID string, now time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.last[userID] = now
+	if now.Sub(w.swept) < w.window {
+		return
+	}
+	for id, at := range w.last {
+		if now.Sub(at) >= w.window {
+			delete(w.last, id)
+		}
+	}
+	w.swept = now
+}
@@ -0,0 +1,130 @@
+package middleware
+
+import (
+	"api-gateway/config"
+	"api-gateway/config/logger"
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt"
+)
+
+// RateLimitResult is the state of a bucket after taking a token from it.
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration // until the bucket is full again
+	RetryAfter time.Duration // until the next request is allowed, if denied
+}
+
+// RateLimitStore keeps token buckets. Each bucket holds up to policy.Limit
+// tokens and refills at policy.Limit tokens per policy.Window.
+type RateLimitStore interface {
+	Take(ctx context.Context, key string, policy config.RateLimitPolicy) (RateLimitResult, error)
+}
+
+// IPPolicy is the route name of the policy RateLimitIP applies.
+const IPPolicy = "ip"
+
+// RateLimit throttles requests per route policy. Clients are identified by
+// the user_id claim, the API key or, for anonymous requests, the client IP,
+// so it has to run after JWTMiddleware on protected routes.
+func RateLimit(store RateLimitStore, policies []config.RateLimitPolicy, logger logger.Logger) gin.HandlerFunc {
+	byRoute := map[string]config.RateLimitPolicy{}
+	for _, p := range policies {
+		byRoute[p.Route] = p
+	}
+
+	return func(c *gin.Context) {
+		route := c.Request.Method + " " + c.FullPath()
+		policy, ok := byRoute[route]
+		if !ok {
+			policy, ok = byRoute["default"]
+		}
+		if !ok {
+			c.Next()
+			return
+		}
+		if take(c, store, policy, policy.Route+"|"+rateLimitClient(c), logger) {
+			c.Next()
+		}
+	}
+}
+
+// RateLimitIP throttles all requests from one client IP by the "ip" policy.
+// It runs before JWTMiddleware, so a flood of bad credentials is refused
+// before each one costs a token check or a call to auth-service.
+func RateLimitIP(store RateLimitStore, policies []config.RateLimitPolicy, logger logger.Logger) gin.HandlerFunc {
+	for _, policy := range policies {
+		if policy.Route != IPPolicy {
+			continue
+		}
+		return func(c *gin.Context) {
+			if take(c, store, policy, IPPolicy+"|ip:"+c.ClientIP(), logger) {
+				c.Next()
+			}
+		}
+	}
+	return func(c *gin.Context) { c.Next() }
+}
+
+// take takes a token from key's bucket and sets the RateLimit headers. It
+// reports whether the request may go on; if not, it has been answered.
+func take(c *gin.Context, store RateLimitStore, policy config.RateLimitPolicy, key string, logger logger.Logger) bool {
+	res, err := store.Take(c.Request.Context(), key, policy)
+	if err != nil {
+		// Don't take the API down with the limiter's backend.
+		logger.WARN.Println("rate limiter unavailable:", err)
+		return true
+	}
+
+	c.Header("RateLimit-Policy", fmt.Sprintf("%d;w=%d", policy.Limit, int(policy.Window.Seconds())))
+	c.Header("RateLimit-Limit", strconv.Itoa(res.Limit))
+	c.Header("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	c.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+	if !res.Allowed {
+		c.Header("Retry-After", strconv.Itoa(ceilSeconds(res.RetryAfter)))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many requests"})
+		c.Abort()
+		return false
+	}
+	return true
+}
+
+func rateLimitClient(c *gin.Context) string {
+	if claims, ok := c.Get("claims"); ok {
+		mc := claims.(jwt.MapClaims)
+		if keyID, ok := mc["api_key_id"].(string); ok {
+			return "key:" + keyID
+		}
+		if userID, ok := mc["user_id"].(string); ok {
+			return "user:" + userID
+		}
+	}
+	return "ip:" + c.ClientIP()
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// bucketResult turns the tokens left in a bucket into a RateLimitResult.
+func bucketResult(policy config.RateLimitPolicy, tokens float64, allowed bool) RateLimitResult {
+	perToken := policy.Window / time.Duration(policy.Limit)
+	res := RateLimitResult{
+		Allowed:   allowed,
+		Limit:     policy.Limit,
+		Remaining: int(tokens),
+		Reset:     time.Duration((float64(policy.Limit) - tokens) * float64(perToken)),
+	}
+	if !allowed {
+		res.RetryAfter = time.Duration((1 - tokens) * float64(perToken))
+	}
+	return res
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"api-gateway/config"
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// MemoryRateLimitStore keeps buckets in process memory. Limits are per
+// gateway instance.
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	full    time.Time // when the bucket will be full again
+}
+
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{buckets: map[string]*bucket{}, lastSweep: time.Now()}
+}
+
+func (s *MemoryRateLimitStore) Take(ctx context.Context, key string, policy config.RateLimitPolicy) (RateLimitResult, error) {
+	now := time.Now()
+	rate := float64(policy.Limit) / float64(policy.Window) // tokens per nanosecond
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.lastSweep) > time.Minute {
+		// A full bucket is the same as no bucket, so drop them.
+		for k, b := range s.buckets {
+			if now.After(b.full) {
+				delete(s.buckets, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(policy.Limit), updated: now}
+		s.buckets[key] = b
+	}
+	b.tokens = math.Min(float64(policy.Limit), b.tokens+float64(now.Sub(b.updated))*rate)
+	b.updated = now
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	b.full = now.Add(time.Duration((float64(policy.Limit) - b.tokens) / rate))
+	return bucketResult(policy, b.tokens, allowed), nil
+}
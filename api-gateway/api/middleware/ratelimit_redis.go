@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"api-gateway/config"
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// tokenBucketScript refills and takes from a bucket stored as a hash, so
+// every gateway instance shares the same limits.
+var tokenBucketScript = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local b = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(b[1]) or capacity
+local ts = tonumber(b[2]) or now
+tokens = math.min(capacity, tokens + math.max(0, now - ts) * rate)
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], math.ceil((capacity - tokens) / rate) + 1000)
+return {allowed, tostring(tokens)}
+`)
+
+// RedisRateLimitStore keeps buckets in Redis or any server speaking the
+// Redis protocol with Lua scripting (KeyDB, Valkey, Dragonfly).
+type RedisRateLimitStore struct {
+	client redis.Scripter
+}
+
+func NewRedisRateLimitStore(client redis.Scripter) *RedisRateLimitStore {
+	return &RedisRateLimitStore{client: client}
+}
+
+func (s *RedisRateLimitStore) Take(ctx context.Context, key string, policy config.RateLimitPolicy) (RateLimitResult, error) {
+	rate := float64(policy.Limit) / float64(policy.Window.Milliseconds()) // tokens per millisecond
+	now := time.Now().UnixMilli()
+
+	res, err := tokenBucketScript.Run(ctx, s.client, []string{"ratelimit:" + key}, policy.Limit, rate, now).Slice()
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+	if len(res) != 2 {
+		return RateLimitResult{}, fmt.Errorf("unexpected rate limit script result %v", res)
+	}
+	allowed, _ := res[0].(int64)
+	tokensStr, _ := res[1].(string)
+	tokens, err := strconv.ParseFloat(tokensStr, 64)
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+	return bucketResult(policy, tokens, allowed == 1), nil
+}
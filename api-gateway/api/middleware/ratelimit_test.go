@@ -0,0 +1,74 @@
+package middleware_test
+
+import (
+	"api-gateway/api/middleware"
+	"api-gateway/config"
+	"api-gateway/config/logger"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenBucket(t *testing.T) {
+	store := middleware.NewMemoryRateLimitStore()
+	policy := config.RateLimitPolicy{Route: "default", Limit: 4, Window: 200 * time.Millisecond}
+	ctx := context.Background()
+
+	for i := 3; i >= 0; i-- {
+		res, err := store.Take(ctx, "client", policy)
+		require.NoError(t, err)
+		assert.True(t, res.Allowed)
+		assert.Equal(t, 4, res.Limit)
+		assert.Equal(t, i, res.Remaining)
+	}
+
+	res, err := store.Take(ctx, "client", policy)
+	require.NoError(t, err)
+	assert.False(t, res.Allowed, "the bucket is empty")
+	assert.Equal(t, 0, res.Remaining)
+	// One token comes back every Window/Limit, a full bucket after Window.
+	assert.InDelta(t, float64(50*time.Millisecond), float64(res.RetryAfter), float64(5*time.Millisecond))
+	assert.InDelta(t, float64(200*time.Millisecond), float64(res.Reset), float64(5*time.Millisecond))
+
+	other, err := store.Take(ctx, "other", policy)
+	require.NoError(t, err)
+	assert.True(t, other.Allowed, "buckets are per key")
+
+	time.Sleep(res.RetryAfter + 5*time.Millisecond)
+	res, err = store.Take(ctx, "client", policy)
+	require.NoError(t, err)
+	assert.True(t, res.Allowed, "a token was refilled")
+	assert.Equal(t, 0, res.Remaining)
+}
+
+func TestRateLimitIPRunsBeforeAuthentication(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	policies := []config.RateLimitPolicy{{Route: middleware.IPPolicy, Limit: 2, Window: time.Minute}}
+	router := gin.New()
+	router.GET("/protected",
+		middleware.RateLimitIP(middleware.NewMemoryRateLimitStore(), policies, logger.Logger{}),
+		middleware.JWTMiddleware(nil, nil),
+		func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	get := func(ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+		req.RemoteAddr = ip + ":1234"
+		req.Header.Set("Authorization", "Bearer not-a-token")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, get("192.0.2.1").Code)
+	assert.Equal(t, http.StatusUnauthorized, get("192.0.2.1").Code)
+	w := get("192.0.2.1")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code, "bad credentials are counted too")
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+	assert.Equal(t, http.StatusUnauthorized, get("192.0.2.2").Code, "other addresses have their own bucket")
+}
@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// SessionChecker asks auth-service whether the session a token belongs to is
+// still active and its owner not banned. Answers are cached for ttl, which
+// bounds how long a revoked session or a banned user's token keeps working
+// at the gateway.
+type SessionChecker struct {
+	authURL string
+	ttl     time.Duration
+	client  *http.Client
+
+	mu    sync.Mutex
+	cache map[string]cachedSession
+}
+
+// SessionState is auth-service's answer for a session.
+type SessionState struct {
+	Active bool `json:"active"`
+	Banned bool `json:"banned"`
+}
+
+type cachedSession struct {
+	state   SessionState
+	expires time.Time
+}
+
+func NewSessionChecker(authURL string, ttl time.Duration) *SessionChecker {
+	return &SessionChecker{
+		authURL: authURL,
+		ttl:     ttl,
+		client:  &http.Client{Timeout: 5 * time.Second},
+		cache:   map[string]cachedSession{},
+	}
+}
+
+func (s *SessionChecker) Check(sessionID string) (SessionState, error) {
+	s.mu.Lock()
+	cached, ok := s.cache[sessionID]
+	s.mu.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.state, nil
+	}
+
+	var res SessionState
+	status, err := postJSON(s.client, s.authURL+"/sessions/introspect", map[string]string{"session_id": sessionID}, &res)
+	if err != nil {
+		return SessionState{}, fmt.Errorf("introspecting session: %w", err)
+	}
+	if status != http.StatusOK {
+		return SessionState{}, fmt.Errorf("introspecting session: auth-service returned %d", status)
+	}
+
+	s.mu.Lock()
+	now := time.Now()
+	for id, c := range s.cache {
+		if now.After(c.expires) {
+			delete(s.cache, id)
+		}
+	}
+	s.cache[sessionID] = cachedSession{state: res, expires: now.Add(s.ttl)}
+	s.mu.Unlock()
+
+	return res, nil
+}
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"api-gateway/config"
+	"context"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Timeout puts a deadline on the request context according to the route's
+// timeout, or the "default" one. Handlers pass the context on to the forum
+// service, so the deadline travels with the gRPC call and a client that
+// disconnects cancels the queries it started.
+func Timeout(timeouts []config.RouteTimeout) gin.HandlerFunc {
+	byRoute := map[string]config.RouteTimeout{}
+	for _, t := range timeouts {
+		byRoute[t.Route] = t
+	}
+
+	return func(c *gin.Context) {
+		timeout, ok := byRoute[c.Request.Method+" "+c.FullPath()]
+		if !ok {
+			timeout, ok = byRoute["default"]
+		}
+		if !ok {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout.Timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
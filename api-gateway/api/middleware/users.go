@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+var ErrUnknownUser = errors.New("unknown user")
+
+// UserRoles looks users' roles up in auth-service. Answers aren't cached:
+// they guard staff actions, which are rare and should see a promotion or
+// demotion at once.
+type UserRoles struct {
+	authURL string
+	client  *http.Client
+}
+
+func NewUserRoles(authURL string) *UserRoles {
+	return &UserRoles{
+		authURL: authURL,
+		client:  &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// Role returns the role of the user with userID.
+func (u *UserRoles) Role(userID string) (string, error) {
+	var res struct {
+		Role string `json:"role"`
+	}
+	status, err := getJSON(u.client, u.authURL+"/user/"+url.PathEscape(userID), &res)
+	if err != nil {
+		return "", fmt.Errorf("looking up user: %w", err)
+	}
+	if status == http.StatusNotFound {
+		return "", ErrUnknownUser
+	}
+	if status != http.StatusOK {
+		return "", fmt.Errorf("looking up user: auth-service returned %d", status)
+	}
+	return res.Role, nil
+}
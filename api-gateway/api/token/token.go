@@ -11,6 +11,13 @@ import (
 
 const (
 	signingKey = "mrbek"
+
+	// ChallengeType marks auth-service tokens that only allow finishing a
+	// two-factor login. They must not be accepted as access tokens.
+	ChallengeType = "2fa_challenge"
+	// RefreshType marks auth-service refresh tokens, which are only good
+	// for getting new tokens from auth-service.
+	RefreshType = "refresh"
 )
 
 type Tokens struct {
@@ -37,6 +44,7 @@ func GenerateJWTToken(userID string, email string, username string) *Tokens {
 	rftClaims["user_id"] = userID
 	rftClaims["email"] = email
 	rftClaims["username"] = username
+	rftClaims["type"] = RefreshType
 	rftClaims["iat"] = time.Now().Unix()
 	rftClaims["exp"] = time.Now().Add(24 * time.Hour).Unix() // Refresh token expires in 24 hours
 	refresh, err := refreshToken.SignedString([]byte(signingKey))
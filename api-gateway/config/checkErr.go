@@ -2,6 +2,7 @@ package config
 
 import (
 	"api-gateway/config/logger"
+	"strconv"
 )
 
 type ErrorManager struct {
@@ -14,6 +15,6 @@ func NewErrorManager(logger *logger.Logger) *ErrorManager {
 
 func (e *ErrorManager) CheckErr(err error, line int) {
 	if err != nil {
-		e.logger.ERROR.Panicln(err.Error() + string(line))
+		e.logger.ERROR.Panicln(err.Error() + " (line " + strconv.Itoa(line) + ")")
 	}
 }
@@ -3,17 +3,54 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/spf13/cast"
 )
 
+// RateLimitPolicy allows Limit requests per Window on Route, given as
+// "METHOD /path" with gin's route syntax, "default" for all other routes, or
+// "ip" for all requests from one client IP, counted before authentication.
+type RateLimitPolicy struct {
+	Route  string
+	Limit  int
+	Window time.Duration
+}
+
+// RouteTimeout bounds how long a request to Route may take, including the
+// calls it makes to the forum service. Route uses the same syntax as
+// RateLimitPolicy.
+type RouteTimeout struct {
+	Route   string
+	Timeout time.Duration
+}
+
 type Config struct {
 	HTTPPort string
 
 	FORUM_SERVICE_PORT string
+	AUTH_SERVICE_URL   string
 
 	LOG_PATH string
+
+	API_KEY_CACHE_TTL time.Duration
+	SESSION_CACHE_TTL time.Duration
+
+	RATE_LIMIT_ENABLED    bool
+	RATE_LIMIT_POLICIES   []RateLimitPolicy
+	RATE_LIMIT_REDIS_ADDR string
+
+	ATTACHMENT_MAX_SIZE int64
+
+	REQUEST_TIMEOUTS []RouteTimeout
+
+	// After a user's write, their requests read from forum-service's primary
+	// for this long, so replica lag can't hide their own changes. Zero turns
+	// it off.
+	READ_PRIMARY_WINDOW time.Duration
 }
 
 func Load() Config {
@@ -27,10 +64,75 @@ func Load() Config {
 
 	config.LOG_PATH = cast.ToString(coalesce("LOG_PATH", "logs/info.log"))
 	config.FORUM_SERVICE_PORT = cast.ToString(coalesce("FORUM_SERVICE_PORT", ":50051"))
+	config.AUTH_SERVICE_URL = cast.ToString(coalesce("AUTH_SERVICE_URL", "http://auth-service:8088"))
+
+	config.API_KEY_CACHE_TTL = cast.ToDuration(coalesce("API_KEY_CACHE_TTL", "30s"))
+	config.SESSION_CACHE_TTL = cast.ToDuration(coalesce("SESSION_CACHE_TTL", "15s"))
+
+	config.RATE_LIMIT_ENABLED = cast.ToBool(coalesce("RATE_LIMIT_ENABLED", true))
+	config.RATE_LIMIT_POLICIES = parseRateLimitPolicies(cast.ToString(coalesce("RATE_LIMIT_POLICIES",
+		"ip=600/1m;default=300/1m;POST /post/=10/1m;POST /comment/=30/1m;POST /category=10/1m")))
+	config.RATE_LIMIT_REDIS_ADDR = cast.ToString(coalesce("RATE_LIMIT_REDIS_ADDR", ""))
+
+	config.ATTACHMENT_MAX_SIZE = cast.ToInt64(coalesce("ATTACHMENT_MAX_SIZE", 10<<20))
+
+	config.REQUEST_TIMEOUTS = parseRouteTimeouts(cast.ToString(coalesce("REQUEST_TIMEOUTS",
+		"default=10s;POST /post/:id/attachments=60s;POST /comment/:id/attachments=60s;GET /attachment/:id/file=60s")))
+
+	config.READ_PRIMARY_WINDOW = cast.ToDuration(coalesce("READ_PRIMARY_WINDOW", "10s"))
 
 	return config
 }
 
+// parseRateLimitPolicies reads policies written as "route=limit/window"
+// separated by semicolons, e.g. "default=300/1m;POST /post/=10/1m".
+// Malformed entries are skipped.
+func parseRateLimitPolicies(s string) []RateLimitPolicy {
+	var policies []RateLimitPolicy
+	for _, entry := range strings.Split(s, ";") {
+		route, rule, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok {
+			continue
+		}
+		limit, window, ok := strings.Cut(rule, "/")
+		if !ok {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(limit))
+		if err != nil || n <= 0 {
+			fmt.Println("Skipping rate limit policy:", entry)
+			continue
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(window))
+		if err != nil || d <= 0 {
+			fmt.Println("Skipping rate limit policy:", entry)
+			continue
+		}
+		policies = append(policies, RateLimitPolicy{Route: strings.TrimSpace(route), Limit: n, Window: d})
+	}
+	return policies
+}
+
+// parseRouteTimeouts reads timeouts written as "route=duration" separated by
+// semicolons, e.g. "default=10s;GET /attachment/:id/file=60s". Malformed
+// entries are skipped.
+func parseRouteTimeouts(s string) []RouteTimeout {
+	var timeouts []RouteTimeout
+	for _, entry := range strings.Split(s, ";") {
+		route, value, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok {
+			continue
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil || d <= 0 {
+			fmt.Println("Skipping request timeout:", entry)
+			continue
+		}
+		timeouts = append(timeouts, RouteTimeout{Route: strings.TrimSpace(route), Timeout: d})
+	}
+	return timeouts
+}
+
 func coalesce(key string, defaultValue interface{}) interface{} {
 	val, exists := os.LookupEnv(key)
 
@@ -0,0 +1,19 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRateLimitPolicies(t *testing.T) {
+	policies := parseRateLimitPolicies(" ip=600/1m; default=300/1m;POST /post/=10/30s;broken;GET /x=0/1m;GET /y=5/never;GET /z=5")
+	assert.Equal(t, []RateLimitPolicy{
+		{Route: "ip", Limit: 600, Window: time.Minute},
+		{Route: "default", Limit: 300, Window: time.Minute},
+		{Route: "POST /post/", Limit: 10, Window: 30 * time.Second},
+	}, policies, "malformed entries are skipped")
+
+	assert.Empty(t, parseRateLimitPolicies(""))
+}
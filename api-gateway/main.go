@@ -24,12 +24,15 @@ func main() {
 	em := cf.NewErrorManager(logger)
 	cll := fmt.Sprintf("forum-service%s", ":50051")
 	//fmt.Println(cll, "2343244444")
-	ForumConn, err := grpc.NewClient(cll, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	// Attachments travel in a single message each way.
+	maxMsg := int(config.ATTACHMENT_MAX_SIZE) + 1<<20
+	ForumConn, err := grpc.NewClient(cll, grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.MaxCallSendMsgSize(maxMsg), grpc.MaxCallRecvMsgSize(maxMsg)))
 	fmt.Println(ForumConn, 1111111111111)
 	em.CheckErr(err, 27)
 	defer ForumConn.Close()
 
-	r := api.NewRouter(ForumConn, *logger)
+	r := api.NewRouter(ForumConn, *logger, config)
 
 	fmt.Printf("Server started on port %s\n", config.HTTPPort)
 	logger.INFO.Println("Server started on port: " + config.HTTPPort)
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"auth-service/models"
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UnlockUser godoc
+// @Summary Unlock a user account
+// @Description Clear failed login attempts and the lockout of a user account
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} string "Account unlocked"
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 403 {object} string "Forbidden"
+// @Failure 404 {object} string "User not found"
+// @Failure 500 {object} string "Server error"
+// @Security BearerAuth
+// @Router /admin/users/{id}/unlock [post]
+func (h *HTTPHandler) UnlockUser(c *gin.Context) {
+	user, err := h.US.GetByID(&models.GetProfileByIdReq{ID: c.Param("id")})
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error", "err": err.Error()})
+		return
+	}
+
+	if err := h.LS.Unlock(user.Email); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error", "err": err.Error()})
+		return
+	}
+	h.Logger.INFO.Println("account unlocked:", user.ID)
+	c.JSON(http.StatusOK, gin.H{"message": "Account unlocked"})
+}
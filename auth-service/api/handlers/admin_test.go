@@ -0,0 +1,31 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnlockUser(t *testing.T) {
+	h, mock := newHandler(t)
+	admin := jwt.MapClaims{"user_id": "admin1", "role": "admin"}
+
+	mock.ExpectQuery("SELECT id, username, email, role FROM users WHERE id = \\$1").
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "email", "role"}).AddRow(userID, "user", email, "user"))
+	mock.ExpectExec("DELETE FROM login_throttles WHERE key = \\$1").
+		WithArgs("email:" + email).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	w := serve(h.UnlockUser, http.MethodPost, "/admin/users/"+userID+"/unlock", "/admin/users/:id/unlock", nil, admin)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	mock.ExpectQuery("SELECT id, username, email, role FROM users WHERE id = \\$1").
+		WithArgs("missing").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "email", "role"}))
+	w = serve(h.UnlockUser, http.MethodPost, "/admin/users/missing/unlock", "/admin/users/:id/unlock", nil, admin)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"auth-service/models"
+	"auth-service/service"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt"
+)
+
+// CreateAPIKey godoc
+// @Summary Create an API key
+// @Description Create a named API key with scopes (read, post, comment). The key is returned only once.
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Param key body models.APIKeyCreateReq true "Key name and scopes"
+// @Success 201 {object} models.APIKeyCreateResp
+// @Failure 400 {object} string "Invalid request payload"
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 500 {object} string "Server error"
+// @Security BearerAuth
+// @Router /api-keys [post]
+func (h *HTTPHandler) CreateAPIKey(c *gin.Context) {
+	claims := c.MustGet("claims").(jwt.MapClaims)
+	req := models.APIKeyCreateReq{}
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Invalid request payload": err.Error()})
+		return
+	}
+
+	res, err := h.KS.Create(claims["user_id"].(string), &req)
+	if errors.Is(err, service.ErrInvalidAPIKeyReq) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error", "err": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, res)
+}
+
+// ListAPIKeys godoc
+// @Summary List API keys
+// @Description List the API keys of the authenticated user, including revoked ones
+// @Tags api-keys
+// @Produce json
+// @Success 200 {object} models.APIKeyListResp
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 500 {object} string "Server error"
+// @Security BearerAuth
+// @Router /api-keys [get]
+func (h *HTTPHandler) ListAPIKeys(c *gin.Context) {
+	claims := c.MustGet("claims").(jwt.MapClaims)
+
+	res, err := h.KS.GetAll(claims["user_id"].(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error", "err": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// RevokeAPIKey godoc
+// @Summary Revoke an API key
+// @Description Revoke one of the authenticated user's API keys
+// @Tags api-keys
+// @Produce json
+// @Param id path string true "API key ID"
+// @Success 200 {object} string "API key revoked"
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 404 {object} string "API key not found"
+// @Failure 500 {object} string "Server error"
+// @Security BearerAuth
+// @Router /api-keys/{id} [delete]
+func (h *HTTPHandler) RevokeAPIKey(c *gin.Context) {
+	claims := c.MustGet("claims").(jwt.MapClaims)
+
+	err := h.KS.Revoke(claims["user_id"].(string), c.Param("id"))
+	if errors.Is(err, service.ErrAPIKeyNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error", "err": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "API key revoked"})
+}
+
+// IntrospectAPIKey godoc
+// @Summary Introspect an API key
+// @Description Resolve an API key into its owner and scopes. Used by api-gateway.
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Param key body models.APIKeyIntrospectReq true "API key"
+// @Success 200 {object} models.APIKeyIntrospectResp
+// @Failure 400 {object} string "Invalid request payload"
+// @Failure 401 {object} string "Invalid API key"
+// @Failure 500 {object} string "Server error"
+// @Router /api-keys/introspect [post]
+func (h *HTTPHandler) IntrospectAPIKey(c *gin.Context) {
+	req := models.APIKeyIntrospectReq{}
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Invalid request payload": err.Error()})
+		return
+	}
+
+	res, err := h.KS.Introspect(req.Key)
+	if errors.Is(err, service.ErrInvalidAPIKey) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error", "err": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,68 @@
+package handlers_test
+
+import (
+	"auth-service/models"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+const keyID = "3c9d6e1f-4a2b-4c8d-9e7f-1a2b3c4d5e6f"
+
+func TestCreateAPIKey(t *testing.T) {
+	h, mock := newHandler(t)
+	claims := jwt.MapClaims{"user_id": userID}
+
+	w := serve(h.CreateAPIKey, http.MethodPost, "/api-keys", "/api-keys", models.APIKeyCreateReq{Name: "bot", Scopes: []string{"admin"}}, claims)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	mock.ExpectQuery("INSERT INTO api_keys").
+		WithArgs(sqlmock.AnyArg(), userID, "bot", sqlmock.AnyArg(), sqlmock.AnyArg(), "read").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "name", "prefix", "scopes", "created_at", "last_used_at", "revoked_at"}).
+			AddRow(keyID, userID, "bot", "fk_abcdefgh", "read", time.Now(), nil, nil))
+	w = serve(h.CreateAPIKey, http.MethodPost, "/api-keys", "/api-keys", models.APIKeyCreateReq{Name: "bot", Scopes: []string{"read"}}, claims)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"key":"fk_`)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRevokeAPIKey(t *testing.T) {
+	h, mock := newHandler(t)
+	claims := jwt.MapClaims{"user_id": userID}
+
+	for _, revoked := range []int64{1, 0} {
+		mock.ExpectExec("UPDATE api_keys SET revoked_at = NOW\\(\\)").
+			WithArgs(keyID, userID).
+			WillReturnResult(sqlmock.NewResult(0, revoked))
+	}
+	w := serve(h.RevokeAPIKey, http.MethodDelete, "/api-keys/"+keyID, "/api-keys/:id", nil, claims)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = serve(h.RevokeAPIKey, http.MethodDelete, "/api-keys/"+keyID, "/api-keys/:id", nil, claims)
+	assert.Equal(t, http.StatusNotFound, w.Code, "another user's or an already revoked key")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestIntrospectAPIKey(t *testing.T) {
+	h, mock := newHandler(t)
+	const key = "fk_0123456789abcdefghijklmnopqrstuvwxyzABCDE"
+
+	mock.ExpectQuery("UPDATE api_keys k SET last_used_at").
+		WithArgs(sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "email", "username", "role", "scopes"}).
+			AddRow(keyID, userID, email, "user", "user", "read,comment"))
+	w := serve(h.IntrospectAPIKey, http.MethodPost, "/api-keys/introspect", "/api-keys/introspect", models.APIKeyIntrospectReq{Key: key}, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), userID)
+
+	// Revoked keys and keys of banned users don't match.
+	mock.ExpectQuery("UPDATE api_keys k SET last_used_at").
+		WithArgs(sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "email", "username", "role", "scopes"}))
+	w = serve(h.IntrospectAPIKey, http.MethodPost, "/api-keys/introspect", "/api-keys/introspect", models.APIKeyIntrospectReq{Key: key}, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -3,7 +3,11 @@ package handlers
 import (
 	"auth-service/api/token"
 	"auth-service/models"
+	"database/sql"
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt"
@@ -54,21 +58,30 @@ func (h *HTTPHandler) Register(c *gin.Context) {
 		return
 	}
 
-	tokens := token.GenerateJWTToken(req.ID, req.Email, req.Username)
+	tokens, err := h.issueTokens(c, req.ID, req.Email, req.Username, "user")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error", "err": err.Error()})
+		return
+	}
 
 	c.JSON(http.StatusCreated, tokens)
 }
 
 // Login godoc
 // @Summary Login a user
-// @Description Authenticate user with email and password
+// @Description Authenticate user with email and password. Repeated failures are throttled per account and per client IP.
+// @Description Accounts with two-factor authentication get a challenge token to finish the login at /login/2fa.
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param credentials body models.LoginReq true "User login credentials"
 // @Success 200 {object} token.Tokens "JWT tokens"
+// @Success 202 {object} models.TwoFactorChallengeResp "Two-factor code required"
 // @Failure 400 {object} string "Invalid request payload"
 // @Failure 401 {object} string "Invalid email or password"
+// @Failure 403 {object} string "Account is banned"
+// @Failure 429 {object} string "Too many login attempts"
+// @Failure 500 {object} string "Server error"
 // @Router /login [post]
 func (h *HTTPHandler) Login(c *gin.Context) {
 	req := models.LoginReq{}
@@ -78,21 +91,84 @@ func (h *HTTPHandler) Login(c *gin.Context) {
 		return
 	}
 
+	attempt := models.LoginAttempt{
+		Email:     req.Email,
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+
+	wait, err := h.LS.Check(req.Email, attempt.IPAddress)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error", "err": err.Error()})
+		return
+	}
+	if wait > 0 {
+		attempt.Reason = models.LoginThrottled
+		if err := h.LS.Fail(attempt); err != nil {
+			h.Logger.ERROR.Println("recording login attempt:", err)
+		}
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many login attempts, try again later"})
+		return
+	}
+
 	user, err := h.US.GetProfile(&models.GetProfileReq{Email: req.Email})
+	if errors.Is(err, sql.ErrNoRows) {
+		// Spend the same time as for a known email so response timing
+		// doesn't reveal which emails are registered.
+		bcrypt.CompareHashAndPassword(dummyHash, []byte(req.Password))
+		attempt.Reason = models.LoginUnknownEmail
+		h.loginFailed(c, attempt)
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"Invalid email or password": err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error", "err": err.Error()})
 		return
 	}
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"Invalid email or password...": err.Error()})
+		attempt.UserID = user.ID
+		attempt.Reason = models.LoginWrongPassword
+		h.loginFailed(c, attempt)
+		return
+	}
+
+	if h.refuseBanned(c, user.ID) {
+		return
+	}
+
+	if user.TwoFactorEnabled {
+		c.JSON(http.StatusAccepted, models.TwoFactorChallengeResp{
+			TwoFactorRequired: true,
+			ChallengeToken:    token.GenerateChallengeToken(user.ID, user.Email, h.TS.ChallengeTTL()),
+		})
 		return
 	}
 
-	tokens := token.GenerateJWTToken(user.ID, user.Email, user.Username)
+	if err := h.LS.Succeed(user.Email); err != nil {
+		h.Logger.ERROR.Println("resetting login throttle:", err)
+	}
+
+	tokens, err := h.issueTokens(c, user.ID, user.Email, user.Username, user.Role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error", "err": err.Error()})
+		return
+	}
 
 	c.JSON(http.StatusOK, tokens)
 }
 
+// dummyHash is compared against when the email is unknown.
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("not-a-real-password"), bcrypt.DefaultCost)
+
+// loginFailed records the failed attempt and answers with the same error
+// whatever the reason was, so clients can't tell unknown emails apart.
+func (h *HTTPHandler) loginFailed(c *gin.Context, attempt models.LoginAttempt) {
+	if err := h.LS.Fail(attempt); err != nil {
+		h.Logger.ERROR.Println("recording login attempt:", err)
+	}
+	c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
+}
+
 // GetProfile godoc
 // @Summary Get user profile
 // @Description Get the profile of the authenticated user
@@ -130,6 +206,10 @@ func (h *HTTPHandler) Profile(c *gin.Context) {
 func (h *HTTPHandler) GetByID(c *gin.Context) {
 	id := &models.GetProfileByIdReq{ID: c.Param("id")}
 	user, err := h.US.GetByID(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"Couldn't get the user": err.Error()})
 		return
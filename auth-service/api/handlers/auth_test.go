@@ -0,0 +1,75 @@
+package handlers_test
+
+import (
+	"auth-service/models"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoginLocksAccountAfterFailures(t *testing.T) {
+	h, mock := newHandler(t)
+
+	expectNotThrottled(mock)
+	expectProfile(mock, false)
+	mock.ExpectExec("INSERT INTO login_attempts").
+		WithArgs(userID, email, "192.0.2.1", sqlmock.AnyArg(), models.LoginWrongPassword).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// The account and the client are counted in no particular order.
+	mock.MatchExpectationsInOrder(false)
+	mock.ExpectQuery("INSERT INTO login_throttles").
+		WithArgs("email:"+email, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"failures"}).AddRow(3))
+	mock.ExpectQuery("INSERT INTO login_throttles").
+		WithArgs("ip:192.0.2.1", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"failures"}).AddRow(3))
+	mock.ExpectExec("UPDATE login_throttles SET locked_until").
+		WithArgs(sqlmock.AnyArg(), "email:"+email).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	w := serve(h.Login, http.MethodPost, "/login", "/login", models.LoginReq{Email: email, Password: "wrong"}, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLoginRefusesLockedAccount(t *testing.T) {
+	h, mock := newHandler(t)
+
+	mock.ExpectQuery("SELECT key, failures, last_failure_at, locked_until FROM login_throttles").
+		WithArgs("email:" + email).
+		WillReturnRows(sqlmock.NewRows([]string{"key", "failures", "last_failure_at", "locked_until"}).
+			AddRow("email:"+email, 3, time.Now(), time.Now().Add(90*time.Second)))
+	mock.ExpectQuery("SELECT key, failures, last_failure_at, locked_until FROM login_throttles").
+		WithArgs("ip:192.0.2.1").
+		WillReturnRows(sqlmock.NewRows([]string{"key", "failures", "last_failure_at", "locked_until"}))
+	mock.ExpectExec("INSERT INTO login_attempts").
+		WithArgs(sqlmock.AnyArg(), email, "192.0.2.1", sqlmock.AnyArg(), models.LoginThrottled).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// Even the right password is refused without being checked.
+	w := serve(h.Login, http.MethodPost, "/login", "/login", models.LoginReq{Email: email, Password: password}, nil)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "90", w.Header().Get("Retry-After"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetUserByID(t *testing.T) {
+	h, mock := newHandler(t)
+
+	mock.ExpectQuery("SELECT id, username, email, role FROM users WHERE id = \\$1").
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "email", "role"}).AddRow(userID, "user", email, "moderator"))
+	w := serve(h.GetByID, http.MethodGet, "/user/"+userID, "/user/:id", nil, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"role":"moderator"`)
+
+	mock.ExpectQuery("SELECT id, username, email, role FROM users WHERE id = \\$1").
+		WithArgs("missing").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "email", "role"}))
+	w = serve(h.GetByID, http.MethodGet, "/user/missing", "/user/:id", nil, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
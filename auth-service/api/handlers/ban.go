@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"auth-service/models"
+	"auth-service/service"
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt"
+)
+
+// roleRank orders roles so staff can only ban users below them, and only
+// lift bans issued by their own rank or below.
+var roleRank = map[string]int{"user": 0, "moderator": 1, "admin": 2}
+
+// rolesUpTo lists the roles ranked no higher than role.
+func rolesUpTo(role string) []string {
+	var roles []string
+	for r, rank := range roleRank {
+		if rank <= roleRank[role] {
+			roles = append(roles, r)
+		}
+	}
+	return roles
+}
+
+// refuseBanned answers 403 and returns true if the user is banned.
+func (h *HTTPHandler) refuseBanned(c *gin.Context, userID string) bool {
+	ban, err := h.BS.Active(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error", "err": err.Error()})
+		return true
+	}
+	if ban == nil {
+		return false
+	}
+	c.JSON(http.StatusForbidden, gin.H{"error": "Account is banned", "reason": ban.Reason, "expires_at": ban.ExpiresAt})
+	return true
+}
+
+// BanUser godoc
+// @Summary Ban a user
+// @Description Ban a user temporarily or permanently. Banned users can't log in or refresh tokens, and their sessions and API keys stop working.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param ban body models.BanReq true "Reason and optional duration"
+// @Success 200 {object} models.Ban
+// @Failure 400 {object} string "Invalid request payload"
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 403 {object} string "Forbidden"
+// @Failure 404 {object} string "User not found"
+// @Failure 500 {object} string "Server error"
+// @Security BearerAuth
+// @Router /admin/users/{id}/ban [post]
+func (h *HTTPHandler) BanUser(c *gin.Context) {
+	req := models.BanReq{}
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Invalid request payload": err.Error()})
+		return
+	}
+
+	target, err := h.US.GetByID(&models.GetProfileByIdReq{ID: c.Param("id")})
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error", "err": err.Error()})
+		return
+	}
+	profile, err := h.US.GetProfile(&models.GetProfileReq{Email: target.Email})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error", "err": err.Error()})
+		return
+	}
+
+	claims := c.MustGet("claims").(jwt.MapClaims)
+	role, _ := claims["role"].(string)
+	if roleRank[profile.Role] >= roleRank[role] {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Can't ban a user with the same or a higher role"})
+		return
+	}
+
+	ban, err := h.BS.Ban(target.ID, claims["user_id"].(string), &req)
+	if errors.Is(err, service.ErrInvalidBanReq) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error", "err": err.Error()})
+		return
+	}
+	h.Logger.INFO.Println("user banned:", target.ID, "by", ban.BannedBy)
+	c.JSON(http.StatusOK, ban)
+}
+
+// UnbanUser godoc
+// @Summary Lift a ban
+// @Description Lift every ban in force for a user. Moderators can't lift a ban an admin issued.
+// @Tags admin
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} string "Ban lifted"
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 403 {object} string "Forbidden"
+// @Failure 404 {object} string "User is not banned"
+// @Failure 500 {object} string "Server error"
+// @Security BearerAuth
+// @Router /admin/users/{id}/ban [delete]
+func (h *HTTPHandler) UnbanUser(c *gin.Context) {
+	claims := c.MustGet("claims").(jwt.MapClaims)
+	role, _ := claims["role"].(string)
+	err := h.BS.Lift(c.Param("id"), claims["user_id"].(string), rolesUpTo(role))
+	if errors.Is(err, service.ErrNotBanned) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, service.ErrBanOutranks) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Can't lift a ban issued by a higher role"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error", "err": err.Error()})
+		return
+	}
+	h.Logger.INFO.Println("ban lifted:", c.Param("id"))
+	c.JSON(http.StatusOK, gin.H{"message": "Ban lifted"})
+}
+
+// ListBans godoc
+// @Summary List bans
+// @Description List current and past bans of a user
+// @Tags admin
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} models.BanListResp
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 403 {object} string "Forbidden"
+// @Failure 500 {object} string "Server error"
+// @Security BearerAuth
+// @Router /admin/users/{id}/bans [get]
+func (h *HTTPHandler) ListBans(c *gin.Context) {
+	res, err := h.BS.GetAll(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error", "err": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
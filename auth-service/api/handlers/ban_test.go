@@ -0,0 +1,112 @@
+package handlers_test
+
+import (
+	"auth-service/api/token"
+	"auth-service/models"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoginRefusesBannedUser(t *testing.T) {
+	h, mock := newHandler(t)
+
+	expectNotThrottled(mock)
+	expectProfile(mock, false)
+	expectBan(mock, true)
+
+	w := serve(h.Login, http.MethodPost, "/login", "/login", models.LoginReq{Email: email, Password: password}, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.NotContains(t, w.Body.String(), "access_token")
+	assert.NoError(t, mock.ExpectationsWereMet(), "no session was started")
+}
+
+func TestLoginTwoFactorRefusesBannedUser(t *testing.T) {
+	h, mock := newHandler(t)
+
+	key, err := totp.Generate(totp.GenerateOpts{Issuer: "forum", AccountName: email})
+	require.NoError(t, err)
+	code, err := totp.GenerateCode(key.Secret(), time.Now())
+	require.NoError(t, err)
+	challenge := token.GenerateChallengeToken(userID, email, time.Minute)
+
+	expectNotThrottled(mock)
+	mock.ExpectQuery("SELECT COALESCE\\(totp_secret, ''\\), totp_enabled FROM users").
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"totp_secret", "totp_enabled"}).AddRow(key.Secret(), true))
+	mock.ExpectExec("UPDATE users SET totp_last_step").
+		WithArgs(sqlmock.AnyArg(), userID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM used_challenges").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO used_challenges").
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectProfile(mock, true)
+	mock.ExpectExec("DELETE FROM login_throttles").
+		WithArgs("email:" + email).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	expectBan(mock, true)
+
+	w := serve(h.LoginTwoFactor, http.MethodPost, "/login/2fa", "/login/2fa", models.TwoFactorLoginReq{ChallengeToken: challenge, Code: code}, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet(), "no session was started")
+}
+
+func TestOAuthCallbackRefusesBannedUser(t *testing.T) {
+	const state, nonce = "state1", "nonce1"
+	h, mock := newHandler(t, newMockProvider(t, nonce))
+
+	mock.ExpectQuery("DELETE FROM oauth_states WHERE state = \\$1").
+		WithArgs(state).
+		WillReturnRows(sqlmock.NewRows([]string{"state", "provider", "nonce", "code_verifier", "expires_at"}).
+			AddRow(state, "mock", nonce, "verifier", time.Now().Add(time.Minute)))
+	mock.ExpectQuery("SELECT user_id FROM user_identities").
+		WithArgs("mock", "external-123").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(userID))
+	mock.ExpectQuery("SELECT id, username, email, role FROM users WHERE id = \\$1").
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "email", "role"}).AddRow(userID, "user", email, "user"))
+	expectProfile(mock, false)
+	expectBan(mock, true)
+
+	w := serve(h.OAuthCallback, http.MethodGet, "/oauth/mock/callback?state="+state+"&code=code1", "/oauth/:provider/callback", nil, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet(), "no session was started")
+}
+
+func TestRefreshRefusesBannedUser(t *testing.T) {
+	h, mock := newHandler(t)
+	tokens := token.GenerateJWTToken(userID, email, "user", "user", sessionID)
+
+	mock.ExpectExec("UPDATE sessions SET last_seen_at = NOW\\(\\), ip_address").
+		WithArgs(sessionID, userID, "192.0.2.1", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectBan(mock, true)
+
+	w := serve(h.Refresh, http.MethodPost, "/refresh", "/refresh", models.RefreshReq{RefreshToken: tokens.RefreshToken}, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.NotContains(t, w.Body.String(), "access_token")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRefreshAllowsUserAfterBanLifted(t *testing.T) {
+	h, mock := newHandler(t)
+	tokens := token.GenerateJWTToken(userID, email, "user", "user", sessionID)
+
+	mock.ExpectExec("UPDATE sessions SET last_seen_at = NOW\\(\\), ip_address").
+		WithArgs(sessionID, userID, "192.0.2.1", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectBan(mock, false)
+	expectProfile(mock, false)
+
+	w := serve(h.Refresh, http.MethodPost, "/refresh", "/refresh", models.RefreshReq{RefreshToken: tokens.RefreshToken}, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "access_token")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
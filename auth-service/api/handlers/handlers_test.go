@@ -0,0 +1,186 @@
+package handlers_test
+
+import (
+	"auth-service/api/handlers"
+	"auth-service/api/oidc"
+	"auth-service/config"
+	"auth-service/config/logger"
+	"auth-service/service"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/go-jose/go-jose/v4"
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	userID    = "5f0c6f8e-2b7a-4f4e-9d51-0c1f7d3a9b21"
+	email     = "user@example.com"
+	password  = "correct horse"
+	sessionID = "0b5ad0a8-7f4e-4b53-9a3c-6f2d2f6c1a11"
+)
+
+var (
+	passwordHash, _ = bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	profileColumns  = []string{"id", "username", "email", "password", "role", "totp_enabled"}
+	banColumns      = []string{"id", "user_id", "banned_by", "reason", "created_at", "expires_at", "lifted_at"}
+)
+
+// newHandler returns a handler whose services all share one mocked database.
+func newHandler(t *testing.T, providers ...*oidc.Provider) (*handlers.HTTPHandler, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	cfg := config.Config{
+		LOGIN_MAX_ATTEMPTS:       3,
+		LOGIN_IP_MAX_ATTEMPTS:    100,
+		LOGIN_ATTEMPT_WINDOW:     15 * time.Minute,
+		LOGIN_BACKOFF_THRESHOLD:  100,
+		LOGIN_LOCKOUT_DURATION:   15 * time.Minute,
+		TWO_FACTOR_CHALLENGE_TTL: 5 * time.Minute,
+		TOTP_ISSUER:              "forum",
+	}
+	h := handlers.NewHandler(
+		service.NewUserService(db),
+		service.NewLoginService(db, cfg),
+		service.NewTwoFactorService(db, cfg),
+		service.NewOAuthService(db, cfg),
+		service.NewAPIKeyService(db),
+		service.NewSessionService(db),
+		service.NewBanService(db),
+		providers,
+		*logger.NewLogger("", ""),
+	)
+	return h, mock
+}
+
+// serve runs one request through handler and returns the recorded response.
+// claims, if given, are set as JWTMiddleware would.
+func serve(handler gin.HandlerFunc, method, path, route string, body interface{}, claims jwt.MapClaims) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Handle(method, route, func(c *gin.Context) {
+		if claims != nil {
+			c.Set("claims", claims)
+		}
+		handler(c)
+	})
+
+	var buf bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&buf).Encode(body)
+	}
+	req := httptest.NewRequest(method, path, &buf)
+	req.RemoteAddr = "192.0.2.1:1234"
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// expectProfile expects the user to be looked up by email.
+func expectProfile(mock sqlmock.Sqlmock, twoFactor bool) {
+	mock.ExpectQuery("SELECT id, username, email, password, role, totp_enabled FROM users WHERE email = \\$1").
+		WithArgs(email).
+		WillReturnRows(sqlmock.NewRows(profileColumns).AddRow(userID, "user", email, string(passwordHash), "user", twoFactor))
+}
+
+// expectBan expects the user's ban to be looked up and answers with one if
+// banned is set.
+func expectBan(mock sqlmock.Sqlmock, banned bool) {
+	rows := sqlmock.NewRows(banColumns)
+	if banned {
+		rows.AddRow("ban1", userID, "", "spam", time.Now(), nil, nil)
+	}
+	mock.ExpectQuery("FROM bans WHERE user_id = \\$1 AND lifted_at IS NULL").
+		WithArgs(userID).
+		WillReturnRows(rows)
+}
+
+// expectNotThrottled expects the account and client throttles to be read
+// and answers that there are none.
+func expectNotThrottled(mock sqlmock.Sqlmock) {
+	for _, key := range []string{"email:" + email, "ip:192.0.2.1"} {
+		mock.ExpectQuery("SELECT key, failures, last_failure_at, locked_until FROM login_throttles").
+			WithArgs(key).
+			WillReturnRows(sqlmock.NewRows([]string{"key", "failures", "last_failure_at", "locked_until"}))
+	}
+}
+
+// mockProvider is a minimal OIDC provider that signs in everyone as the
+// same external user.
+type mockProvider struct {
+	*httptest.Server
+	key   *rsa.PrivateKey
+	nonce string
+}
+
+func newMockProvider(t *testing.T, nonce string) *oidc.Provider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	m := &mockProvider{key: key, nonce: nonce}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"issuer":                                m.URL,
+			"authorization_endpoint":                m.URL + "/authorize",
+			"token_endpoint":                        m.URL + "/token",
+			"jwks_uri":                              m.URL + "/jwks",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+			{Key: &key.PublicKey, KeyID: "test", Algorithm: "RS256", Use: "sig"},
+		}})
+	})
+	mux.HandleFunc("/token", m.token)
+	m.Server = httptest.NewServer(mux)
+	t.Cleanup(m.Close)
+
+	p, err := oidc.NewProvider(context.Background(), config.OIDCProvider{
+		Name:         "mock",
+		Issuer:       m.URL,
+		ClientID:     "forum",
+		ClientSecret: "secret",
+		RedirectURL:  "http://localhost:8088/oauth/mock/callback",
+	})
+	require.NoError(t, err)
+	return p
+}
+
+func (m *mockProvider) token(w http.ResponseWriter, r *http.Request) {
+	signer, _ := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: jose.JSONWebKey{Key: m.key, KeyID: "test"}}, nil)
+	payload, _ := json.Marshal(map[string]interface{}{
+		"iss":            m.URL,
+		"sub":            "external-123",
+		"aud":            "forum",
+		"iat":            time.Now().Unix(),
+		"exp":            time.Now().Add(time.Minute).Unix(),
+		"nonce":          m.nonce,
+		"email":          email,
+		"email_verified": true,
+	})
+	jws, _ := signer.Sign(payload)
+	idToken, _ := jws.CompactSerialize()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token": "access",
+		"token_type":   "Bearer",
+		"expires_in":   60,
+		"id_token":     idToken,
+	})
+}
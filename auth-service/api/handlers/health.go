@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Healthz godoc
+// @Summary Liveness
+// @Description Answer as long as the process serves requests
+// @Tags health
+// @Produce json
+// @Success 200 {object} string "ok"
+// @Router /healthz [get]
+func (h *HTTPHandler) Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Readyz godoc
+// @Summary Readiness
+// @Description Report whether the service can serve requests, i.e. its database answered the last health check
+// @Tags health
+// @Produce json
+// @Success 200 {object} string "ready"
+// @Failure 503 {object} string "Database unavailable"
+// @Router /readyz [get]
+func (h *HTTPHandler) Readyz(c *gin.Context) {
+	if h.Ready != nil {
+		if err := h.Ready(); err != nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database unavailable", "err": err.Error()})
+			return
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ready"})
+}
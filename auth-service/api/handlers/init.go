@@ -1,15 +1,30 @@
 package handlers
 
 import (
+	"auth-service/api/oidc"
 	"auth-service/config/logger"
 	"auth-service/service"
 )
 
 type HTTPHandler struct {
 	US     *service.UserService
+	LS     *service.LoginService
+	TS     *service.TwoFactorService
+	OS     *service.OAuthService
+	KS     *service.APIKeyService
+	SS     *service.SessionService
+	BS     *service.BanService
+	OIDC   map[string]*oidc.Provider
 	Logger logger.Logger
+
+	// Ready reports why the service can't serve requests, or nil.
+	Ready func() error
 }
 
-func NewHandler(us *service.UserService, l logger.Logger) *HTTPHandler {
-	return &HTTPHandler{US: us, Logger: l}
+func NewHandler(us *service.UserService, ls *service.LoginService, ts *service.TwoFactorService, oauth *service.OAuthService, ks *service.APIKeyService, ss *service.SessionService, bs *service.BanService, providers []*oidc.Provider, l logger.Logger) *HTTPHandler {
+	h := &HTTPHandler{US: us, LS: ls, TS: ts, OS: oauth, KS: ks, SS: ss, BS: bs, OIDC: map[string]*oidc.Provider{}, Logger: l}
+	for _, p := range providers {
+		h.OIDC[p.Name] = p
+	}
+	return h
 }
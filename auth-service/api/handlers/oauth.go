@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"auth-service/api/oidc"
+	"auth-service/api/token"
+	"auth-service/models"
+	"auth-service/service"
+	"errors"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OAuthProviders godoc
+// @Summary List identity providers
+// @Description List the configured external identity providers
+// @Tags oauth
+// @Produce json
+// @Success 200 {object} models.OAuthProvidersResp
+// @Router /oauth/providers [get]
+func (h *HTTPHandler) OAuthProviders(c *gin.Context) {
+	res := models.OAuthProvidersResp{Providers: []string{}}
+	for name := range h.OIDC {
+		res.Providers = append(res.Providers, name)
+	}
+	sort.Strings(res.Providers)
+	c.JSON(http.StatusOK, res)
+}
+
+// OAuthLogin godoc
+// @Summary Sign in with an identity provider
+// @Description Redirect to the provider's authorization endpoint (authorization code flow with PKCE)
+// @Tags oauth
+// @Param provider path string true "Provider name"
+// @Success 302 {object} string "Redirect to the provider"
+// @Failure 404 {object} string "Unknown identity provider"
+// @Failure 500 {object} string "Server error"
+// @Router /oauth/{provider}/login [get]
+func (h *HTTPHandler) OAuthLogin(c *gin.Context) {
+	provider, ok := h.OIDC[c.Param("provider")]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown identity provider"})
+		return
+	}
+
+	req, err := oidc.NewAuthRequest()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error", "err": err.Error()})
+		return
+	}
+	err = h.OS.SaveState(models.OAuthState{
+		State:        req.State,
+		Provider:     provider.Name,
+		Nonce:        req.Nonce,
+		CodeVerifier: req.CodeVerifier,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error", "err": err.Error()})
+		return
+	}
+
+	c.Redirect(http.StatusFound, provider.AuthCodeURL(req))
+}
+
+// OAuthCallback godoc
+// @Summary Finish signing in with an identity provider
+// @Description Verify the provider's response, link or create the user and return JWT tokens
+// @Tags oauth
+// @Produce json
+// @Param provider path string true "Provider name"
+// @Param state query string true "State from the login redirect"
+// @Param code query string true "Authorization code"
+// @Success 200 {object} token.Tokens "JWT tokens"
+// @Success 202 {object} models.TwoFactorChallengeResp "Two-factor code required"
+// @Failure 400 {object} string "Invalid or expired login state"
+// @Failure 401 {object} string "Login rejected"
+// @Failure 403 {object} string "Account is banned"
+// @Failure 404 {object} string "Unknown identity provider"
+// @Failure 409 {object} string "Email can't be linked"
+// @Failure 500 {object} string "Server error"
+// @Router /oauth/{provider}/callback [get]
+func (h *HTTPHandler) OAuthCallback(c *gin.Context) {
+	provider, ok := h.OIDC[c.Param("provider")]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown identity provider"})
+		return
+	}
+	if reason := c.Query("error"); reason != "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Login rejected by identity provider", "details": reason})
+		return
+	}
+
+	state, err := h.OS.ConsumeState(provider.Name, c.Query("state"))
+	if errors.Is(err, service.ErrInvalidState) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error", "err": err.Error()})
+		return
+	}
+
+	identity, err := provider.Exchange(c.Request.Context(), c.Query("code"), &oidc.AuthRequest{
+		State:        state.State,
+		Nonce:        state.Nonce,
+		CodeVerifier: state.CodeVerifier,
+	})
+	if err != nil {
+		h.Logger.WARN.Println("oidc login failed:", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Login rejected"})
+		return
+	}
+
+	user, err := h.OS.SignIn(identity)
+	if errors.Is(err, service.ErrEmailRequired) || errors.Is(err, service.ErrEmailNotVerified) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error", "err": err.Error()})
+		return
+	}
+
+	if h.refuseBanned(c, user.ID) {
+		return
+	}
+
+	if user.TwoFactorEnabled {
+		c.JSON(http.StatusAccepted, models.TwoFactorChallengeResp{
+			TwoFactorRequired: true,
+			ChallengeToken:    token.GenerateChallengeToken(user.ID, user.Email, h.TS.ChallengeTTL()),
+		})
+		return
+	}
+
+	tokens, err := h.issueTokens(c, user.ID, user.Email, user.Username, user.Role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error", "err": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
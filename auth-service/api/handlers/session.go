@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"auth-service/api/token"
+	"auth-service/models"
+	"auth-service/service"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt"
+)
+
+// issueTokens starts a new session for the user on the calling device and
+// returns tokens bound to it.
+func (h *HTTPHandler) issueTokens(c *gin.Context, userID, email, username, role string) (*token.Tokens, error) {
+	session, err := h.SS.Create(userID, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		return nil, err
+	}
+	return token.GenerateJWTToken(userID, email, username, role, session.ID), nil
+}
+
+// Refresh godoc
+// @Summary Refresh tokens
+// @Description Exchange a refresh token of an active session for new tokens
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param token body models.RefreshReq true "Refresh token"
+// @Success 200 {object} token.Tokens "JWT tokens"
+// @Failure 400 {object} string "Invalid request payload"
+// @Failure 401 {object} string "Invalid refresh token or revoked session"
+// @Failure 403 {object} string "Account is banned"
+// @Failure 500 {object} string "Server error"
+// @Router /refresh [post]
+func (h *HTTPHandler) Refresh(c *gin.Context) {
+	req := models.RefreshReq{}
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Invalid request payload": err.Error()})
+		return
+	}
+
+	claims, err := token.ExtractRefreshClaim(req.RefreshToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+		return
+	}
+	sessionID, _ := claims["session_id"].(string)
+	email, _ := claims["email"].(string)
+	userID, _ := claims["user_id"].(string)
+
+	err = h.SS.Refresh(sessionID, userID, c.ClientIP(), c.Request.UserAgent())
+	if errors.Is(err, service.ErrSessionNotFound) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Session has been revoked"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error", "err": err.Error()})
+		return
+	}
+
+	if h.refuseBanned(c, userID) {
+		return
+	}
+
+	// Read the user again so a changed username or role shows up in the
+	// new tokens.
+	user, err := h.US.GetProfile(&models.GetProfileReq{Email: email})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error", "err": err.Error()})
+		return
+	}
+
+	tokens := token.GenerateJWTToken(user.ID, user.Email, user.Username, user.Role, sessionID)
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// ListSessions godoc
+// @Summary List sessions
+// @Description List the active sessions of the authenticated user
+// @Tags sessions
+// @Produce json
+// @Success 200 {object} models.SessionListResp
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 500 {object} string "Server error"
+// @Security BearerAuth
+// @Router /sessions [get]
+func (h *HTTPHandler) ListSessions(c *gin.Context) {
+	claims := c.MustGet("claims").(jwt.MapClaims)
+	current, _ := claims["session_id"].(string)
+
+	res, err := h.SS.GetAll(claims["user_id"].(string), current)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error", "err": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// RevokeSession godoc
+// @Summary Revoke a session
+// @Description Log out one of the authenticated user's sessions
+// @Tags sessions
+// @Produce json
+// @Param id path string true "Session ID"
+// @Success 200 {object} string "Session revoked"
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 404 {object} string "Session not found"
+// @Failure 500 {object} string "Server error"
+// @Security BearerAuth
+// @Router /sessions/{id} [delete]
+func (h *HTTPHandler) RevokeSession(c *gin.Context) {
+	claims := c.MustGet("claims").(jwt.MapClaims)
+	h.revokeSession(c, claims["user_id"].(string), c.Param("id"))
+}
+
+// Logout godoc
+// @Summary Log out
+// @Description Revoke the session of the current token
+// @Tags sessions
+// @Produce json
+// @Success 200 {object} string "Session revoked"
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 404 {object} string "Session not found"
+// @Failure 500 {object} string "Server error"
+// @Security BearerAuth
+// @Router /logout [post]
+func (h *HTTPHandler) Logout(c *gin.Context) {
+	claims := c.MustGet("claims").(jwt.MapClaims)
+	sessionID, _ := claims["session_id"].(string)
+	h.revokeSession(c, claims["user_id"].(string), sessionID)
+}
+
+func (h *HTTPHandler) revokeSession(c *gin.Context, userID, sessionID string) {
+	err := h.SS.Revoke(userID, sessionID)
+	if errors.Is(err, service.ErrSessionNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error", "err": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked"})
+}
+
+// IntrospectSession godoc
+// @Summary Introspect a session
+// @Description Tell whether a session is still active. Used by api-gateway.
+// @Tags sessions
+// @Accept json
+// @Produce json
+// @Param session body models.SessionIntrospectReq true "Session ID"
+// @Success 200 {object} models.SessionIntrospectResp
+// @Failure 400 {object} string "Invalid request payload"
+// @Failure 500 {object} string "Server error"
+// @Router /sessions/introspect [post]
+func (h *HTTPHandler) IntrospectSession(c *gin.Context) {
+	req := models.SessionIntrospectReq{}
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Invalid request payload": err.Error()})
+		return
+	}
+
+	res, err := h.SS.Introspect(req.SessionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error", "err": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,82 @@
+package handlers_test
+
+import (
+	"auth-service/api/token"
+	"auth-service/models"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRefreshRevokedSession(t *testing.T) {
+	h, mock := newHandler(t)
+	tokens := token.GenerateJWTToken(userID, email, "user", "user", sessionID)
+
+	mock.ExpectExec("UPDATE sessions SET last_seen_at = NOW\\(\\), ip_address").
+		WithArgs(sessionID, userID, "192.0.2.1", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	w := serve(h.Refresh, http.MethodPost, "/refresh", "/refresh", models.RefreshReq{RefreshToken: tokens.RefreshToken}, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.NotContains(t, w.Body.String(), "access_token")
+
+	// An access token is not a refresh token.
+	w = serve(h.Refresh, http.MethodPost, "/refresh", "/refresh", models.RefreshReq{RefreshToken: tokens.AccessToken}, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestListSessionsMarksCurrent(t *testing.T) {
+	h, mock := newHandler(t)
+	const other = "7d1c3b2a-9e8f-4a6b-8c5d-4e3f2a1b0c9d"
+
+	mock.ExpectQuery("FROM sessions WHERE user_id = \\$1 AND revoked_at IS NULL").
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "user_agent", "ip_address", "created_at", "last_seen_at"}).
+			AddRow(sessionID, userID, "curl", "192.0.2.1", time.Now(), time.Now()).
+			AddRow(other, userID, "firefox", "192.0.2.2", time.Now(), time.Now()))
+
+	w := serve(h.ListSessions, http.MethodGet, "/sessions", "/sessions", nil, jwt.MapClaims{"user_id": userID, "session_id": sessionID})
+	require.Equal(t, http.StatusOK, w.Code)
+	var res models.SessionListResp
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	require.Len(t, res.Sessions, 2)
+	assert.True(t, res.Sessions[0].Current)
+	assert.False(t, res.Sessions[1].Current)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLogoutRevokesSession(t *testing.T) {
+	h, mock := newHandler(t)
+	claims := jwt.MapClaims{"user_id": userID, "session_id": sessionID}
+
+	for _, revoked := range []int64{1, 0} {
+		mock.ExpectExec("UPDATE sessions SET revoked_at = NOW\\(\\)").
+			WithArgs(sessionID, userID).
+			WillReturnResult(sqlmock.NewResult(0, revoked))
+	}
+	w := serve(h.Logout, http.MethodPost, "/logout", "/logout", nil, claims)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = serve(h.RevokeSession, http.MethodDelete, "/sessions/"+sessionID, "/sessions/:id", nil, claims)
+	assert.Equal(t, http.StatusNotFound, w.Code, "the session is already revoked")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestIntrospectRevokedSession(t *testing.T) {
+	h, mock := newHandler(t)
+
+	mock.ExpectQuery("UPDATE sessions SET last_seen_at = NOW\\(\\) WHERE id = \\$1").
+		WithArgs(sessionID).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}))
+
+	w := serve(h.IntrospectSession, http.MethodPost, "/sessions/introspect", "/sessions/introspect", models.SessionIntrospectReq{SessionID: sessionID}, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"active":false,"user_id":"","banned":false}`, w.Body.String())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
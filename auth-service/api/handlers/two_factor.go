@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"auth-service/api/token"
+	"auth-service/models"
+	"auth-service/service"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt"
+)
+
+// LoginTwoFactor godoc
+// @Summary Finish a two-factor login
+// @Description Exchange the challenge token from /login and a TOTP or recovery code for JWT tokens. A challenge token and a TOTP code each work once.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param credentials body models.TwoFactorLoginReq true "Challenge token and code"
+// @Success 200 {object} token.Tokens "JWT tokens"
+// @Failure 400 {object} string "Invalid request payload"
+// @Failure 401 {object} string "Invalid challenge token or code"
+// @Failure 403 {object} string "Account is banned"
+// @Failure 429 {object} string "Too many login attempts"
+// @Failure 500 {object} string "Server error"
+// @Router /login/2fa [post]
+func (h *HTTPHandler) LoginTwoFactor(c *gin.Context) {
+	req := models.TwoFactorLoginReq{}
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Invalid request payload": err.Error()})
+		return
+	}
+
+	claims, err := token.ExtractChallengeClaim(req.ChallengeToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid challenge token"})
+		return
+	}
+	userID, _ := claims["user_id"].(string)
+	email, _ := claims["email"].(string)
+	jti, _ := claims["jti"].(string)
+	exp, _ := claims["exp"].(float64)
+
+	attempt := models.LoginAttempt{
+		UserID:    userID,
+		Email:     email,
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+
+	wait, err := h.LS.Check(email, attempt.IPAddress)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error", "err": err.Error()})
+		return
+	}
+	if wait > 0 {
+		attempt.Reason = models.LoginThrottled
+		if err := h.LS.Fail(attempt); err != nil {
+			h.Logger.ERROR.Println("recording login attempt:", err)
+		}
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many login attempts, try again later"})
+		return
+	}
+
+	err = h.TS.Verify(userID, req.Code)
+	if errors.Is(err, service.ErrInvalidCode) {
+		attempt.Reason = models.LoginWrongCode
+		if err := h.LS.Fail(attempt); err != nil {
+			h.Logger.ERROR.Println("recording login attempt:", err)
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid code"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error", "err": err.Error()})
+		return
+	}
+
+	err = h.TS.UseChallenge(jti, time.Unix(int64(exp), 0))
+	if errors.Is(err, service.ErrChallengeUsed) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Challenge token was already used"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error", "err": err.Error()})
+		return
+	}
+
+	user, err := h.US.GetProfile(&models.GetProfileReq{Email: email})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error", "err": err.Error()})
+		return
+	}
+
+	if err := h.LS.Succeed(user.Email); err != nil {
+		h.Logger.ERROR.Println("resetting login throttle:", err)
+	}
+
+	if h.refuseBanned(c, user.ID) {
+		return
+	}
+
+	tokens, err := h.issueTokens(c, user.ID, user.Email, user.Username, user.Role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error", "err": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// EnrollTwoFactor godoc
+// @Summary Start two-factor enrollment
+// @Description Generate a TOTP secret and provisioning URI. Two-factor authentication is enabled only after /2fa/confirm.
+// @Tags 2fa
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.TwoFactorEnrollResp
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 409 {object} string "Two-factor authentication already enabled"
+// @Failure 500 {object} string "Server error"
+// @Security BearerAuth
+// @Router /2fa/enroll [post]
+func (h *HTTPHandler) EnrollTwoFactor(c *gin.Context) {
+	claims := c.MustGet("claims").(jwt.MapClaims)
+
+	res, err := h.TS.Enroll(claims["user_id"].(string), claims["email"].(string))
+	if err != nil {
+		h.twoFactorError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// ConfirmTwoFactor godoc
+// @Summary Confirm two-factor enrollment
+// @Description Enable two-factor authentication with a code from the authenticator and get recovery codes
+// @Tags 2fa
+// @Accept json
+// @Produce json
+// @Param code body models.TwoFactorCodeReq true "TOTP code"
+// @Success 200 {object} models.TwoFactorConfirmResp
+// @Failure 400 {object} string "Invalid request payload or code"
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 409 {object} string "Two-factor authentication already enabled"
+// @Failure 500 {object} string "Server error"
+// @Security BearerAuth
+// @Router /2fa/confirm [post]
+func (h *HTTPHandler) ConfirmTwoFactor(c *gin.Context) {
+	claims := c.MustGet("claims").(jwt.MapClaims)
+	req := models.TwoFactorCodeReq{}
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Invalid request payload": err.Error()})
+		return
+	}
+
+	res, err := h.TS.Confirm(claims["user_id"].(string), req.Code)
+	if err != nil {
+		h.twoFactorError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// DisableTwoFactor godoc
+// @Summary Disable two-factor authentication
+// @Description Turn off two-factor authentication with a TOTP or recovery code
+// @Tags 2fa
+// @Accept json
+// @Produce json
+// @Param code body models.TwoFactorCodeReq true "TOTP or recovery code"
+// @Success 200 {object} string "Two-factor authentication disabled"
+// @Failure 400 {object} string "Invalid request payload or code"
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 500 {object} string "Server error"
+// @Security BearerAuth
+// @Router /2fa/disable [post]
+func (h *HTTPHandler) DisableTwoFactor(c *gin.Context) {
+	claims := c.MustGet("claims").(jwt.MapClaims)
+	req := models.TwoFactorCodeReq{}
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Invalid request payload": err.Error()})
+		return
+	}
+
+	if err := h.TS.Disable(claims["user_id"].(string), req.Code); err != nil {
+		h.twoFactorError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
+
+func (h *HTTPHandler) twoFactorError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrTwoFactorEnabled):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidCode),
+		errors.Is(err, service.ErrTwoFactorNotEnabled),
+		errors.Is(err, service.ErrTwoFactorNotEnrolled):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error", "err": err.Error()})
+	}
+}
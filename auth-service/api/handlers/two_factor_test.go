@@ -0,0 +1,64 @@
+package handlers_test
+
+import (
+	"auth-service/models"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/golang-jwt/jwt"
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnrollTwoFactor(t *testing.T) {
+	h, mock := newHandler(t)
+	claims := jwt.MapClaims{"user_id": userID, "email": email}
+
+	mock.ExpectExec("UPDATE users SET totp_secret").
+		WithArgs(sqlmock.AnyArg(), userID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	w := serve(h.EnrollTwoFactor, http.MethodPost, "/2fa/enroll", "/2fa/enroll", nil, claims)
+	require.Equal(t, http.StatusOK, w.Code)
+	var enrolled models.TwoFactorEnrollResp
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &enrolled))
+	secret := func() {
+		mock.ExpectQuery("SELECT COALESCE\\(totp_secret, ''\\), totp_enabled FROM users").
+			WithArgs(userID).
+			WillReturnRows(sqlmock.NewRows([]string{"totp_secret", "totp_enabled"}).AddRow(enrolled.Secret, false))
+	}
+
+	secret()
+	w = serve(h.ConfirmTwoFactor, http.MethodPost, "/2fa/confirm", "/2fa/confirm", models.TwoFactorCodeReq{Code: "000000"}, claims)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	code, err := totp.GenerateCode(enrolled.Secret, time.Now())
+	require.NoError(t, err)
+	secret()
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE users SET totp_enabled = TRUE").
+		WithArgs(userID, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM recovery_codes").
+		WithArgs(userID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	for i := 0; i < 10; i++ {
+		mock.ExpectExec("INSERT INTO recovery_codes").
+			WithArgs(userID, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectCommit()
+	w = serve(h.ConfirmTwoFactor, http.MethodPost, "/2fa/confirm", "/2fa/confirm", models.TwoFactorCodeReq{Code: code}, claims)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "recovery_codes")
+
+	mock.ExpectExec("UPDATE users SET totp_secret").
+		WithArgs(sqlmock.AnyArg(), userID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	w = serve(h.EnrollTwoFactor, http.MethodPost, "/2fa/enroll", "/2fa/enroll", nil, claims)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
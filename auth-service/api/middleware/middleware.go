@@ -2,12 +2,16 @@ package middleware
 
 import (
 	"auth-service/api/token"
+	"auth-service/service"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt"
 )
 
-func JWTMiddleware() gin.HandlerFunc {
+// JWTMiddleware validates the access token and, for tokens bound to a
+// session, that the session hasn't been revoked.
+func JWTMiddleware(ss *service.SessionService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -28,7 +32,52 @@ func JWTMiddleware() gin.HandlerFunc {
 			c.Abort()
 			return
 		}
+		if claims["type"] == token.ChallengeType {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Two-factor authentication required"})
+			c.Abort()
+			return
+		}
+		if claims["type"] == token.RefreshType {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh tokens can't be used as access tokens"})
+			c.Abort()
+			return
+		}
+		if sessionID, ok := claims["session_id"].(string); ok {
+			session, err := ss.Introspect(sessionID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Server error", "err": err.Error()})
+				c.Abort()
+				return
+			}
+			if !session.Active {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Session has been revoked"})
+				c.Abort()
+				return
+			}
+		}
 		c.Set("claims", claims)
 		c.Next()
 	}
 }
+
+// RoleMiddleware only lets through requests whose token carries one of roles.
+// It has to run after JWTMiddleware.
+func RoleMiddleware(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, exists := c.Get("claims")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			c.Abort()
+			return
+		}
+		role, _ := claims.(jwt.MapClaims)["role"].(string)
+		for _, r := range roles {
+			if r == role {
+				c.Next()
+				return
+			}
+		}
+		c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
+		c.Abort()
+	}
+}
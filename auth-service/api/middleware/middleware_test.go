@@ -0,0 +1,50 @@
+package middleware_test
+
+import (
+	"auth-service/api/middleware"
+	"auth-service/api/token"
+	"auth-service/service"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sessionID = "0b5ad0a8-7f4e-4b53-9a3c-6f2d2f6c1a11"
+
+func TestRefreshTokenIsNotAnAccessToken(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/profile", middleware.JWTMiddleware(service.NewSessionService(db)), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	get := func(auth string) int {
+		req := httptest.NewRequest(http.MethodGet, "/profile", nil)
+		req.Header.Set("Authorization", auth)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	tokens := token.GenerateJWTToken("user1", "user@example.com", "user", "user", sessionID)
+
+	// Only the access token gets as far as the session check.
+	mock.ExpectQuery("UPDATE sessions SET last_seen_at").
+		WithArgs(sessionID).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow("user1"))
+	mock.ExpectQuery("FROM bans").
+		WithArgs("user1").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	assert.Equal(t, http.StatusOK, get(tokens.AccessToken))
+
+	assert.Equal(t, http.StatusUnauthorized, get(tokens.RefreshToken))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,177 @@
+package oidc_test
+
+import (
+	"auth-service/api/oidc"
+	"auth-service/config"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockProvider is a minimal OIDC provider: discovery, JWKS and a token
+// endpoint that checks PKCE and signs ID tokens.
+type mockProvider struct {
+	*httptest.Server
+	key   *rsa.PrivateKey
+	mu    sync.Mutex
+	codes map[string]authorization
+}
+
+type authorization struct {
+	challenge string
+	nonce     string
+}
+
+const clientID = "forum"
+
+func newMockProvider(t *testing.T) *mockProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	m := &mockProvider{key: key, codes: map[string]authorization{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"issuer":                                m.URL,
+			"authorization_endpoint":                m.URL + "/authorize",
+			"token_endpoint":                        m.URL + "/token",
+			"jwks_uri":                              m.URL + "/jwks",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+			{Key: &key.PublicKey, KeyID: "test", Algorithm: "RS256", Use: "sig"},
+		}})
+	})
+	mux.HandleFunc("/token", m.token)
+	m.Server = httptest.NewServer(mux)
+	t.Cleanup(m.Close)
+	return m
+}
+
+// authorize plays the user approving the login and returns the code the
+// provider would redirect back with.
+func (m *mockProvider) authorize(t *testing.T, authURL string) (code, state string) {
+	u, err := url.Parse(authURL)
+	require.NoError(t, err)
+	q := u.Query()
+	assert.Equal(t, "S256", q.Get("code_challenge_method"))
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	code = "code-" + q.Get("state")
+	m.codes[code] = authorization{challenge: q.Get("code_challenge"), nonce: q.Get("nonce")}
+	return code, q.Get("state")
+}
+
+func (m *mockProvider) token(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	m.mu.Lock()
+	auth, ok := m.codes[r.Form.Get("code")]
+	delete(m.codes, r.Form.Get("code"))
+	m.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.Form.Get("code_verifier")))
+	if !ok || base64.RawURLEncoding.EncodeToString(sum[:]) != auth.challenge {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":"invalid_grant"}`))
+		return
+	}
+
+	signer, _ := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: jose.JSONWebKey{Key: m.key, KeyID: "test"}}, nil)
+	payload, _ := json.Marshal(map[string]interface{}{
+		"iss":                m.URL,
+		"sub":                "external-123",
+		"aud":                clientID,
+		"iat":                time.Now().Unix(),
+		"exp":                time.Now().Add(time.Minute).Unix(),
+		"nonce":              auth.nonce,
+		"email":              "user@example.com",
+		"email_verified":     true,
+		"preferred_username": "external_user",
+	})
+	jws, _ := signer.Sign(payload)
+	idToken, _ := jws.CompactSerialize()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token": "access",
+		"token_type":   "Bearer",
+		"expires_in":   60,
+		"id_token":     idToken,
+	})
+}
+
+func newProvider(t *testing.T, m *mockProvider) *oidc.Provider {
+	p, err := oidc.NewProvider(context.Background(), config.OIDCProvider{
+		Name:         "mock",
+		Issuer:       m.URL,
+		ClientID:     clientID,
+		ClientSecret: "secret",
+		RedirectURL:  "http://localhost:8088/oauth/mock/callback",
+	})
+	require.NoError(t, err)
+	return p
+}
+
+func TestExchange(t *testing.T) {
+	m := newMockProvider(t)
+	p := newProvider(t, m)
+
+	req, err := oidc.NewAuthRequest()
+	require.NoError(t, err)
+	code, state := m.authorize(t, p.AuthCodeURL(req))
+	assert.Equal(t, req.State, state)
+
+	identity, err := p.Exchange(context.Background(), code, req)
+	require.NoError(t, err)
+	assert.Equal(t, "mock", identity.Provider)
+	assert.Equal(t, "external-123", identity.Subject)
+	assert.Equal(t, "user@example.com", identity.Email)
+	assert.True(t, identity.EmailVerified)
+	assert.Equal(t, "external_user", identity.PreferredUsername)
+}
+
+func TestExchangeRejectsWrongVerifier(t *testing.T) {
+	m := newMockProvider(t)
+	p := newProvider(t, m)
+
+	req, err := oidc.NewAuthRequest()
+	require.NoError(t, err)
+	code, _ := m.authorize(t, p.AuthCodeURL(req))
+
+	other, err := oidc.NewAuthRequest()
+	require.NoError(t, err)
+	req.CodeVerifier = other.CodeVerifier
+
+	_, err = p.Exchange(context.Background(), code, req)
+	assert.Error(t, err)
+}
+
+func TestExchangeRejectsNonceMismatch(t *testing.T) {
+	m := newMockProvider(t)
+	p := newProvider(t, m)
+
+	req, err := oidc.NewAuthRequest()
+	require.NoError(t, err)
+	code, _ := m.authorize(t, p.AuthCodeURL(req))
+	req.Nonce = "replayed"
+
+	_, err = p.Exchange(context.Background(), code, req)
+	assert.Error(t, err)
+}
@@ -20,11 +20,44 @@ func NewRouter(h *handlers.HTTPHandler) *gin.Engine {
 
 	router.GET("/api/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+	router.GET("/healthz", h.Healthz)
+	router.GET("/readyz", h.Readyz)
+
 	router.POST("/register", h.Register)
 	router.POST("/login", h.Login)
+	router.POST("/login/2fa", h.LoginTwoFactor)
+	router.POST("/refresh", h.Refresh)
+
+	router.GET("/oauth/providers", h.OAuthProviders)
+	router.GET("/oauth/:provider/login", h.OAuthLogin)
+	router.GET("/oauth/:provider/callback", h.OAuthCallback)
 
-	protected := router.Group("/", middleware.JWTMiddleware())
+	protected := router.Group("/", middleware.JWTMiddleware(h.SS))
 	protected.GET("/profile", h.Profile)
+	protected.POST("/logout", h.Logout)
+
+	router.POST("/sessions/introspect", h.IntrospectSession)
+	protected.GET("/sessions", h.ListSessions)
+	protected.DELETE("/sessions/:id", h.RevokeSession)
+
+	router.POST("/api-keys/introspect", h.IntrospectAPIKey)
+	apiKeys := protected.Group("/api-keys")
+	apiKeys.POST("", h.CreateAPIKey)
+	apiKeys.GET("", h.ListAPIKeys)
+	apiKeys.DELETE("/:id", h.RevokeAPIKey)
+
+	twoFactor := protected.Group("/2fa")
+	twoFactor.POST("/enroll", h.EnrollTwoFactor)
+	twoFactor.POST("/confirm", h.ConfirmTwoFactor)
+	twoFactor.POST("/disable", h.DisableTwoFactor)
+
+	admin := protected.Group("/admin", middleware.RoleMiddleware("admin"))
+	admin.POST("/users/:id/unlock", h.UnlockUser)
+
+	staff := protected.Group("/admin", middleware.RoleMiddleware("admin", "moderator"))
+	staff.POST("/users/:id/ban", h.BanUser)
+	staff.DELETE("/users/:id/ban", h.UnbanUser)
+	staff.GET("/users/:id/bans", h.ListBans)
 
 	router.GET("/user/:id", h.GetByID)
 	return router
@@ -7,10 +7,16 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
 )
 
 const (
 	signingKey = "mrbek"
+
+	// ChallengeType marks tokens that only allow finishing a two-factor login.
+	ChallengeType = "2fa_challenge"
+	// RefreshType marks refresh tokens, which are accepted only by /refresh.
+	RefreshType = "refresh"
 )
 
 type Tokens struct {
@@ -18,7 +24,7 @@ type Tokens struct {
 	RefreshToken string `json:"refresh_token"`
 }
 
-func GenerateJWTToken(userID string, email string, username string) *Tokens {
+func GenerateJWTToken(userID string, email string, username string, role string, sessionID string) *Tokens {
 	accessToken := jwt.New(jwt.SigningMethodHS256)
 	refreshToken := jwt.New(jwt.SigningMethodHS256)
 
@@ -26,6 +32,8 @@ func GenerateJWTToken(userID string, email string, username string) *Tokens {
 	claims["user_id"] = userID
 	claims["email"] = email
 	claims["username"] = username
+	claims["role"] = role
+	claims["session_id"] = sessionID
 	claims["iat"] = time.Now().Unix()
 	claims["exp"] = time.Now().Add(180 * time.Minute).Unix() // Token expires in 3 minutes
 	access, err := accessToken.SignedString([]byte(signingKey))
@@ -37,6 +45,9 @@ func GenerateJWTToken(userID string, email string, username string) *Tokens {
 	rftClaims["user_id"] = userID
 	rftClaims["email"] = email
 	rftClaims["username"] = username
+	rftClaims["role"] = role
+	rftClaims["session_id"] = sessionID
+	rftClaims["type"] = RefreshType
 	rftClaims["iat"] = time.Now().Unix()
 	rftClaims["exp"] = time.Now().Add(24 * time.Hour).Unix() // Refresh token expires in 24 hours
 	refresh, err := refreshToken.SignedString([]byte(signingKey))
@@ -50,6 +61,52 @@ func GenerateJWTToken(userID string, email string, username string) *Tokens {
 	}
 }
 
+// GenerateChallengeToken issues the short-lived token returned by /login when
+// the account has two-factor authentication enabled. Its jti lets /login/2fa
+// accept it only once.
+func GenerateChallengeToken(userID string, email string, ttl time.Duration) string {
+	challengeToken := jwt.New(jwt.SigningMethodHS256)
+
+	claims := challengeToken.Claims.(jwt.MapClaims)
+	claims["user_id"] = userID
+	claims["email"] = email
+	claims["type"] = ChallengeType
+	claims["jti"] = uuid.NewString()
+	claims["iat"] = time.Now().Unix()
+	claims["exp"] = time.Now().Add(ttl).Unix()
+	challenge, err := challengeToken.SignedString([]byte(signingKey))
+	if err != nil {
+		log.Fatal("error while generating challenge token : ", err)
+	}
+
+	return challenge
+}
+
+func ExtractChallengeClaim(tokenStr string) (jwt.MapClaims, error) {
+	claims, err := ExtractClaim(tokenStr)
+	if err != nil {
+		return nil, err
+	}
+	if claims["type"] != ChallengeType {
+		return nil, errors.New("not a challenge token")
+	}
+	if jti, _ := claims["jti"].(string); jti == "" {
+		return nil, errors.New("challenge token has no id")
+	}
+	return claims, nil
+}
+
+func ExtractRefreshClaim(tokenStr string) (jwt.MapClaims, error) {
+	claims, err := ExtractClaim(tokenStr)
+	if err != nil {
+		return nil, err
+	}
+	if claims["type"] != RefreshType {
+		return nil, errors.New("not a refresh token")
+	}
+	return claims, nil
+}
+
 func ValidateToken(tokenStr string) (bool, error) {
 	_, err := ExtractClaim(tokenStr)
 	if err != nil {
@@ -3,11 +3,21 @@ package config
 import (
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/spf13/cast"
 )
 
+type OIDCProvider struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+}
+
 type Config struct {
 	AUTH_PORT string
 
@@ -17,7 +27,38 @@ type Config struct {
 	DB_PASSWORD string
 	DB_NAME     string
 
+	// Pool limits. Connections are closed after DB_CONN_MAX_LIFETIME, or
+	// DB_CONN_MAX_IDLE_TIME unused, so restarts and failovers are picked up.
+	DB_MAX_OPEN_CONNS     int
+	DB_MAX_IDLE_CONNS     int
+	DB_CONN_MAX_LIFETIME  time.Duration
+	DB_CONN_MAX_IDLE_TIME time.Duration
+
+	// Startup tries DB_CONNECT_ATTEMPTS times, waiting DB_CONNECT_BACKOFF
+	// and then twice as long each time, while the database comes up. Once
+	// running, it is pinged every DB_HEALTH_INTERVAL.
+	DB_CONNECT_ATTEMPTS int
+	DB_CONNECT_BACKOFF  time.Duration
+	DB_HEALTH_INTERVAL  time.Duration
+
+	// Apply pending migrations at startup; otherwise only check the schema
+	// is not newer than the binary.
+	MIGRATE_ON_START bool
+
 	LOG_PATH string
+
+	LOGIN_ATTEMPT_WINDOW    time.Duration
+	LOGIN_BACKOFF_THRESHOLD int
+	LOGIN_BACKOFF_BASE      time.Duration
+	LOGIN_MAX_ATTEMPTS      int
+	LOGIN_IP_MAX_ATTEMPTS   int
+	LOGIN_LOCKOUT_DURATION  time.Duration
+
+	TOTP_ISSUER              string
+	TWO_FACTOR_CHALLENGE_TTL time.Duration
+
+	OIDC_PROVIDERS []OIDCProvider
+	OIDC_STATE_TTL time.Duration
 }
 
 func Load() Config {
@@ -35,8 +76,47 @@ func Load() Config {
 	config.DB_PASSWORD = cast.ToString(coalesce("DB_PASSWORD", "12345"))
 	config.DB_NAME = cast.ToString(coalesce("DB_NAME", "n10"))
 
+	config.DB_MAX_OPEN_CONNS = cast.ToInt(coalesce("DB_MAX_OPEN_CONNS", 25))
+	config.DB_MAX_IDLE_CONNS = cast.ToInt(coalesce("DB_MAX_IDLE_CONNS", 10))
+	config.DB_CONN_MAX_LIFETIME = cast.ToDuration(coalesce("DB_CONN_MAX_LIFETIME", "30m"))
+	config.DB_CONN_MAX_IDLE_TIME = cast.ToDuration(coalesce("DB_CONN_MAX_IDLE_TIME", "5m"))
+
+	config.DB_CONNECT_ATTEMPTS = cast.ToInt(coalesce("DB_CONNECT_ATTEMPTS", 10))
+	config.DB_CONNECT_BACKOFF = cast.ToDuration(coalesce("DB_CONNECT_BACKOFF", "1s"))
+	config.DB_HEALTH_INTERVAL = cast.ToDuration(coalesce("DB_HEALTH_INTERVAL", "10s"))
+
+	config.MIGRATE_ON_START = cast.ToBool(coalesce("MIGRATE_ON_START", true))
+
 	config.LOG_PATH = cast.ToString(coalesce("LOG_PATH", "logs/info.log"))
 
+	config.LOGIN_ATTEMPT_WINDOW = cast.ToDuration(coalesce("LOGIN_ATTEMPT_WINDOW", "15m"))
+	config.LOGIN_BACKOFF_THRESHOLD = cast.ToInt(coalesce("LOGIN_BACKOFF_THRESHOLD", 3))
+	config.LOGIN_BACKOFF_BASE = cast.ToDuration(coalesce("LOGIN_BACKOFF_BASE", "1s"))
+	config.LOGIN_MAX_ATTEMPTS = cast.ToInt(coalesce("LOGIN_MAX_ATTEMPTS", 10))
+	config.LOGIN_IP_MAX_ATTEMPTS = cast.ToInt(coalesce("LOGIN_IP_MAX_ATTEMPTS", 50))
+	config.LOGIN_LOCKOUT_DURATION = cast.ToDuration(coalesce("LOGIN_LOCKOUT_DURATION", "15m"))
+
+	config.TOTP_ISSUER = cast.ToString(coalesce("TOTP_ISSUER", "Forum"))
+	config.TWO_FACTOR_CHALLENGE_TTL = cast.ToDuration(coalesce("TWO_FACTOR_CHALLENGE_TTL", "5m"))
+
+	// OIDC_PROVIDERS is a comma separated list of names, each configured
+	// through OIDC_<NAME>_ISSUER, _CLIENT_ID, _CLIENT_SECRET and _REDIRECT_URL.
+	for _, name := range strings.Split(cast.ToString(coalesce("OIDC_PROVIDERS", "")), ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		config.OIDC_PROVIDERS = append(config.OIDC_PROVIDERS, OIDCProvider{
+			Name:         strings.ToLower(name),
+			Issuer:       cast.ToString(coalesce(prefix+"ISSUER", "")),
+			ClientID:     cast.ToString(coalesce(prefix+"CLIENT_ID", "")),
+			ClientSecret: cast.ToString(coalesce(prefix+"CLIENT_SECRET", "")),
+			RedirectURL:  cast.ToString(coalesce(prefix+"REDIRECT_URL", "")),
+		})
+	}
+	config.OIDC_STATE_TTL = cast.ToDuration(coalesce("OIDC_STATE_TTL", "10m"))
+
 	return config
 }
 
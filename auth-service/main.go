@@ -3,10 +3,14 @@ package main
 import (
 	"auth-service/api"
 	"auth-service/api/handlers"
+	"auth-service/api/oidc"
 	"auth-service/config"
 	"auth-service/config/logger"
+	"auth-service/migrations"
 	"auth-service/postgresql"
 	"auth-service/service"
+	"context"
+	"os"
 	"path/filepath"
 	"runtime"
 )
@@ -25,8 +29,46 @@ func main() {
 	em.CheckErr(err)
 	defer conn.Close()
 
+	m, err := migrations.New(conn)
+	em.CheckErr(err)
+	m.Logf = logger.INFO.Printf
+
+	// auth-service migrate up | down [N] | status | force VERSION
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		em.CheckErr(m.Command(context.Background(), os.Args[2:], os.Stdout))
+		return
+	}
+	if cf.MIGRATE_ON_START {
+		err = m.Up(context.Background())
+	} else {
+		err = m.Check(context.Background())
+	}
+	em.CheckErr(err)
+
+	health := postgresql.NewHealth(conn)
+	health.Logf = logger.WARN.Printf
+	go health.Run(context.Background(), cf.DB_HEALTH_INTERVAL)
+
 	us := service.NewUserService(conn)
-	handler := handlers.NewHandler(us, *logger)
+	ls := service.NewLoginService(conn, cf)
+	ts := service.NewTwoFactorService(conn, cf)
+	oauth := service.NewOAuthService(conn, cf)
+	ks := service.NewAPIKeyService(conn)
+	ss := service.NewSessionService(conn)
+	bs := service.NewBanService(conn)
+
+	var providers []*oidc.Provider
+	for _, pc := range cf.OIDC_PROVIDERS {
+		p, err := oidc.NewProvider(context.Background(), pc)
+		if err != nil {
+			logger.ERROR.Println("skipping identity provider:", err)
+			continue
+		}
+		providers = append(providers, p)
+	}
+
+	handler := handlers.NewHandler(us, ls, ts, oauth, ks, ss, bs, providers, *logger)
+	handler.Ready = health.Err
 
 	router := api.NewRouter(handler)
 	logger.INFO.Println("Server is running on port ", cf.AUTH_PORT)
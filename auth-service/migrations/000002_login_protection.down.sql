@@ -0,0 +1,4 @@
+-- Down migration
+DROP TABLE IF EXISTS login_attempts;
+DROP TABLE IF EXISTS login_throttles;
+ALTER TABLE users DROP COLUMN IF EXISTS role;
\ No newline at end of file
@@ -0,0 +1,3 @@
+-- Down migration
+DROP TABLE IF EXISTS oauth_states;
+DROP TABLE IF EXISTS user_identities;
\ No newline at end of file
@@ -0,0 +1,2 @@
+-- Down migration
+DROP TABLE IF EXISTS api_keys;
\ No newline at end of file
@@ -0,0 +1,2 @@
+-- Down migration
+DROP TABLE IF EXISTS sessions;
\ No newline at end of file
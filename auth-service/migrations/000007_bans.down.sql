@@ -0,0 +1,2 @@
+-- Down migration
+DROP TABLE IF EXISTS bans;
\ No newline at end of file
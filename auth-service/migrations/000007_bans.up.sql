@@ -0,0 +1,13 @@
+-- Up migration
+CREATE TABLE bans (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reason TEXT NOT NULL,
+    banned_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    expires_at TIMESTAMPTZ,
+    lifted_at TIMESTAMPTZ,
+    lifted_by UUID REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX bans_user_id_idx ON bans (user_id) WHERE lifted_at IS NULL;
\ No newline at end of file
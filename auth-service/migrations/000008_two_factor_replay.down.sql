@@ -0,0 +1,3 @@
+-- Down migration
+DROP TABLE IF EXISTS used_challenges;
+ALTER TABLE users DROP COLUMN IF EXISTS totp_last_step;
\ No newline at end of file
@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// Scopes an API key can be granted.
+const (
+	ScopeRead    = "read"
+	ScopePost    = "post"
+	ScopeComment = "comment"
+)
+
+type APIKeyCreateReq struct {
+	Name   string   `json:"name"`   // Label to recognise the key by
+	Scopes []string `json:"scopes"` // Any of read, post, comment
+}
+
+type APIKeyCreateResp struct {
+	APIKey
+	Key string `json:"key"` // The key itself, shown only once
+}
+
+type APIKey struct {
+	ID         string     `json:"id"`           // Key's unique identifier
+	UserID     string     `json:"user_id"`      // Owner of the key
+	Name       string     `json:"name"`         // Label to recognise the key by
+	Prefix     string     `json:"prefix"`       // First characters of the key, safe to display
+	Scopes     []string   `json:"scopes"`       // Granted scopes
+	CreatedAt  time.Time  `json:"created_at"`   // Creation time
+	LastUsedAt *time.Time `json:"last_used_at"` // Last successful use
+	RevokedAt  *time.Time `json:"revoked_at"`   // Set once the key is revoked
+}
+
+type APIKeyListResp struct {
+	APIKeys []APIKey `json:"api_keys"` // Keys of the user, newest first
+	Count   int      `json:"count"`    // Number of keys
+}
+
+type APIKeyIntrospectReq struct {
+	Key string `json:"key"` // Key from an "Authorization: ApiKey ..." header
+}
+
+type APIKeyIntrospectResp struct {
+	KeyID    string   `json:"api_key_id"` // ID of the key
+	UserID   string   `json:"user_id"`    // Owner of the key
+	Email    string   `json:"email"`      // Owner's email
+	Username string   `json:"username"`   // Owner's username
+	Role     string   `json:"role"`       // Owner's role
+	Scopes   []string `json:"scopes"`     // Granted scopes
+}
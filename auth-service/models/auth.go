@@ -31,6 +31,9 @@ type GetProfileResp struct {
 	Username string `json:"username"` // User's username
 	Email    string `json:"email"`    // User's email address
 	Password string `json:"password"` // User's password
+	Role     string `json:"role"`     // User's role (user, moderator, admin)
+
+	TwoFactorEnabled bool `json:"two_factor_enabled"` // Whether login asks for a TOTP code
 }
 
 type GetProfileByIdReq struct {
@@ -41,4 +44,5 @@ type GetProfileByIdResp struct {
 	ID       string `json:"id"`       // User's unique identifier
 	Username string `json:"username"` // User's username
 	Email    string `json:"email"`    // User's email address
+	Role     string `json:"role"`     // User's role
 }
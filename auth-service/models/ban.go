@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+type Ban struct {
+	ID        string     `json:"id"`         // Ban's unique identifier
+	UserID    string     `json:"user_id"`    // Banned user
+	Reason    string     `json:"reason"`     // Why the user was banned
+	BannedBy  string     `json:"banned_by"`  // Moderator who issued the ban
+	CreatedAt time.Time  `json:"created_at"` // When the ban was issued
+	ExpiresAt *time.Time `json:"expires_at"` // End of a temporary ban, null if permanent
+	LiftedAt  *time.Time `json:"lifted_at"`  // When a moderator lifted the ban early
+}
+
+type BanReq struct {
+	Reason   string `json:"reason"`   // Why the user is banned
+	Duration string `json:"duration"` // Length of a temporary ban, e.g. "72h"; empty for a permanent ban
+}
+
+type BanListResp struct {
+	Bans  []Ban `json:"bans"`  // Bans of the user, newest first
+	Count int   `json:"count"` // Number of bans
+}
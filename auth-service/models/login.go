@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// Reasons recorded for failed login attempts.
+const (
+	LoginUnknownEmail  = "unknown_email"
+	LoginWrongPassword = "wrong_password"
+	LoginThrottled     = "throttled"
+	LoginWrongCode     = "wrong_2fa_code"
+)
+
+type LoginAttempt struct {
+	UserID    string `json:"user_id"`    // Owner of the email, empty if the email is unknown
+	Email     string `json:"email"`      // Email the attempt was made for
+	IPAddress string `json:"ip_address"` // Client IP address
+	UserAgent string `json:"user_agent"` // Client User-Agent header
+	Reason    string `json:"reason"`     // Why the attempt failed
+}
+
+type LoginThrottle struct {
+	Key           string     `json:"key"`             // "email:<email>" or "ip:<address>"
+	Failures      int        `json:"failures"`        // Consecutive failures inside the attempt window
+	LastFailureAt time.Time  `json:"last_failure_at"` // Time of the latest failure
+	LockedUntil   *time.Time `json:"locked_until"`    // Set while the key is locked out
+}
+
+type UnlockUserReq struct {
+	ID string `json:"id"` // ID of the user to unlock
+}
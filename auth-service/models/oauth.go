@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+type OAuthState struct {
+	State        string    `json:"state"`         // Opaque value echoed back by the provider
+	Provider     string    `json:"provider"`      // Provider the login was started with
+	Nonce        string    `json:"nonce"`         // Expected nonce of the ID token
+	CodeVerifier string    `json:"code_verifier"` // PKCE code verifier
+	ExpiresAt    time.Time `json:"expires_at"`    // The login has to finish before this time
+}
+
+type UserIdentity struct {
+	Provider string `json:"provider"` // Name of the configured provider
+	Subject  string `json:"subject"`  // Provider's stable user identifier
+	UserID   string `json:"user_id"`  // Linked row in users
+	Email    string `json:"email"`    // Email reported by the provider
+}
+
+type ExternalIdentity struct {
+	Provider          string `json:"provider"`           // Name of the configured provider
+	Subject           string `json:"subject"`            // Provider's stable user identifier
+	Email             string `json:"email"`              // Email claim of the ID token
+	EmailVerified     bool   `json:"email_verified"`     // Whether the provider verified the email
+	Name              string `json:"name"`               // Display name claim
+	PreferredUsername string `json:"preferred_username"` // Username suggested by the provider
+}
+
+type OAuthProvidersResp struct {
+	Providers []string `json:"providers"` // Names usable in /oauth/{provider}/login
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+type Session struct {
+	ID         string    `json:"id"`           // Session's unique identifier
+	UserID     string    `json:"user_id"`      // Owner of the session
+	UserAgent  string    `json:"user_agent"`   // Device the session was last used from
+	IPAddress  string    `json:"ip_address"`   // Address the session was last used from
+	CreatedAt  time.Time `json:"created_at"`   // Login time
+	LastSeenAt time.Time `json:"last_seen_at"` // Last refresh or request
+	Current    bool      `json:"current"`      // Whether this is the session of the caller
+}
+
+type SessionListResp struct {
+	Sessions []Session `json:"sessions"` // Active sessions, most recently seen first
+	Count    int       `json:"count"`    // Number of sessions
+}
+
+type RefreshReq struct {
+	RefreshToken string `json:"refresh_token"` // Refresh token from a previous login or refresh
+}
+
+type SessionIntrospectReq struct {
+	SessionID string `json:"session_id"` // session_id claim of a token
+}
+
+type SessionIntrospectResp struct {
+	Active bool   `json:"active"`  // False once the session was revoked or its owner banned
+	UserID string `json:"user_id"` // Owner of the session
+	Banned bool   `json:"banned"`  // Whether the owner is banned
+}
@@ -0,0 +1,24 @@
+package models
+
+type TwoFactorEnrollResp struct {
+	Secret          string `json:"secret"`           // Base32 TOTP secret for manual entry
+	ProvisioningURI string `json:"provisioning_uri"` // otpauth:// URI to render as a QR code
+}
+
+type TwoFactorCodeReq struct {
+	Code string `json:"code"` // TOTP code or unused recovery code
+}
+
+type TwoFactorConfirmResp struct {
+	RecoveryCodes []string `json:"recovery_codes"` // Single-use codes, shown only once
+}
+
+type TwoFactorChallengeResp struct {
+	TwoFactorRequired bool   `json:"two_factor_required"` // Always true
+	ChallengeToken    string `json:"challenge_token"`     // Short-lived token for /login/2fa
+}
+
+type TwoFactorLoginReq struct {
+	ChallengeToken string `json:"challenge_token"` // Token returned by /login
+	Code           string `json:"code"`            // TOTP code or unused recovery code
+}
@@ -0,0 +1,64 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"sync"
+	"time"
+)
+
+// Health remembers whether the database answered its last ping, so
+// readiness checks don't each query it.
+type Health struct {
+	db   *sql.DB
+	Logf func(format string, args ...interface{})
+
+	mu  sync.RWMutex
+	err error
+}
+
+// NewHealth starts out healthy, for a db ConnectDB just returned.
+func NewHealth(db *sql.DB) *Health {
+	return &Health{db: db, Logf: log.Printf}
+}
+
+// Check pings the database and records the result.
+func (h *Health) Check(ctx context.Context) error {
+	err := h.db.PingContext(ctx)
+	h.mu.Lock()
+	was := h.err
+	h.err = err
+	h.mu.Unlock()
+	if (was == nil) != (err == nil) {
+		if err == nil {
+			h.Logf("Database is reachable again")
+		} else {
+			h.Logf("Database is unreachable: %v", err)
+		}
+	}
+	return err
+}
+
+// Err is the result of the last check.
+func (h *Health) Err() error {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.err
+}
+
+// Run checks the database every interval until ctx is done.
+func (h *Health) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			checkCtx, cancel := context.WithTimeout(ctx, interval)
+			h.Check(checkCtx)
+			cancel()
+		}
+	}
+}
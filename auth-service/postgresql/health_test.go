@@ -0,0 +1,69 @@
+package postgresql_test
+
+import (
+	"auth-service/postgresql"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var ctx = context.Background()
+
+func TestConnectRetries(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	require.NoError(t, err)
+	defer db.Close()
+
+	down := errors.New("connection refused")
+	mock.ExpectPing().WillReturnError(down)
+	mock.ExpectPing().WillReturnError(down)
+	mock.ExpectPing()
+	assert.NoError(t, postgresql.Connect(ctx, db, 3, time.Millisecond))
+
+	mock.ExpectPing().WillReturnError(down)
+	mock.ExpectPing().WillReturnError(down)
+	assert.ErrorIs(t, postgresql.Connect(ctx, db, 2, time.Millisecond), down)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestConnectGivesUpWhenCancelled(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	require.NoError(t, err)
+	defer db.Close()
+
+	down := errors.New("connection refused")
+	mock.ExpectPing().WillReturnError(down)
+	short, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, postgresql.Connect(short, db, 10, time.Hour), down, "it doesn't wait out the backoff")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHealth(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	require.NoError(t, err)
+	defer db.Close()
+
+	health := postgresql.NewHealth(db)
+	var logged []string
+	health.Logf = func(format string, args ...interface{}) { logged = append(logged, format) }
+	assert.NoError(t, health.Err())
+
+	down := errors.New("connection refused")
+	mock.ExpectPing().WillReturnError(down)
+	mock.ExpectPing().WillReturnError(down)
+	health.Check(ctx)
+	health.Check(ctx)
+	assert.ErrorIs(t, health.Err(), down)
+
+	mock.ExpectPing()
+	health.Check(ctx)
+	assert.NoError(t, health.Err())
+	assert.Len(t, logged, 2, "only changes are logged")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -2,21 +2,55 @@ package postgresql
 
 import (
 	"auth-service/config"
+	"context"
 	"database/sql"
 	"fmt"
+	"log"
+	"time"
 
 	_ "github.com/lib/pq"
 )
 
+// maxBackoff caps the wait between connection attempts.
+const maxBackoff = 30 * time.Second
+
+// ConnectDB opens the pool with the configured limits and waits for the
+// database to answer, retrying with backoff while it comes up.
 func ConnectDB(cf *config.Config) (*sql.DB, error) {
 	dbConn := fmt.Sprintf("postgres://%s:%s@%s:%d/%s?sslmode=disable", cf.DB_USER, cf.DB_PASSWORD, cf.DB_HOST, cf.DB_PORT, cf.DB_NAME)
 	db, err := sql.Open("postgres", dbConn)
 	if err != nil {
 		return nil, err
 	}
-	err = db.Ping()
-	if err != nil {
+	db.SetMaxOpenConns(cf.DB_MAX_OPEN_CONNS)
+	db.SetMaxIdleConns(cf.DB_MAX_IDLE_CONNS)
+	db.SetConnMaxLifetime(cf.DB_CONN_MAX_LIFETIME)
+	db.SetConnMaxIdleTime(cf.DB_CONN_MAX_IDLE_TIME)
+
+	if err := Connect(context.Background(), db, cf.DB_CONNECT_ATTEMPTS, cf.DB_CONNECT_BACKOFF); err != nil {
+		db.Close()
 		return nil, err
 	}
 	return db, nil
 }
+
+// Connect pings db until it answers, up to attempts times, waiting backoff
+// after the first failure and twice as long after each next one.
+func Connect(ctx context.Context, db *sql.DB, attempts int, backoff time.Duration) error {
+	var err error
+	for i := 1; ; i++ {
+		if err = db.PingContext(ctx); err == nil {
+			return nil
+		}
+		if i >= attempts {
+			return err
+		}
+		log.Printf("Database not ready (attempt %d of %d): %v; retrying in %s", i, attempts, err, backoff)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, maxBackoff)
+	}
+}
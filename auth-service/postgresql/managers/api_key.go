@@ -0,0 +1,94 @@
+package managers
+
+import (
+	"auth-service/models"
+	"database/sql"
+	"strings"
+)
+
+type APIKeyManager struct {
+	Conn *sql.DB
+}
+
+func NewAPIKeyManager(db *sql.DB) *APIKeyManager {
+	return &APIKeyManager{Conn: db}
+}
+
+func (m *APIKeyManager) Create(key models.APIKey, keyHash string) (*models.APIKey, error) {
+	query := `INSERT INTO api_keys (id, user_id, name, prefix, key_hash, scopes) VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, user_id, name, prefix, scopes, created_at, last_used_at, revoked_at`
+	row := m.Conn.QueryRow(query, key.ID, key.UserID, key.Name, key.Prefix, keyHash, strings.Join(key.Scopes, ","))
+	return scanAPIKey(row)
+}
+
+func (m *APIKeyManager) GetAll(userID string) ([]models.APIKey, error) {
+	query := `SELECT id, user_id, name, prefix, scopes, created_at, last_used_at, revoked_at
+		FROM api_keys WHERE user_id = $1 ORDER BY created_at DESC`
+	rows, err := m.Conn.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []models.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *key)
+	}
+	return keys, rows.Err()
+}
+
+// Revoke reports whether a live key with id belonging to userID was revoked.
+func (m *APIKeyManager) Revoke(userID, id string) (bool, error) {
+	query := "UPDATE api_keys SET revoked_at = NOW() WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL"
+	res, err := m.Conn.Exec(query, id, userID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// Use looks up a live key by hash, records the use and returns the key
+// together with its owner. Keys of banned users are not live.
+func (m *APIKeyManager) Use(keyHash string) (*models.APIKeyIntrospectResp, error) {
+	query := `UPDATE api_keys k SET last_used_at = NOW()
+		FROM users u
+		WHERE k.key_hash = $1 AND k.revoked_at IS NULL AND u.id = k.user_id
+			AND NOT EXISTS (SELECT 1 FROM bans b WHERE b.user_id = u.id AND b.lifted_at IS NULL
+				AND (b.expires_at IS NULL OR b.expires_at > NOW()))
+		RETURNING k.id, u.id, u.email, u.username, u.role, k.scopes`
+	res := &models.APIKeyIntrospectResp{}
+	var scopes string
+	err := m.Conn.QueryRow(query, keyHash).Scan(&res.KeyID, &res.UserID, &res.Email, &res.Username, &res.Role, &scopes)
+	if err != nil {
+		return nil, err
+	}
+	res.Scopes = strings.Split(scopes, ",")
+	return res, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanAPIKey(row rowScanner) (*models.APIKey, error) {
+	key := &models.APIKey{}
+	var scopes string
+	var lastUsedAt, revokedAt sql.NullTime
+	err := row.Scan(&key.ID, &key.UserID, &key.Name, &key.Prefix, &scopes, &key.CreatedAt, &lastUsedAt, &revokedAt)
+	if err != nil {
+		return nil, err
+	}
+	key.Scopes = strings.Split(scopes, ",")
+	if lastUsedAt.Valid {
+		key.LastUsedAt = &lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+	return key, nil
+}
@@ -0,0 +1,79 @@
+package managers
+
+import (
+	"auth-service/models"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type BanManager struct {
+	Conn *sql.DB
+}
+
+func NewBanManager(db *sql.DB) *BanManager {
+	return &BanManager{Conn: db}
+}
+
+func (m *BanManager) Create(userID, bannedBy, reason string, expiresAt *time.Time) (*models.Ban, error) {
+	query := `INSERT INTO bans (user_id, banned_by, reason, expires_at) VALUES ($1, $2, $3, $4)
+		RETURNING id, user_id, COALESCE(banned_by::text, ''), reason, created_at, expires_at, lifted_at`
+	return scanBan(m.Conn.QueryRow(query, userID, bannedBy, reason, expiresAt))
+}
+
+// Active returns the ban in force for the user that ends last, or
+// sql.ErrNoRows if there is none.
+func (m *BanManager) Active(userID string) (*models.Ban, error) {
+	query := `SELECT id, user_id, COALESCE(banned_by::text, ''), reason, created_at, expires_at, lifted_at
+		FROM bans WHERE user_id = $1 AND lifted_at IS NULL AND (expires_at IS NULL OR expires_at > NOW())
+		ORDER BY expires_at DESC NULLS FIRST LIMIT 1`
+	return scanBan(m.Conn.QueryRow(query, userID))
+}
+
+func (m *BanManager) GetAll(userID string) ([]models.Ban, error) {
+	query := `SELECT id, user_id, COALESCE(banned_by::text, ''), reason, created_at, expires_at, lifted_at
+		FROM bans WHERE user_id = $1 ORDER BY created_at DESC`
+	rows, err := m.Conn.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	bans := []models.Ban{}
+	for rows.Next() {
+		b, err := scanBan(rows)
+		if err != nil {
+			return nil, err
+		}
+		bans = append(bans, *b)
+	}
+	return bans, rows.Err()
+}
+
+// Lift ends every ban in force for the user and reports how many there were.
+// It lifts nothing if any of them was issued by a user whose role is not in
+// roles.
+func (m *BanManager) Lift(userID, liftedBy string, roles []string) (int64, error) {
+	query := `UPDATE bans SET lifted_at = NOW(), lifted_by = $2
+		WHERE user_id = $1 AND lifted_at IS NULL AND (expires_at IS NULL OR expires_at > NOW())
+		AND NOT EXISTS (
+			SELECT 1 FROM bans b JOIN users u ON u.id = b.banned_by
+			WHERE b.user_id = $1 AND b.lifted_at IS NULL AND (b.expires_at IS NULL OR b.expires_at > NOW())
+			AND u.role <> ALL($3)
+		)`
+	res, err := m.Conn.Exec(query, userID, liftedBy, pq.Array(roles))
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func scanBan(row rowScanner) (*models.Ban, error) {
+	b := &models.Ban{}
+	err := row.Scan(&b.ID, &b.UserID, &b.BannedBy, &b.Reason, &b.CreatedAt, &b.ExpiresAt, &b.LiftedAt)
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
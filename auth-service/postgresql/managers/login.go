@@ -0,0 +1,67 @@
+package managers
+
+import (
+	"auth-service/models"
+	"database/sql"
+	"time"
+)
+
+type LoginManager struct {
+	Conn *sql.DB
+}
+
+func NewLoginManager(db *sql.DB) *LoginManager {
+	return &LoginManager{Conn: db}
+}
+
+func (m *LoginManager) RecordAttempt(req models.LoginAttempt) error {
+	query := "INSERT INTO login_attempts (user_id, email, ip_address, user_agent, reason) VALUES ($1, $2, $3, $4, $5)"
+	_, err := m.Conn.Exec(query, sql.NullString{String: req.UserID, Valid: req.UserID != ""}, req.Email, req.IPAddress, req.UserAgent, req.Reason)
+	return err
+}
+
+func (m *LoginManager) GetThrottle(key string) (*models.LoginThrottle, error) {
+	query := "SELECT key, failures, last_failure_at, locked_until FROM login_throttles WHERE key = $1"
+	t := &models.LoginThrottle{}
+	var lockedUntil sql.NullTime
+	err := m.Conn.QueryRow(query, key).Scan(&t.Key, &t.Failures, &t.LastFailureAt, &lockedUntil)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if lockedUntil.Valid {
+		t.LockedUntil = &lockedUntil.Time
+	}
+	return t, nil
+}
+
+// AddFailure increments the failure counter of key and returns the new count.
+// Failures older than window no longer count and the counter starts over.
+func (m *LoginManager) AddFailure(key string, window time.Duration) (int, error) {
+	query := `
+		INSERT INTO login_throttles (key, failures, last_failure_at) VALUES ($1, 1, NOW())
+		ON CONFLICT (key) DO UPDATE SET
+			failures = CASE
+				WHEN login_throttles.last_failure_at < NOW() - make_interval(secs => $2) THEN 1
+				ELSE login_throttles.failures + 1
+			END,
+			last_failure_at = NOW()
+		RETURNING failures`
+	var failures int
+	err := m.Conn.QueryRow(query, key, window.Seconds()).Scan(&failures)
+	return failures, err
+}
+
+func (m *LoginManager) Lock(key string, until time.Time) error {
+	query := "UPDATE login_throttles SET locked_until = $1 WHERE key = $2"
+	_, err := m.Conn.Exec(query, until, key)
+	return err
+}
+
+func (m *LoginManager) Reset(key string) error {
+	query := "DELETE FROM login_throttles WHERE key = $1"
+	_, err := m.Conn.Exec(query, key)
+	return err
+}
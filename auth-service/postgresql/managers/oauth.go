@@ -0,0 +1,81 @@
+package managers
+
+import (
+	"auth-service/models"
+	"database/sql"
+)
+
+type OAuthManager struct {
+	Conn *sql.DB
+}
+
+func NewOAuthManager(db *sql.DB) *OAuthManager {
+	return &OAuthManager{Conn: db}
+}
+
+func (m *OAuthManager) SaveState(req models.OAuthState) error {
+	query := "INSERT INTO oauth_states (state, provider, nonce, code_verifier, expires_at) VALUES ($1, $2, $3, $4, $5)"
+	_, err := m.Conn.Exec(query, req.State, req.Provider, req.Nonce, req.CodeVerifier, req.ExpiresAt)
+	return err
+}
+
+// ConsumeState deletes and returns the state so it can only be used once.
+func (m *OAuthManager) ConsumeState(state string) (*models.OAuthState, error) {
+	query := "DELETE FROM oauth_states WHERE state = $1 RETURNING state, provider, nonce, code_verifier, expires_at"
+	s := &models.OAuthState{}
+	err := m.Conn.QueryRow(query, state).Scan(&s.State, &s.Provider, &s.Nonce, &s.CodeVerifier, &s.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (m *OAuthManager) DeleteExpiredStates() error {
+	_, err := m.Conn.Exec("DELETE FROM oauth_states WHERE expires_at < NOW()")
+	return err
+}
+
+func (m *OAuthManager) GetIdentityUserID(provider, subject string) (string, error) {
+	query := "SELECT user_id FROM user_identities WHERE provider = $1 AND subject = $2"
+	var userID string
+	err := m.Conn.QueryRow(query, provider, subject).Scan(&userID)
+	return userID, err
+}
+
+func (m *OAuthManager) LinkIdentity(req models.UserIdentity) error {
+	query := "INSERT INTO user_identities (provider, subject, user_id, email) VALUES ($1, $2, $3, $4)"
+	_, err := m.Conn.Exec(query, req.Provider, req.Subject, req.UserID, req.Email)
+	return err
+}
+
+// RegisterWithIdentity creates a user without a usable password together with
+// its external identity.
+func (m *OAuthManager) RegisterWithIdentity(user models.RegisterReq, identity models.UserIdentity) error {
+	tx, err := m.Conn.Begin()
+	if err != nil {
+		return err
+	}
+	query := "INSERT INTO users (id, username, email, password) VALUES ($1, $2, $3, $4)"
+	_, err = tx.Exec(query, user.ID, user.Username, user.Email, user.Password)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	query = "INSERT INTO user_identities (provider, subject, user_id, email) VALUES ($1, $2, $3, $4)"
+	_, err = tx.Exec(query, identity.Provider, identity.Subject, user.ID, identity.Email)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (m *OAuthManager) UsernameExists(username string) (bool, error) {
+	query := "SELECT COUNT(*) FROM users WHERE username = $1"
+	var count int
+	err := m.Conn.QueryRow(query, username).Scan(&count)
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
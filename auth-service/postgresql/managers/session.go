@@ -0,0 +1,85 @@
+package managers
+
+import (
+	"auth-service/models"
+	"database/sql"
+)
+
+type SessionManager struct {
+	Conn *sql.DB
+}
+
+func NewSessionManager(db *sql.DB) *SessionManager {
+	return &SessionManager{Conn: db}
+}
+
+func (m *SessionManager) Create(s models.Session) (*models.Session, error) {
+	query := `INSERT INTO sessions (id, user_id, user_agent, ip_address) VALUES ($1, $2, $3, $4)
+		RETURNING id, user_id, user_agent, ip_address, created_at, last_seen_at`
+	res := &models.Session{}
+	err := m.Conn.QueryRow(query, s.ID, s.UserID, s.UserAgent, s.IPAddress).
+		Scan(&res.ID, &res.UserID, &res.UserAgent, &res.IPAddress, &res.CreatedAt, &res.LastSeenAt)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Touch records a use of an active session. It returns sql.ErrNoRows if the
+// session doesn't exist, was revoked or belongs to another user.
+func (m *SessionManager) Touch(id, userID, ip, userAgent string) error {
+	query := `UPDATE sessions SET last_seen_at = NOW(), ip_address = $3, user_agent = $4
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`
+	res, err := m.Conn.Exec(query, id, userID, ip, userAgent)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// Seen updates last_seen_at of an active session and returns its owner.
+func (m *SessionManager) Seen(id string) (string, error) {
+	query := "UPDATE sessions SET last_seen_at = NOW() WHERE id = $1 AND revoked_at IS NULL RETURNING user_id"
+	var userID string
+	err := m.Conn.QueryRow(query, id).Scan(&userID)
+	return userID, err
+}
+
+func (m *SessionManager) GetAll(userID string) ([]models.Session, error) {
+	query := `SELECT id, user_id, COALESCE(user_agent, ''), COALESCE(ip_address, ''), created_at, last_seen_at
+		FROM sessions WHERE user_id = $1 AND revoked_at IS NULL ORDER BY last_seen_at DESC`
+	rows, err := m.Conn.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []models.Session{}
+	for rows.Next() {
+		var s models.Session
+		if err := rows.Scan(&s.ID, &s.UserID, &s.UserAgent, &s.IPAddress, &s.CreatedAt, &s.LastSeenAt); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
+
+// Revoke reports whether an active session with id belonging to userID was
+// revoked.
+func (m *SessionManager) Revoke(userID, id string) (bool, error) {
+	query := "UPDATE sessions SET revoked_at = NOW() WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL"
+	res, err := m.Conn.Exec(query, id, userID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
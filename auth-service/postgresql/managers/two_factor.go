@@ -0,0 +1,118 @@
+package managers
+
+import (
+	"database/sql"
+	"time"
+)
+
+type TwoFactorManager struct {
+	Conn *sql.DB
+}
+
+func NewTwoFactorManager(db *sql.DB) *TwoFactorManager {
+	return &TwoFactorManager{Conn: db}
+}
+
+// SetSecret stores a pending secret for the user. It returns false if 2FA is
+// already enabled, in which case the current secret is kept.
+func (m *TwoFactorManager) SetSecret(userID, secret string) (bool, error) {
+	query := "UPDATE users SET totp_secret = $1 WHERE id = $2 AND totp_enabled = FALSE"
+	res, err := m.Conn.Exec(query, secret, userID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (m *TwoFactorManager) GetSecret(userID string) (string, bool, error) {
+	query := "SELECT COALESCE(totp_secret, ''), totp_enabled FROM users WHERE id = $1"
+	var secret string
+	var enabled bool
+	err := m.Conn.QueryRow(query, userID).Scan(&secret, &enabled)
+	return secret, enabled, err
+}
+
+// Enable turns 2FA on and replaces the user's recovery codes. step is the
+// time step of the code that confirmed the secret, which can't be used again.
+func (m *TwoFactorManager) Enable(userID string, step int64, codeHashes []string) error {
+	tx, err := m.Conn.Begin()
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("UPDATE users SET totp_enabled = TRUE, totp_last_step = $2 WHERE id = $1", userID, step)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	_, err = tx.Exec("DELETE FROM recovery_codes WHERE user_id = $1", userID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	for _, hash := range codeHashes {
+		_, err = tx.Exec("INSERT INTO recovery_codes (user_id, code_hash) VALUES ($1, $2)", userID, hash)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (m *TwoFactorManager) Disable(userID string) error {
+	tx, err := m.Conn.Begin()
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("UPDATE users SET totp_enabled = FALSE, totp_secret = NULL WHERE id = $1", userID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	_, err = tx.Exec("DELETE FROM recovery_codes WHERE user_id = $1", userID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// UseRecoveryCode marks the code as used and reports whether it was valid.
+func (m *TwoFactorManager) UseRecoveryCode(userID, codeHash string) (bool, error) {
+	query := "UPDATE recovery_codes SET used_at = NOW() WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL"
+	res, err := m.Conn.Exec(query, userID, codeHash)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// UseStep records that a TOTP code for the given time step was accepted. It
+// reports false if that step or a later one was already used, so each code
+// works once.
+func (m *TwoFactorManager) UseStep(userID string, step int64) (bool, error) {
+	query := "UPDATE users SET totp_last_step = $1 WHERE id = $2 AND totp_last_step < $1"
+	res, err := m.Conn.Exec(query, step, userID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// UseChallenge marks a login challenge token as spent and reports false if
+// it already was. Spent tokens are kept until they expire.
+func (m *TwoFactorManager) UseChallenge(jti string, expiresAt time.Time) (bool, error) {
+	if _, err := m.Conn.Exec("DELETE FROM used_challenges WHERE expires_at < NOW()"); err != nil {
+		return false, err
+	}
+	query := "INSERT INTO used_challenges (jti, expires_at) VALUES ($1, $2) ON CONFLICT DO NOTHING"
+	res, err := m.Conn.Exec(query, jti, expiresAt)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
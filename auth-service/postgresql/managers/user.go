@@ -20,10 +20,10 @@ func (m *UserManager) Register(req models.RegisterReq) error {
 }
 
 func (m *UserManager) Profile(req models.GetProfileReq) (*models.GetProfileResp, error) {
-	query := "SELECT id, username, email, password FROM users WHERE email = $1"
+	query := "SELECT id, username, email, password, role, totp_enabled FROM users WHERE email = $1"
 	row := m.Conn.QueryRow(query, req.Email)
 	var user models.GetProfileResp
-	err := row.Scan(&user.ID, &user.Username, &user.Email, &user.Password)
+	err := row.Scan(&user.ID, &user.Username, &user.Email, &user.Password, &user.Role, &user.TwoFactorEnabled)
 	if err != nil {
 		return nil, err
 	}
@@ -41,9 +41,9 @@ func (m *UserManager) EmailExists(email string) (bool, error) {
 }
 
 func (m *UserManager) GetByID(id *models.GetProfileByIdReq) (*models.GetProfileByIdResp, error) {
-	query := "SELECT id, username, email FROM users WHERE id = $1"
+	query := "SELECT id, username, email, role FROM users WHERE id = $1"
 	user := &models.GetProfileByIdResp{}
-	err := m.Conn.QueryRow(query, id).Scan(user.ID, user.Username, user.Email)
+	err := m.Conn.QueryRow(query, id.ID).Scan(&user.ID, &user.Username, &user.Email, &user.Role)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"auth-service/models"
+	"auth-service/postgresql/managers"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const (
+	apiKeyPrefix    = "fk_"
+	apiKeyPrefixLen = len(apiKeyPrefix) + 8
+)
+
+var (
+	ErrInvalidAPIKeyReq = errors.New("name and at least one of the scopes read, post, comment are required")
+	ErrAPIKeyNotFound   = errors.New("api key not found")
+	ErrInvalidAPIKey    = errors.New("invalid api key")
+)
+
+type APIKeyService struct {
+	KM managers.APIKeyManager
+}
+
+func NewAPIKeyService(conn *sql.DB) *APIKeyService {
+	return &APIKeyService{KM: *managers.NewAPIKeyManager(conn)}
+}
+
+func (s *APIKeyService) Create(userID string, req *models.APIKeyCreateReq) (*models.APIKeyCreateResp, error) {
+	scopes, ok := normalizeScopes(req.Scopes)
+	name := strings.TrimSpace(req.Name)
+	if !ok || name == "" || len(name) > 100 {
+		return nil, ErrInvalidAPIKeyReq
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	key := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(b)
+
+	created, err := s.KM.Create(models.APIKey{
+		ID:     uuid.NewString(),
+		UserID: userID,
+		Name:   name,
+		Prefix: key[:apiKeyPrefixLen],
+		Scopes: scopes,
+	}, hashAPIKey(key))
+	if err != nil {
+		return nil, err
+	}
+	return &models.APIKeyCreateResp{APIKey: *created, Key: key}, nil
+}
+
+func (s *APIKeyService) GetAll(userID string) (*models.APIKeyListResp, error) {
+	keys, err := s.KM.GetAll(userID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.APIKeyListResp{APIKeys: keys, Count: len(keys)}, nil
+}
+
+func (s *APIKeyService) Revoke(userID, id string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return ErrAPIKeyNotFound
+	}
+	ok, err := s.KM.Revoke(userID, id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// Introspect resolves a key presented to the gateway into its owner and
+// scopes.
+func (s *APIKeyService) Introspect(key string) (*models.APIKeyIntrospectResp, error) {
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+	res, err := s.KM.Use(hashAPIKey(key))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInvalidAPIKey
+	}
+	return res, err
+}
+
+func normalizeScopes(scopes []string) ([]string, bool) {
+	allowed := map[string]bool{models.ScopeRead: true, models.ScopePost: true, models.ScopeComment: true}
+	seen := map[string]bool{}
+	var result []string
+	for _, scope := range scopes {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		if !allowed[scope] {
+			return nil, false
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			result = append(result, scope)
+		}
+	}
+	return result, len(result) > 0
+}
+
+// API keys are 256-bit random values, so SHA-256 is enough to store them and
+// allows looking them up by hash.
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,80 @@
+package service_test
+
+import (
+	"auth-service/models"
+	"auth-service/service"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const keyID = "3c9d6e1f-4a2b-4c8d-9e7f-1a2b3c4d5e6f"
+
+var apiKeyColumns = []string{"id", "user_id", "name", "prefix", "scopes", "created_at", "last_used_at", "revoked_at"}
+
+func TestAPIKeyLifecycle(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	s := service.NewAPIKeyService(db)
+
+	// Scopes are normalized and deduplicated; only the hash is stored.
+	mock.ExpectQuery("INSERT INTO api_keys").
+		WithArgs(sqlmock.AnyArg(), userID, "bot", sqlmock.AnyArg(), sqlmock.AnyArg(), "read,post").
+		WillReturnRows(sqlmock.NewRows(apiKeyColumns).AddRow(keyID, userID, "bot", "fk_abcdefgh", "read,post", time.Now(), nil, nil))
+	created, err := s.Create(userID, &models.APIKeyCreateReq{Name: " bot ", Scopes: []string{" Read", "post", "read"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"read", "post"}, created.Scopes)
+	assert.Regexp(t, "^fk_", created.Key)
+
+	sum := sha256.Sum256([]byte(created.Key))
+	use := func() *sqlmock.ExpectedQuery {
+		return mock.ExpectQuery("UPDATE api_keys k SET last_used_at = NOW\\(\\)").
+			WithArgs(hex.EncodeToString(sum[:]))
+	}
+	use().WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "email", "username", "role", "scopes"}).
+		AddRow(keyID, userID, "user@example.com", "user", "user", "read,post"))
+	owner, err := s.Introspect(created.Key)
+	require.NoError(t, err)
+	assert.Equal(t, userID, owner.UserID)
+	assert.Equal(t, []string{"read", "post"}, owner.Scopes)
+
+	revoke := func(revoked int64) {
+		mock.ExpectExec("UPDATE api_keys SET revoked_at = NOW\\(\\) WHERE id = \\$1 AND user_id = \\$2 AND revoked_at IS NULL").
+			WithArgs(keyID, userID).
+			WillReturnResult(sqlmock.NewResult(0, revoked))
+	}
+	revoke(1)
+	assert.NoError(t, s.Revoke(userID, keyID))
+	revoke(0)
+	assert.ErrorIs(t, s.Revoke(userID, keyID), service.ErrAPIKeyNotFound)
+
+	// A revoked key is no longer found.
+	use().WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "email", "username", "role", "scopes"}))
+	_, err = s.Introspect(created.Key)
+	assert.ErrorIs(t, err, service.ErrInvalidAPIKey)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAPIKeyRejectsBadInput(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	s := service.NewAPIKeyService(db)
+
+	_, err = s.Create(userID, &models.APIKeyCreateReq{Name: "bot", Scopes: []string{"read", "admin"}})
+	assert.ErrorIs(t, err, service.ErrInvalidAPIKeyReq)
+	_, err = s.Create(userID, &models.APIKeyCreateReq{Name: " ", Scopes: []string{"read"}})
+	assert.ErrorIs(t, err, service.ErrInvalidAPIKeyReq)
+	_, err = s.Introspect("not-a-key")
+	assert.ErrorIs(t, err, service.ErrInvalidAPIKey)
+	assert.ErrorIs(t, s.Revoke(userID, "not-a-uuid"), service.ErrAPIKeyNotFound)
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "none of these reach the database")
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"auth-service/models"
+	"auth-service/postgresql/managers"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidBanReq = errors.New("reason is required and duration must be a positive length like \"72h\"")
+	ErrNotBanned     = errors.New("user is not banned")
+	ErrBanOutranks   = errors.New("the ban was issued by a higher role")
+)
+
+type BanService struct {
+	BM managers.BanManager
+}
+
+func NewBanService(conn *sql.DB) *BanService {
+	return &BanService{BM: *managers.NewBanManager(conn)}
+}
+
+// Ban bans the user for req.Duration, or for good if it is empty.
+func (s *BanService) Ban(userID, bannedBy string, req *models.BanReq) (*models.Ban, error) {
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return nil, ErrInvalidBanReq
+	}
+	var expiresAt *time.Time
+	if req.Duration != "" {
+		d, err := time.ParseDuration(req.Duration)
+		if err != nil || d <= 0 {
+			return nil, ErrInvalidBanReq
+		}
+		t := time.Now().Add(d)
+		expiresAt = &t
+	}
+	return s.BM.Create(userID, bannedBy, reason, expiresAt)
+}
+
+// Active returns the ban in force for the user, or nil if there is none.
+func (s *BanService) Active(userID string) (*models.Ban, error) {
+	ban, err := s.BM.Active(userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return ban, err
+}
+
+func (s *BanService) GetAll(userID string) (*models.BanListResp, error) {
+	bans, err := s.BM.GetAll(userID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.BanListResp{Bans: bans, Count: len(bans)}, nil
+}
+
+// Lift ends the user's bans if every one of them was issued by a user whose
+// role is in roles.
+func (s *BanService) Lift(userID, liftedBy string, roles []string) error {
+	n, err := s.BM.Lift(userID, liftedBy, roles)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return nil
+	}
+	ban, err := s.Active(userID)
+	if err != nil {
+		return err
+	}
+	if ban != nil {
+		return ErrBanOutranks
+	}
+	return ErrNotBanned
+}
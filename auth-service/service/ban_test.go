@@ -0,0 +1,48 @@
+package service_test
+
+import (
+	"auth-service/service"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const moderatorID = "0b6d2f3e-8c1a-4d7e-9f20-5a3b4c6d7e81"
+
+var banColumns = []string{"id", "user_id", "banned_by", "reason", "created_at", "expires_at", "lifted_at"}
+
+func TestLiftBan(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	s := service.NewBanService(db)
+	roles := []string{"user", "moderator"}
+
+	lift := func(lifted int64) {
+		mock.ExpectExec("UPDATE bans SET lifted_at = NOW\\(\\), lifted_by = \\$2").
+			WithArgs(userID, moderatorID, pq.Array(roles)).
+			WillReturnResult(sqlmock.NewResult(0, lifted))
+	}
+
+	lift(1)
+	assert.NoError(t, s.Lift(userID, moderatorID, roles))
+
+	// Nothing was lifted because an admin's ban is still in force.
+	lift(0)
+	mock.ExpectQuery("SELECT (.+) FROM bans WHERE user_id = \\$1 AND lifted_at IS NULL").
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows(banColumns).AddRow("ban1", userID, "admin1", "spam", time.Now(), nil, nil))
+	assert.ErrorIs(t, s.Lift(userID, moderatorID, roles), service.ErrBanOutranks)
+
+	lift(0)
+	mock.ExpectQuery("SELECT (.+) FROM bans WHERE user_id = \\$1 AND lifted_at IS NULL").
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows(banColumns))
+	assert.ErrorIs(t, s.Lift(userID, moderatorID, roles), service.ErrNotBanned)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
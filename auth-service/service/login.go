@@ -0,0 +1,120 @@
+package service
+
+import (
+	"auth-service/config"
+	"auth-service/models"
+	"auth-service/postgresql/managers"
+	"database/sql"
+	"strings"
+	"time"
+)
+
+type LoginService struct {
+	LM  managers.LoginManager
+	cfg config.Config
+}
+
+func NewLoginService(conn *sql.DB, cfg config.Config) *LoginService {
+	return &LoginService{LM: *managers.NewLoginManager(conn), cfg: cfg}
+}
+
+func emailKey(email string) string {
+	return "email:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+// Check reports how long the client has to wait before it may try to log in
+// for email from ip again. Zero means the attempt is allowed.
+func (s *LoginService) Check(email, ip string) (time.Duration, error) {
+	now := time.Now()
+
+	account, err := s.LM.GetThrottle(emailKey(email))
+	if err != nil {
+		return 0, err
+	}
+	wait := s.accountWait(account, now)
+
+	client, err := s.LM.GetThrottle(ipKey(ip))
+	if err != nil {
+		return 0, err
+	}
+	if w := s.clientWait(client, now); w > wait {
+		wait = w
+	}
+	return wait, nil
+}
+
+// accountWait applies exponential backoff once an account has reached the
+// backoff threshold and a full lockout once it reaches the maximum.
+func (s *LoginService) accountWait(t *models.LoginThrottle, now time.Time) time.Duration {
+	if t == nil {
+		return 0
+	}
+	if t.LockedUntil != nil && t.LockedUntil.After(now) {
+		return t.LockedUntil.Sub(now)
+	}
+	if now.Sub(t.LastFailureAt) > s.cfg.LOGIN_ATTEMPT_WINDOW || t.Failures < s.cfg.LOGIN_BACKOFF_THRESHOLD {
+		return 0
+	}
+	delay := s.cfg.LOGIN_BACKOFF_BASE
+	for i := s.cfg.LOGIN_BACKOFF_THRESHOLD; i < t.Failures && delay < s.cfg.LOGIN_LOCKOUT_DURATION; i++ {
+		delay *= 2
+	}
+	if delay > s.cfg.LOGIN_LOCKOUT_DURATION {
+		delay = s.cfg.LOGIN_LOCKOUT_DURATION
+	}
+	if until := t.LastFailureAt.Add(delay); until.After(now) {
+		return until.Sub(now)
+	}
+	return 0
+}
+
+func (s *LoginService) clientWait(t *models.LoginThrottle, now time.Time) time.Duration {
+	if t == nil {
+		return 0
+	}
+	if t.LockedUntil != nil && t.LockedUntil.After(now) {
+		return t.LockedUntil.Sub(now)
+	}
+	return 0
+}
+
+// Fail records a failed attempt and locks the account or the client address
+// when they run out of attempts.
+func (s *LoginService) Fail(attempt models.LoginAttempt) error {
+	if err := s.LM.RecordAttempt(attempt); err != nil {
+		return err
+	}
+	if attempt.Reason == models.LoginThrottled {
+		return nil
+	}
+
+	limits := map[string]int{
+		emailKey(attempt.Email):  s.cfg.LOGIN_MAX_ATTEMPTS,
+		ipKey(attempt.IPAddress): s.cfg.LOGIN_IP_MAX_ATTEMPTS,
+	}
+	for key, max := range limits {
+		failures, err := s.LM.AddFailure(key, s.cfg.LOGIN_ATTEMPT_WINDOW)
+		if err != nil {
+			return err
+		}
+		if failures >= max {
+			if err := s.LM.Lock(key, time.Now().Add(s.cfg.LOGIN_LOCKOUT_DURATION)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Succeed clears the failure counter of the account that just logged in.
+func (s *LoginService) Succeed(email string) error {
+	return s.LM.Reset(emailKey(email))
+}
+
+func (s *LoginService) Unlock(email string) error {
+	return s.LM.Reset(emailKey(email))
+}
@@ -0,0 +1,91 @@
+package service_test
+
+import (
+	"auth-service/config"
+	"auth-service/models"
+	"auth-service/service"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var throttleColumns = []string{"key", "failures", "last_failure_at", "locked_until"}
+
+var loginConfig = config.Config{
+	LOGIN_MAX_ATTEMPTS:      3,
+	LOGIN_IP_MAX_ATTEMPTS:   100,
+	LOGIN_ATTEMPT_WINDOW:    15 * time.Minute,
+	LOGIN_BACKOFF_THRESHOLD: 2,
+	LOGIN_BACKOFF_BASE:      time.Second,
+	LOGIN_LOCKOUT_DURATION:  15 * time.Minute,
+}
+
+func TestLoginLockout(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	// The account and the client are counted in no particular order.
+	mock.MatchExpectationsInOrder(false)
+	s := service.NewLoginService(db, loginConfig)
+	attempt := models.LoginAttempt{Email: "User@Example.com ", IPAddress: "192.0.2.1", Reason: models.LoginWrongPassword}
+
+	mock.ExpectExec("INSERT INTO login_attempts").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("INSERT INTO login_throttles").
+		WithArgs("email:user@example.com", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"failures"}).AddRow(3))
+	mock.ExpectQuery("INSERT INTO login_throttles").
+		WithArgs("ip:192.0.2.1", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"failures"}).AddRow(3))
+	mock.ExpectExec("UPDATE login_throttles SET locked_until = \\$1 WHERE key = \\$2").
+		WithArgs(sqlmock.AnyArg(), "email:user@example.com").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, s.Fail(attempt))
+	require.NoError(t, mock.ExpectationsWereMet(), "only the account ran out of attempts")
+
+	mock.MatchExpectationsInOrder(true)
+	lockedUntil := time.Now().Add(10 * time.Minute)
+	mock.ExpectQuery("SELECT key, failures, last_failure_at, locked_until FROM login_throttles").
+		WithArgs("email:user@example.com").
+		WillReturnRows(sqlmock.NewRows(throttleColumns).AddRow("email:user@example.com", 3, time.Now(), lockedUntil))
+	mock.ExpectQuery("SELECT key, failures, last_failure_at, locked_until FROM login_throttles").
+		WithArgs("ip:192.0.2.1").
+		WillReturnRows(sqlmock.NewRows(throttleColumns))
+	wait, err := s.Check(attempt.Email, attempt.IPAddress)
+	require.NoError(t, err)
+	assert.InDelta(t, 10*time.Minute, wait, float64(time.Second))
+
+	mock.ExpectExec("DELETE FROM login_throttles WHERE key = \\$1").
+		WithArgs("email:user@example.com").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, s.Unlock("user@example.com"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLoginBackoff(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	s := service.NewLoginService(db, loginConfig)
+
+	check := func(failures int, lastFailure time.Time) time.Duration {
+		mock.ExpectQuery("SELECT key, failures, last_failure_at, locked_until FROM login_throttles").
+			WithArgs("email:user@example.com").
+			WillReturnRows(sqlmock.NewRows(throttleColumns).AddRow("email:user@example.com", failures, lastFailure, nil))
+		mock.ExpectQuery("SELECT key, failures, last_failure_at, locked_until FROM login_throttles").
+			WithArgs("ip:192.0.2.1").
+			WillReturnRows(sqlmock.NewRows(throttleColumns))
+		wait, err := s.Check("user@example.com", "192.0.2.1")
+		require.NoError(t, err)
+		return wait
+	}
+
+	assert.Zero(t, check(1, time.Now()), "below the threshold")
+	assert.InDelta(t, time.Second, check(2, time.Now()), float64(100*time.Millisecond))
+	assert.InDelta(t, 4*time.Second, check(4, time.Now()), float64(100*time.Millisecond), "the delay doubles")
+	assert.Zero(t, check(4, time.Now().Add(-time.Hour)), "old failures no longer count")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"auth-service/config"
+	"auth-service/models"
+	"auth-service/postgresql/managers"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidState         = errors.New("invalid or expired login state")
+	ErrEmailRequired        = errors.New("identity provider did not return an email")
+	ErrEmailNotVerified     = errors.New("an account with this email already exists and the provider did not verify the email")
+	disallowedUsernameChars = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+)
+
+type OAuthService struct {
+	OM  managers.OAuthManager
+	UM  managers.UserManager
+	cfg config.Config
+}
+
+func NewOAuthService(conn *sql.DB, cfg config.Config) *OAuthService {
+	return &OAuthService{OM: *managers.NewOAuthManager(conn), UM: *managers.NewUserManager(conn), cfg: cfg}
+}
+
+func (s *OAuthService) SaveState(state models.OAuthState) error {
+	if err := s.OM.DeleteExpiredStates(); err != nil {
+		return err
+	}
+	state.ExpiresAt = time.Now().Add(s.cfg.OIDC_STATE_TTL)
+	return s.OM.SaveState(state)
+}
+
+// ConsumeState returns the stored login state for the provider callback. A
+// state can be used only once.
+func (s *OAuthService) ConsumeState(provider, state string) (*models.OAuthState, error) {
+	st, err := s.OM.ConsumeState(state)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInvalidState
+	}
+	if err != nil {
+		return nil, err
+	}
+	if st.Provider != provider || time.Now().After(st.ExpiresAt) {
+		return nil, ErrInvalidState
+	}
+	return st, nil
+}
+
+// SignIn returns the user linked to the external identity. Unknown identities
+// are linked to the account with the same email when the provider verified
+// it, otherwise a new account is created.
+func (s *OAuthService) SignIn(identity *models.ExternalIdentity) (*models.GetProfileResp, error) {
+	userID, err := s.OM.GetIdentityUserID(identity.Provider, identity.Subject)
+	if err == nil {
+		user, err := s.UM.GetByID(&models.GetProfileByIdReq{ID: userID})
+		if err != nil {
+			return nil, err
+		}
+		return s.UM.Profile(models.GetProfileReq{Email: user.Email})
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	if identity.Email == "" {
+		return nil, ErrEmailRequired
+	}
+	link := models.UserIdentity{Provider: identity.Provider, Subject: identity.Subject, Email: identity.Email}
+
+	existing, err := s.UM.Profile(models.GetProfileReq{Email: identity.Email})
+	if err == nil {
+		if !identity.EmailVerified {
+			return nil, ErrEmailNotVerified
+		}
+		link.UserID = existing.ID
+		if err := s.OM.LinkIdentity(link); err != nil {
+			return nil, err
+		}
+		return existing, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	username, err := s.freeUsername(identity)
+	if err != nil {
+		return nil, err
+	}
+	// An empty password hash never matches, so the account can only sign
+	// in through its identity provider.
+	user := models.RegisterReq{ID: uuid.NewString(), Username: username, Email: identity.Email}
+	if err := s.OM.RegisterWithIdentity(user, link); err != nil {
+		return nil, err
+	}
+	return s.UM.Profile(models.GetProfileReq{Email: identity.Email})
+}
+
+func (s *OAuthService) freeUsername(identity *models.ExternalIdentity) (string, error) {
+	base := identity.PreferredUsername
+	if base == "" {
+		base = strings.SplitN(identity.Email, "@", 2)[0]
+	}
+	base = disallowedUsernameChars.ReplaceAllString(base, "")
+	if base == "" {
+		base = "user"
+	}
+
+	username := base
+	for i := 0; i < 5; i++ {
+		exists, err := s.OM.UsernameExists(username)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return username, nil
+		}
+		suffix := make([]byte, 3)
+		if _, err := rand.Read(suffix); err != nil {
+			return "", err
+		}
+		username = base + "-" + hex.EncodeToString(suffix)
+	}
+	return uuid.NewString(), nil
+}
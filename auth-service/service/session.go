@@ -0,0 +1,85 @@
+package service
+
+import (
+	"auth-service/models"
+	"auth-service/postgresql/managers"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+var ErrSessionNotFound = errors.New("session not found")
+
+type SessionService struct {
+	SM managers.SessionManager
+	BM managers.BanManager
+}
+
+func NewSessionService(conn *sql.DB) *SessionService {
+	return &SessionService{SM: *managers.NewSessionManager(conn), BM: *managers.NewBanManager(conn)}
+}
+
+func (s *SessionService) Create(userID, ip, userAgent string) (*models.Session, error) {
+	return s.SM.Create(models.Session{ID: uuid.NewString(), UserID: userID, IPAddress: ip, UserAgent: userAgent})
+}
+
+// Refresh records the refresh of an active session owned by userID.
+func (s *SessionService) Refresh(id, userID, ip, userAgent string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return ErrSessionNotFound
+	}
+	err := s.SM.Touch(id, userID, ip, userAgent)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrSessionNotFound
+	}
+	return err
+}
+
+// Introspect tells whether a session is still active and marks it as seen.
+// Sessions of banned users are reported inactive.
+func (s *SessionService) Introspect(id string) (*models.SessionIntrospectResp, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return &models.SessionIntrospectResp{}, nil
+	}
+	userID, err := s.SM.Seen(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return &models.SessionIntrospectResp{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	_, err = s.BM.Active(userID)
+	if err == nil {
+		return &models.SessionIntrospectResp{UserID: userID, Banned: true}, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	return &models.SessionIntrospectResp{Active: true, UserID: userID}, nil
+}
+
+func (s *SessionService) GetAll(userID, currentID string) (*models.SessionListResp, error) {
+	sessions, err := s.SM.GetAll(userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentID
+	}
+	return &models.SessionListResp{Sessions: sessions, Count: len(sessions)}, nil
+}
+
+func (s *SessionService) Revoke(userID, id string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return ErrSessionNotFound
+	}
+	ok, err := s.SM.Revoke(userID, id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrSessionNotFound
+	}
+	return nil
+}
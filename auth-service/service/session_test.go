@@ -0,0 +1,93 @@
+package service_test
+
+import (
+	"auth-service/service"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sessionID = "0b5ad0a8-7f4e-4b53-9a3c-6f2d2f6c1a11"
+
+func TestRefreshSession(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	s := service.NewSessionService(db)
+
+	touch := func(touched int64) {
+		mock.ExpectExec("UPDATE sessions SET last_seen_at = NOW\\(\\), ip_address = \\$3, user_agent = \\$4").
+			WithArgs(sessionID, userID, "192.0.2.1", "curl").
+			WillReturnResult(sqlmock.NewResult(0, touched))
+	}
+	touch(1)
+	assert.NoError(t, s.Refresh(sessionID, userID, "192.0.2.1", "curl"))
+
+	// Revoked, or another user's.
+	touch(0)
+	assert.ErrorIs(t, s.Refresh(sessionID, userID, "192.0.2.1", "curl"), service.ErrSessionNotFound)
+
+	// Tokens from before sessions carry no session ID.
+	assert.ErrorIs(t, s.Refresh("", userID, "192.0.2.1", "curl"), service.ErrSessionNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRevokeSession(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	s := service.NewSessionService(db)
+
+	for _, revoked := range []int64{1, 0} {
+		mock.ExpectExec("UPDATE sessions SET revoked_at = NOW\\(\\) WHERE id = \\$1 AND user_id = \\$2 AND revoked_at IS NULL").
+			WithArgs(sessionID, userID).
+			WillReturnResult(sqlmock.NewResult(0, revoked))
+	}
+	assert.NoError(t, s.Revoke(userID, sessionID))
+	assert.ErrorIs(t, s.Revoke(userID, sessionID), service.ErrSessionNotFound)
+	assert.ErrorIs(t, s.Revoke(userID, "not-a-uuid"), service.ErrSessionNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestIntrospectSession(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	s := service.NewSessionService(db)
+
+	seen := func() *sqlmock.ExpectedQuery {
+		return mock.ExpectQuery("UPDATE sessions SET last_seen_at = NOW\\(\\) WHERE id = \\$1 AND revoked_at IS NULL RETURNING user_id").
+			WithArgs(sessionID)
+	}
+	ban := func() *sqlmock.ExpectedQuery {
+		return mock.ExpectQuery("FROM bans WHERE user_id = \\$1 AND lifted_at IS NULL").
+			WithArgs(userID)
+	}
+
+	seen().WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(userID))
+	ban().WillReturnRows(sqlmock.NewRows(banColumns))
+	res, err := s.Introspect(sessionID)
+	require.NoError(t, err)
+	assert.True(t, res.Active)
+	assert.Equal(t, userID, res.UserID)
+
+	seen().WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(userID))
+	ban().WillReturnRows(sqlmock.NewRows(banColumns).AddRow("ban1", userID, moderatorID, "spam", time.Now(), nil, nil))
+	res, err = s.Introspect(sessionID)
+	require.NoError(t, err)
+	assert.False(t, res.Active)
+	assert.True(t, res.Banned)
+
+	seen().WillReturnRows(sqlmock.NewRows([]string{"user_id"}))
+	res, err = s.Introspect(sessionID)
+	require.NoError(t, err)
+	assert.False(t, res.Active, "the session was revoked")
+
+	res, err = s.Introspect("not-a-uuid")
+	require.NoError(t, err)
+	assert.False(t, res.Active)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
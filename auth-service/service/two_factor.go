@@ -0,0 +1,179 @@
+package service
+
+import (
+	"auth-service/config"
+	"auth-service/models"
+	"auth-service/postgresql/managers"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/hotp"
+	"github.com/pquerna/otp/totp"
+)
+
+const (
+	recoveryCodeCount = 10
+	totpPeriod        = 30 // seconds, the authenticator app default
+)
+
+var (
+	ErrTwoFactorEnabled     = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnabled  = errors.New("two-factor authentication is not enabled")
+	ErrTwoFactorNotEnrolled = errors.New("two-factor enrollment was not started")
+	ErrInvalidCode          = errors.New("invalid two-factor code")
+	ErrChallengeUsed        = errors.New("challenge token was already used")
+)
+
+type TwoFactorService struct {
+	TM  managers.TwoFactorManager
+	cfg config.Config
+}
+
+func NewTwoFactorService(conn *sql.DB, cfg config.Config) *TwoFactorService {
+	return &TwoFactorService{TM: *managers.NewTwoFactorManager(conn), cfg: cfg}
+}
+
+// Enroll generates a new secret for the user. 2FA stays off until the secret
+// is confirmed with a valid code.
+func (s *TwoFactorService) Enroll(userID, email string) (*models.TwoFactorEnrollResp, error) {
+	key, err := totp.Generate(totp.GenerateOpts{Issuer: s.cfg.TOTP_ISSUER, AccountName: email})
+	if err != nil {
+		return nil, err
+	}
+	ok, err := s.TM.SetSecret(userID, key.Secret())
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrTwoFactorEnabled
+	}
+	return &models.TwoFactorEnrollResp{Secret: key.Secret(), ProvisioningURI: key.URL()}, nil
+}
+
+// Confirm enables 2FA once the user proves the authenticator works and
+// returns fresh recovery codes in plain text.
+func (s *TwoFactorService) Confirm(userID, code string) (*models.TwoFactorConfirmResp, error) {
+	secret, enabled, err := s.TM.GetSecret(userID)
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		return nil, ErrTwoFactorEnabled
+	}
+	if secret == "" {
+		return nil, ErrTwoFactorNotEnrolled
+	}
+	step, ok := totpStep(strings.TrimSpace(code), secret, time.Now())
+	if !ok {
+		return nil, ErrInvalidCode
+	}
+
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		codes[i], err = newRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+	if err := s.TM.Enable(userID, step, hashes); err != nil {
+		return nil, err
+	}
+	return &models.TwoFactorConfirmResp{RecoveryCodes: codes}, nil
+}
+
+func (s *TwoFactorService) Disable(userID, code string) error {
+	if err := s.Verify(userID, code); err != nil {
+		return err
+	}
+	return s.TM.Disable(userID)
+}
+
+// Verify accepts either a current TOTP code or an unused recovery code. Both
+// are consumed: a TOTP code is refused once its time step or a later one has
+// been used, so a code seen over someone's shoulder can't be replayed.
+func (s *TwoFactorService) Verify(userID, code string) error {
+	secret, enabled, err := s.TM.GetSecret(userID)
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		return ErrTwoFactorNotEnabled
+	}
+	if step, ok := totpStep(strings.TrimSpace(code), secret, time.Now()); ok {
+		fresh, err := s.TM.UseStep(userID, step)
+		if err != nil {
+			return err
+		}
+		if !fresh {
+			return ErrInvalidCode
+		}
+		return nil
+	}
+	ok, err := s.TM.UseRecoveryCode(userID, hashRecoveryCode(code))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidCode
+	}
+	return nil
+}
+
+// UseChallenge spends a login challenge token so it finishes one login only.
+func (s *TwoFactorService) UseChallenge(jti string, expiresAt time.Time) error {
+	ok, err := s.TM.UseChallenge(jti, expiresAt)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrChallengeUsed
+	}
+	return nil
+}
+
+// totpStep finds the time step code belongs to, allowing one step of clock
+// drift either way as totp.Validate does.
+func totpStep(code, secret string, now time.Time) (int64, bool) {
+	current := now.Unix() / totpPeriod
+	for _, step := range []int64{current - 1, current, current + 1} {
+		ok, _ := hotp.ValidateCustom(code, uint64(step), secret, hotp.ValidateOpts{
+			Digits:    otp.DigitsSix,
+			Algorithm: otp.AlgorithmSHA1,
+		})
+		if ok {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+func newRecoveryCode() (string, error) {
+	b := make([]byte, 10)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	code := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b))
+	return code[:8] + "-" + code[8:], nil
+}
+
+// Recovery codes carry 80 random bits, so a plain SHA-256 is enough and lets
+// them be looked up directly.
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+// ChallengeTTL is how long a login challenge token stays valid.
+func (s *TwoFactorService) ChallengeTTL() time.Duration {
+	return s.cfg.TWO_FACTOR_CHALLENGE_TTL
+}
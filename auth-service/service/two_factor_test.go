@@ -0,0 +1,156 @@
+package service_test
+
+import (
+	"auth-service/config"
+	"auth-service/service"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const userID = "5f0c6f8e-2b7a-4f4e-9d51-0c1f7d3a9b21"
+
+func TestEnrollAndConfirm(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	s := service.NewTwoFactorService(db, config.Config{TOTP_ISSUER: "forum"})
+
+	mock.ExpectExec("UPDATE users SET totp_secret = \\$1 WHERE id = \\$2 AND totp_enabled = FALSE").
+		WithArgs(sqlmock.AnyArg(), userID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	enrolled, err := s.Enroll(userID, "user@example.com")
+	require.NoError(t, err)
+	assert.Contains(t, enrolled.ProvisioningURI, "issuer=forum")
+	secret := func(enabled bool) {
+		mock.ExpectQuery("SELECT COALESCE\\(totp_secret, ''\\), totp_enabled FROM users").
+			WithArgs(userID).
+			WillReturnRows(sqlmock.NewRows([]string{"totp_secret", "totp_enabled"}).AddRow(enrolled.Secret, enabled))
+	}
+
+	// A wrong code leaves 2FA off.
+	secret(false)
+	_, err = s.Confirm(userID, "000000")
+	assert.ErrorIs(t, err, service.ErrInvalidCode)
+
+	code, err := totp.GenerateCode(enrolled.Secret, time.Now())
+	require.NoError(t, err)
+	secret(false)
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE users SET totp_enabled = TRUE, totp_last_step = \\$2").
+		WithArgs(userID, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM recovery_codes WHERE user_id = \\$1").
+		WithArgs(userID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	for i := 0; i < 10; i++ {
+		mock.ExpectExec("INSERT INTO recovery_codes").
+			WithArgs(userID, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectCommit()
+	confirmed, err := s.Confirm(userID, code)
+	require.NoError(t, err)
+	assert.Len(t, confirmed.RecoveryCodes, 10)
+
+	// Once on, the secret can't be replaced or confirmed again.
+	mock.ExpectExec("UPDATE users SET totp_secret").
+		WithArgs(sqlmock.AnyArg(), userID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	_, err = s.Enroll(userID, "user@example.com")
+	assert.ErrorIs(t, err, service.ErrTwoFactorEnabled)
+	secret(true)
+	_, err = s.Confirm(userID, code)
+	assert.ErrorIs(t, err, service.ErrTwoFactorEnabled)
+
+	// A recovery code is accepted however it is typed.
+	recovery := confirmed.RecoveryCodes[0]
+	sum := sha256.Sum256([]byte(strings.ReplaceAll(recovery, "-", "")))
+	secret(true)
+	mock.ExpectExec("UPDATE recovery_codes SET used_at").
+		WithArgs(userID, hex.EncodeToString(sum[:])).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, s.Verify(userID, " "+strings.ToUpper(recovery)))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestConfirmNeedsEnrollment(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	s := service.NewTwoFactorService(db, config.Config{})
+
+	mock.ExpectQuery("SELECT COALESCE\\(totp_secret, ''\\), totp_enabled FROM users").
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"totp_secret", "totp_enabled"}).AddRow("", false))
+	_, err = s.Confirm(userID, "123456")
+	assert.ErrorIs(t, err, service.ErrTwoFactorNotEnrolled)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTOTPCodeWorksOnce(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	s := service.NewTwoFactorService(db, config.Config{})
+
+	key, err := totp.Generate(totp.GenerateOpts{Issuer: "forum", AccountName: "user@example.com"})
+	require.NoError(t, err)
+	code, err := totp.GenerateCode(key.Secret(), time.Now())
+	require.NoError(t, err)
+	secret := func() {
+		mock.ExpectQuery("SELECT COALESCE\\(totp_secret, ''\\), totp_enabled FROM users").
+			WithArgs(userID).
+			WillReturnRows(sqlmock.NewRows([]string{"totp_secret", "totp_enabled"}).AddRow(key.Secret(), true))
+	}
+
+	secret()
+	mock.ExpectExec("UPDATE users SET totp_last_step").
+		WithArgs(sqlmock.AnyArg(), userID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, s.Verify(userID, code))
+
+	// The step is already recorded, so the same code is refused.
+	secret()
+	mock.ExpectExec("UPDATE users SET totp_last_step").
+		WithArgs(sqlmock.AnyArg(), userID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.ErrorIs(t, s.Verify(userID, code), service.ErrInvalidCode)
+
+	// Anything else is tried as a recovery code.
+	secret()
+	mock.ExpectExec("UPDATE recovery_codes SET used_at").
+		WithArgs(userID, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.ErrorIs(t, s.Verify(userID, "0000-0000"), service.ErrInvalidCode)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestChallengeWorksOnce(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	s := service.NewTwoFactorService(db, config.Config{})
+
+	jti, expires := "9a4e1c2b-6d3f-4b8a-8e7c-2f1d0c9b8a76", time.Now().Add(5*time.Minute)
+	for _, inserted := range []int64{1, 0} {
+		mock.ExpectExec("DELETE FROM used_challenges WHERE expires_at < NOW\\(\\)").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("INSERT INTO used_challenges").
+			WithArgs(jti, expires).
+			WillReturnResult(sqlmock.NewResult(0, inserted))
+	}
+
+	assert.NoError(t, s.UseChallenge(jti, expires))
+	assert.ErrorIs(t, s.UseChallenge(jti, expires), service.ErrChallengeUsed)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,31 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"forum-service/config"
+)
+
+var ErrNotFound = errors.New("blob not found")
+
+// Store keeps attachment bytes. Keys are slash separated paths chosen by the
+// caller, e.g. "attachments/<id>".
+type Store interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// New builds the store selected by BLOB_BACKEND.
+func New(cfg config.Config) (Store, error) {
+	switch cfg.BLOB_BACKEND {
+	case "local":
+		return NewLocalStore(cfg.BLOB_LOCAL_DIR)
+	case "s3":
+		return NewS3Store(cfg.S3_ENDPOINT, cfg.S3_ACCESS_KEY, cfg.S3_SECRET_KEY, cfg.S3_BUCKET, cfg.S3_USE_SSL)
+	}
+	return nil, fmt.Errorf("unknown blob backend %q", cfg.BLOB_BACKEND)
+}
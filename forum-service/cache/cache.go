@@ -0,0 +1,36 @@
+// Package cache keeps the results of hot reads for a short while, in process
+// or in Redis, so popular posts and lists are not queried on every request.
+package cache
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"forum-service/config"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Cache stores encoded values under string keys. A ttl of 0 keeps a value
+// until it is deleted or evicted.
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// New builds the cache selected by CACHE_BACKEND, or nil for none.
+func New(cfg config.Config) (Cache, error) {
+	switch cfg.CACHE_BACKEND {
+	case "none":
+		return nil, nil
+	case "memory":
+		return NewLRU(cfg.CACHE_SIZE), nil
+	case "redis":
+		log.Printf("Caching reads in Redis at %s", cfg.CACHE_REDIS_ADDR)
+		return NewRedis(redis.NewClient(&redis.Options{Addr: cfg.CACHE_REDIS_ADDR}), "forum:"), nil
+	}
+	return nil, fmt.Errorf("unknown cache backend %q", cfg.CACHE_BACKEND)
+}
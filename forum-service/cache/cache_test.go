@@ -0,0 +1,129 @@
+package cache_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"forum-service/cache"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var ctx = context.Background()
+
+func TestLRUEviction(t *testing.T) {
+	fmt.Println("Testing LRU eviction...")
+	c := cache.NewLRU(2)
+	assert.NoError(t, c.Set(ctx, "a", []byte("1"), 0))
+	assert.NoError(t, c.Set(ctx, "b", []byte("2"), 0))
+	_, ok, _ := c.Get(ctx, "a")
+	assert.True(t, ok)
+	assert.NoError(t, c.Set(ctx, "c", []byte("3"), 0))
+
+	_, ok, _ = c.Get(ctx, "b")
+	assert.False(t, ok, "b was used least recently")
+	_, ok, _ = c.Get(ctx, "a")
+	assert.True(t, ok)
+
+	assert.NoError(t, c.Set(ctx, "d", []byte("4"), time.Millisecond))
+	time.Sleep(2 * time.Millisecond)
+	_, ok, _ = c.Get(ctx, "d")
+	assert.False(t, ok, "d expired")
+	fmt.Println("OK. Old and expired values are gone.")
+}
+
+func TestLoaderSharesLoads(t *testing.T) {
+	fmt.Println("Testing loader...")
+	l := cache.NewLoader(cache.NewLRU(10), time.Minute, time.Second)
+	// Stats live as long as the process; count this run's alone.
+	name := fmt.Sprintf("test-%d", time.Now().UnixNano())
+	st := cache.StatsFor(name)
+
+	var loads atomic.Int32
+	release := make(chan struct{})
+	load := func(context.Context) (int, error) {
+		loads.Add(1)
+		<-release
+		return 42, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := cache.Load(ctx, l, name, "answer", load)
+			assert.NoError(t, err)
+			assert.Equal(t, 42, v)
+		}()
+	}
+	// Let the callers miss before the load finishes.
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int32(1), loads.Load())
+
+	v, err := cache.Load(ctx, l, name, "answer", load)
+	assert.NoError(t, err)
+	assert.Equal(t, 42, v)
+	assert.Equal(t, int64(11), st.Hits.Load()+st.Misses.Load())
+	assert.Greater(t, st.HitRate(), 0.0)
+
+	l.Forget(ctx, "answer")
+	_, err = cache.Load(ctx, l, name, "answer", load)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), loads.Load())
+	fmt.Println("OK. Concurrent misses share one load.")
+}
+
+func TestLoaderOutlivesCaller(t *testing.T) {
+	fmt.Println("Testing a caller giving up on a shared load...")
+	l := cache.NewLoader(cache.NewLRU(10), time.Minute, time.Second)
+	name := fmt.Sprintf("test-%d", time.Now().UnixNano())
+
+	started, release := make(chan struct{}), make(chan struct{})
+	load := func(ctx context.Context) (int, error) {
+		close(started)
+		select {
+		case <-release:
+			return 42, nil
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+
+	first, cancel := context.WithCancel(ctx)
+	firstErr := make(chan error)
+	go func() {
+		_, err := cache.Load(first, l, name, "answer", load)
+		firstErr <- err
+	}()
+	<-started
+
+	second := make(chan int)
+	go func() {
+		v, err := cache.Load(ctx, l, name, "answer", load)
+		assert.NoError(t, err)
+		second <- v
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	assert.ErrorIs(t, <-firstErr, context.Canceled, "the caller stops waiting")
+	close(release)
+	assert.Equal(t, 42, <-second, "the others still get the value")
+	fmt.Println("OK. The load outlives the caller that started it.")
+}
+
+func TestLoaderGenerations(t *testing.T) {
+	fmt.Println("Testing generations...")
+	l := cache.NewLoader(cache.NewLRU(10), time.Minute, time.Second)
+	before := l.Generation(ctx, "list")
+	assert.Equal(t, before, l.Generation(ctx, "list"))
+	l.Bump(ctx, "list")
+	assert.NotEqual(t, before, l.Generation(ctx, "list"))
+	fmt.Println("OK. Bumping starts a new generation.")
+}
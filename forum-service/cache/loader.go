@@ -0,0 +1,158 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"log"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Loader reads through a Cache. Values are stored as JSON and decoded anew
+// for every caller, so callers may change what they get.
+type Loader struct {
+	cache   Cache
+	ttl     time.Duration
+	timeout time.Duration
+	group   singleflight.Group
+}
+
+// NewLoader keeps loaded values for ttl. A load may take up to timeout, or
+// as long as it needs if timeout is zero.
+func NewLoader(c Cache, ttl, timeout time.Duration) *Loader {
+	return &Loader{cache: c, ttl: ttl, timeout: timeout}
+}
+
+// Load returns the value under key or, on a miss, runs load and caches what
+// it returns. Callers missing the same key together share one load, so an
+// expired hot key costs a single query. The shared load keeps the values of
+// the ctx that started it but not its cancellation, so one caller giving up
+// doesn't fail the others; each caller stops waiting when its own ctx ends.
+// Errors are not cached, and a failing cache only means every call loads.
+// name groups the hit counts.
+func Load[T any](ctx context.Context, l *Loader, name, key string, load func(context.Context) (T, error)) (T, error) {
+	st := StatsFor(name)
+	var v, zero T
+	data, ok, err := l.cache.Get(ctx, key)
+	if err != nil {
+		st.Errors.Add(1)
+	} else if ok && json.Unmarshal(data, &v) == nil {
+		st.Hits.Add(1)
+		return v, nil
+	}
+	st.Misses.Add(1)
+
+	loaded := l.group.DoChan(key, func() (interface{}, error) {
+		ctx := context.WithoutCancel(ctx)
+		if l.timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, l.timeout)
+			defer cancel()
+		}
+		v, err := load(ctx)
+		if err != nil {
+			return nil, err
+		}
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		if err := l.cache.Set(ctx, key, data, l.ttl); err != nil {
+			st.Errors.Add(1)
+		}
+		return data, nil
+	})
+	select {
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	case res := <-loaded:
+		if res.Err != nil {
+			return zero, res.Err
+		}
+		var fresh T
+		if err := json.Unmarshal(res.Val.([]byte), &fresh); err != nil {
+			return zero, err
+		}
+		return fresh, nil
+	}
+}
+
+// Forget drops keys after the values behind them changed.
+func (l *Loader) Forget(ctx context.Context, keys ...string) {
+	if err := l.cache.Delete(ctx, keys...); err != nil {
+		log.Printf("cache: could not forget %v: %v", keys, err)
+	}
+}
+
+// Generation is part of the keys of a family of values, such as every page
+// of a list, that go stale together: Bump starts a new generation, and the
+// old keys are never read again and expire.
+func (l *Loader) Generation(ctx context.Context, family string) string {
+	data, ok, err := l.cache.Get(ctx, family+":gen")
+	if err != nil || !ok {
+		return "0"
+	}
+	return string(data)
+}
+
+func (l *Loader) Bump(ctx context.Context, family string) {
+	gen := strconv.FormatInt(time.Now().UnixNano(), 36)
+	if err := l.cache.Set(ctx, family+":gen", []byte(gen), 0); err != nil {
+		log.Printf("cache: could not start a new generation of %s: %v", family, err)
+	}
+}
+
+// Stats counts the lookups of one name.
+type Stats struct {
+	Hits, Misses, Errors atomic.Int64
+}
+
+// HitRate is the share of lookups served from the cache.
+func (s *Stats) HitRate() float64 {
+	hits, misses := s.Hits.Load(), s.Misses.Load()
+	if hits+misses == 0 {
+		return 0
+	}
+	return float64(hits) / float64(hits+misses)
+}
+
+var (
+	statsMu sync.Mutex
+	stats   = map[string]*Stats{}
+)
+
+// StatsFor returns the counts of name.
+func StatsFor(name string) *Stats {
+	statsMu.Lock()
+	defer statsMu.Unlock()
+	st, ok := stats[name]
+	if !ok {
+		st = &Stats{}
+		stats[name] = st
+	}
+	return st
+}
+
+// The counts are published with expvar under "cache", e.g.
+// {"post": {"hits": 90, "misses": 10, "errors": 0, "hit_rate": 0.9}}.
+func init() {
+	expvar.Publish("cache", expvar.Func(func() interface{} {
+		statsMu.Lock()
+		defer statsMu.Unlock()
+		all := map[string]map[string]interface{}{}
+		for name, st := range stats {
+			all[name] = map[string]interface{}{
+				"hits":     st.Hits.Load(),
+				"misses":   st.Misses.Load(),
+				"errors":   st.Errors.Load(),
+				"hit_rate": st.HitRate(),
+			}
+		}
+		return all
+	}))
+}
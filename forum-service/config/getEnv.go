@@ -3,6 +3,8 @@ package config
 import (
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/spf13/cast"
@@ -12,13 +14,86 @@ type Config struct {
 	AUTH_PORT          string
 	FORUM_SERVICE_PORT string
 
+	// postgres, or memory for tests and local runs; memory keeps nothing
+	// across restarts.
+	STORAGE_BACKEND string
+
 	DB_HOST     string
 	DB_PORT     int
 	DB_USER     string
 	DB_PASSWORD string
 	DB_NAME     string
 
+	// Pool limits, per database; replicas get their own pools of the same
+	// size. Connections are closed after DB_CONN_MAX_LIFETIME, or
+	// DB_CONN_MAX_IDLE_TIME unused, so restarts and failovers are picked up.
+	DB_MAX_OPEN_CONNS     int
+	DB_MAX_IDLE_CONNS     int
+	DB_CONN_MAX_LIFETIME  time.Duration
+	DB_CONN_MAX_IDLE_TIME time.Duration
+
+	// Startup tries DB_CONNECT_ATTEMPTS times, waiting DB_CONNECT_BACKOFF
+	// and then twice as long each time, while the database comes up. Once
+	// running, it is pinged every DB_HEALTH_INTERVAL.
+	DB_CONNECT_ATTEMPTS int
+	DB_CONNECT_BACKOFF  time.Duration
+	DB_HEALTH_INTERVAL  time.Duration
+
+	// Read replicas as host or host:port, sharing the primary's user,
+	// password and database. Lists and single reads go to a replica no more
+	// than DB_REPLICA_MAX_LAG behind; writes, and reads made while handling
+	// one, go to the primary.
+	DB_REPLICA_HOSTS          []string
+	DB_REPLICA_MAX_LAG        time.Duration
+	DB_REPLICA_CHECK_INTERVAL time.Duration
+
+	// Apply pending migrations at startup; otherwise only check the schema
+	// is not newer than the binary.
+	MIGRATE_ON_START bool
+
 	LOG_PATH string
+
+	MODERATION_BANNED_WORDS          []string
+	MODERATION_NEW_ACCOUNT_AGE       time.Duration
+	MODERATION_NEW_ACCOUNT_MAX_LINKS int
+	MODERATION_DUPLICATE_WINDOW      time.Duration
+	MODERATION_RATE_LIMIT            int
+	MODERATION_RATE_WINDOW           time.Duration
+
+	REPORT_HIDE_THRESHOLD int
+
+	BLOB_BACKEND   string
+	BLOB_LOCAL_DIR string
+	S3_ENDPOINT    string
+	S3_ACCESS_KEY  string
+	S3_SECRET_KEY  string
+	S3_BUCKET      string
+	S3_USE_SSL     bool
+
+	ATTACHMENT_MAX_SIZE      int64
+	ATTACHMENT_ALLOWED_TYPES []string
+
+	IMAGE_THUMBNAIL_SIZES []int
+	IMAGE_MAX_PIXELS      int
+	IMAGE_WORKER_INTERVAL time.Duration
+	IMAGE_MAX_ATTEMPTS    int
+	IMAGE_RETRY_BACKOFF   time.Duration
+
+	// memory keeps up to CACHE_SIZE values per replica; redis shares them
+	// between replicas; none turns caching off. Values older than CACHE_TTL
+	// are read again, which bounds how stale a change made elsewhere, e.g.
+	// by another replica, can look.
+	CACHE_BACKEND    string
+	CACHE_SIZE       int
+	CACHE_TTL        time.Duration
+	CACHE_REDIS_ADDR string
+
+	// Serves expvar's /debug/vars, with the cache hit rates; empty turns it
+	// off.
+	METRICS_ADDR string
+
+	// Requests arriving without a gRPC deadline get this one.
+	REQUEST_TIMEOUT time.Duration
 }
 
 func Load() Config {
@@ -31,17 +106,81 @@ func Load() Config {
 	config.AUTH_PORT = cast.ToString(coalesce("AUTH_PORT", ":8088"))
 	config.FORUM_SERVICE_PORT = cast.ToString(coalesce("FORUM_SERVICE_PORT", ":50051"))
 
+	config.STORAGE_BACKEND = cast.ToString(coalesce("STORAGE_BACKEND", "postgres"))
+
 	config.DB_HOST = cast.ToString(coalesce("DB_HOST", "postgres"))
 	config.DB_PORT = cast.ToInt(coalesce("DB_PORT", 5432))
 	config.DB_USER = cast.ToString(coalesce("DB_USER", "n10"))
 	config.DB_PASSWORD = cast.ToString(coalesce("DB_PASSWORD", "12345"))
 	config.DB_NAME = cast.ToString(coalesce("DB_NAME", "n10"))
 
+	config.DB_MAX_OPEN_CONNS = cast.ToInt(coalesce("DB_MAX_OPEN_CONNS", 25))
+	config.DB_MAX_IDLE_CONNS = cast.ToInt(coalesce("DB_MAX_IDLE_CONNS", 10))
+	config.DB_CONN_MAX_LIFETIME = cast.ToDuration(coalesce("DB_CONN_MAX_LIFETIME", "30m"))
+	config.DB_CONN_MAX_IDLE_TIME = cast.ToDuration(coalesce("DB_CONN_MAX_IDLE_TIME", "5m"))
+
+	config.DB_CONNECT_ATTEMPTS = cast.ToInt(coalesce("DB_CONNECT_ATTEMPTS", 10))
+	config.DB_CONNECT_BACKOFF = cast.ToDuration(coalesce("DB_CONNECT_BACKOFF", "1s"))
+	config.DB_HEALTH_INTERVAL = cast.ToDuration(coalesce("DB_HEALTH_INTERVAL", "10s"))
+
+	config.DB_REPLICA_HOSTS = splitList(cast.ToString(coalesce("DB_REPLICA_HOSTS", "")))
+	config.DB_REPLICA_MAX_LAG = cast.ToDuration(coalesce("DB_REPLICA_MAX_LAG", "5s"))
+	config.DB_REPLICA_CHECK_INTERVAL = cast.ToDuration(coalesce("DB_REPLICA_CHECK_INTERVAL", "5s"))
+
+	config.MIGRATE_ON_START = cast.ToBool(coalesce("MIGRATE_ON_START", true))
+
 	config.LOG_PATH = cast.ToString(coalesce("LOG_PATH", "logs/info.log"))
 
+	config.MODERATION_BANNED_WORDS = splitList(cast.ToString(coalesce("MODERATION_BANNED_WORDS", "")))
+	config.MODERATION_NEW_ACCOUNT_AGE = cast.ToDuration(coalesce("MODERATION_NEW_ACCOUNT_AGE", "72h"))
+	config.MODERATION_NEW_ACCOUNT_MAX_LINKS = cast.ToInt(coalesce("MODERATION_NEW_ACCOUNT_MAX_LINKS", 1))
+	config.MODERATION_DUPLICATE_WINDOW = cast.ToDuration(coalesce("MODERATION_DUPLICATE_WINDOW", "24h"))
+	config.MODERATION_RATE_LIMIT = cast.ToInt(coalesce("MODERATION_RATE_LIMIT", 10))
+	config.MODERATION_RATE_WINDOW = cast.ToDuration(coalesce("MODERATION_RATE_WINDOW", "10m"))
+
+	config.REPORT_HIDE_THRESHOLD = cast.ToInt(coalesce("REPORT_HIDE_THRESHOLD", 5))
+
+	config.BLOB_BACKEND = cast.ToString(coalesce("BLOB_BACKEND", "local"))
+	config.BLOB_LOCAL_DIR = cast.ToString(coalesce("BLOB_LOCAL_DIR", "data/blobs"))
+	config.S3_ENDPOINT = cast.ToString(coalesce("S3_ENDPOINT", "minio:9000"))
+	config.S3_ACCESS_KEY = cast.ToString(coalesce("S3_ACCESS_KEY", ""))
+	config.S3_SECRET_KEY = cast.ToString(coalesce("S3_SECRET_KEY", ""))
+	config.S3_BUCKET = cast.ToString(coalesce("S3_BUCKET", "forum-attachments"))
+	config.S3_USE_SSL = cast.ToBool(coalesce("S3_USE_SSL", false))
+
+	config.ATTACHMENT_MAX_SIZE = cast.ToInt64(coalesce("ATTACHMENT_MAX_SIZE", 10<<20))
+	config.ATTACHMENT_ALLOWED_TYPES = splitList(cast.ToString(coalesce("ATTACHMENT_ALLOWED_TYPES",
+		"image/png,image/jpeg,image/gif,image/webp,text/plain,application/pdf,application/zip")))
+
+	config.IMAGE_THUMBNAIL_SIZES = cast.ToIntSlice(splitList(cast.ToString(coalesce("IMAGE_THUMBNAIL_SIZES", "160,480,1024"))))
+	config.IMAGE_MAX_PIXELS = cast.ToInt(coalesce("IMAGE_MAX_PIXELS", 40_000_000))
+	config.IMAGE_WORKER_INTERVAL = cast.ToDuration(coalesce("IMAGE_WORKER_INTERVAL", "5s"))
+	config.IMAGE_MAX_ATTEMPTS = cast.ToInt(coalesce("IMAGE_MAX_ATTEMPTS", 5))
+	config.IMAGE_RETRY_BACKOFF = cast.ToDuration(coalesce("IMAGE_RETRY_BACKOFF", "30s"))
+
+	config.CACHE_BACKEND = cast.ToString(coalesce("CACHE_BACKEND", "memory"))
+	config.CACHE_SIZE = cast.ToInt(coalesce("CACHE_SIZE", 10000))
+	config.CACHE_TTL = cast.ToDuration(coalesce("CACHE_TTL", "30s"))
+	config.CACHE_REDIS_ADDR = cast.ToString(coalesce("CACHE_REDIS_ADDR", "redis:6379"))
+
+	config.METRICS_ADDR = cast.ToString(coalesce("METRICS_ADDR", ":9090"))
+
+	config.REQUEST_TIMEOUT = cast.ToDuration(coalesce("REQUEST_TIMEOUT", "30s"))
+
 	return config
 }
 
+// splitList splits a comma separated value, dropping empty items.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 func coalesce(key string, defaultValue interface{}) interface{} {
 	val, exists := os.LookupEnv(key)
 
@@ -0,0 +1,135 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// ErrTooLarge is returned for images whose pixel count exceeds the limit,
+// before they are decoded.
+var ErrTooLarge = errors.New("image dimensions too large")
+
+// Image is an encoded image without metadata.
+type Image struct {
+	Width    int
+	Height   int
+	MimeType string
+	Data     []byte
+}
+
+// Variant is a thumbnail named after its bounding box, e.g. "thumb_160".
+type Variant struct {
+	Name string
+	Image
+}
+
+// Result is a processed upload: the cleaned original plus its thumbnails.
+type Result struct {
+	Original   Image
+	Thumbnails []Variant
+}
+
+// Supported reports whether Process can handle a sniffed media type.
+func Supported(mimeType string) bool {
+	switch mimeType {
+	case "image/jpeg", "image/png", "image/gif", "image/webp":
+		return true
+	}
+	return false
+}
+
+// Process re-encodes an image, which drops EXIF and every other metadata
+// block, after applying the EXIF orientation so photos stay upright. It then
+// scales a thumbnail into each square bounding box in sizes that is smaller
+// than the image. Images over maxPixels are refused.
+func Process(data []byte, mimeType string, sizes []int, maxPixels int) (*Result, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if maxPixels > 0 && cfg.Width*cfg.Height > maxPixels {
+		return nil, ErrTooLarge
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if mimeType == "image/jpeg" {
+		img = orient(img, orientation(data))
+	}
+
+	res := &Result{}
+	if mimeType == "image/gif" {
+		// GIF carries no EXIF; keep it as is so animations survive.
+		b := img.Bounds()
+		res.Original = Image{Width: b.Dx(), Height: b.Dy(), MimeType: mimeType, Data: data}
+	} else {
+		res.Original, err = encode(img, outputType(mimeType))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, size := range sizes {
+		if size <= 0 || (res.Original.Width <= size && res.Original.Height <= size) {
+			continue
+		}
+		thumb, err := encode(scale(img, size), outputType(mimeType))
+		if err != nil {
+			return nil, err
+		}
+		res.Thumbnails = append(res.Thumbnails, Variant{Name: fmt.Sprintf("thumb_%d", size), Image: thumb})
+	}
+	return res, nil
+}
+
+// outputType keeps JPEG as JPEG and stores everything else as PNG, which
+// preserves transparency. WebP cannot be encoded by the standard library.
+func outputType(mimeType string) string {
+	if mimeType == "image/jpeg" {
+		return "image/jpeg"
+	}
+	return "image/png"
+}
+
+func encode(img image.Image, mimeType string) (Image, error) {
+	var buf bytes.Buffer
+	var err error
+	switch mimeType {
+	case "image/jpeg":
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85})
+	case "image/png":
+		err = png.Encode(&buf, img)
+	case "image/gif":
+		err = gif.Encode(&buf, img, nil)
+	default:
+		err = fmt.Errorf("cannot encode %s", mimeType)
+	}
+	if err != nil {
+		return Image{}, err
+	}
+	b := img.Bounds()
+	return Image{Width: b.Dx(), Height: b.Dy(), MimeType: mimeType, Data: buf.Bytes()}, nil
+}
+
+// scale fits img into a size x size box, keeping its aspect ratio.
+func scale(img image.Image, size int) image.Image {
+	b := img.Bounds()
+	w, h := size, size
+	if b.Dx() > b.Dy() {
+		h = max(1, b.Dy()*size/b.Dx())
+	} else {
+		w = max(1, b.Dx()*size/b.Dy())
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Src, nil)
+	return dst
+}
@@ -0,0 +1,80 @@
+package imaging_test
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"os"
+	"testing"
+
+	"forum-service/imaging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// exif_gps_orientation6.jpg is stored 40x20, red on the left and blue on the
+// right, with EXIF orientation 6 (rotate 90 clockwise to display) and a GPS
+// block. truncated.jpg is the same file cut off halfway through the scan.
+func fixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile("testdata/" + name)
+	require.NoError(t, err)
+	return data
+}
+
+func TestProcessStripsMetadataAndRotates(t *testing.T) {
+	data := fixture(t, "exif_gps_orientation6.jpg")
+	require.True(t, bytes.Contains(data, []byte("Exif")))
+
+	res, err := imaging.Process(data, "image/jpeg", nil, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "image/jpeg", res.Original.MimeType)
+	assert.Equal(t, 20, res.Original.Width, "rotated upright")
+	assert.Equal(t, 40, res.Original.Height)
+	assert.False(t, bytes.Contains(res.Original.Data, []byte("Exif")), "EXIF, and the GPS block in it, is gone")
+	assert.False(t, bytes.Contains(res.Original.Data, []byte{0xFF, 0xE1}), "no APP1 segment is left")
+
+	img, err := jpeg.Decode(bytes.NewReader(res.Original.Data))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 20, 40), img.Bounds())
+	r, _, b, _ := img.At(10, 5).RGBA()
+	assert.Greater(t, r, b, "the stored left edge is now on top")
+	r, _, b, _ = img.At(10, 35).RGBA()
+	assert.Greater(t, b, r)
+}
+
+func TestProcessThumbnails(t *testing.T) {
+	res, err := imaging.Process(fixture(t, "exif_gps_orientation6.jpg"), "image/jpeg", []int{16, 32, 64}, 0)
+	require.NoError(t, err)
+
+	// 64 is larger than the image, so only two thumbnails are made.
+	require.Len(t, res.Thumbnails, 2)
+	for i, want := range []struct {
+		name          string
+		width, height int
+	}{
+		{"thumb_16", 8, 16},
+		{"thumb_32", 16, 32},
+	} {
+		thumb := res.Thumbnails[i]
+		assert.Equal(t, want.name, thumb.Name)
+		assert.Equal(t, want.width, thumb.Width)
+		assert.Equal(t, want.height, thumb.Height)
+		cfg, err := jpeg.DecodeConfig(bytes.NewReader(thumb.Data))
+		require.NoError(t, err)
+		assert.Equal(t, want.width, cfg.Width, "the encoded size matches")
+		assert.Equal(t, want.height, cfg.Height)
+	}
+}
+
+func TestProcessRefusesBadImages(t *testing.T) {
+	_, err := imaging.Process(fixture(t, "truncated.jpg"), "image/jpeg", nil, 0)
+	assert.Error(t, err)
+
+	_, err = imaging.Process([]byte("not an image"), "image/jpeg", nil, 0)
+	assert.Error(t, err)
+
+	_, err = imaging.Process(fixture(t, "exif_gps_orientation6.jpg"), "image/jpeg", nil, 799)
+	assert.ErrorIs(t, err, imaging.ErrTooLarge)
+}
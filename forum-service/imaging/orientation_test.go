@@ -0,0 +1,78 @@
+package imaging
+
+import (
+	"encoding/binary"
+	"image"
+	"image/color"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// exifJPEG is the start of a JPEG whose APP1 segment holds one IFD0 entry.
+func exifJPEG(order binary.ByteOrder, tag uint16, value uint16) []byte {
+	tiff := make([]byte, 8+2+12+4)
+	if order == binary.LittleEndian {
+		copy(tiff, "II")
+	} else {
+		copy(tiff, "MM")
+	}
+	order.PutUint16(tiff[2:], 42)
+	order.PutUint32(tiff[4:], 8)
+	order.PutUint16(tiff[8:], 1)
+	order.PutUint16(tiff[10:], tag)
+	order.PutUint16(tiff[12:], 3) // SHORT
+	order.PutUint32(tiff[14:], 1)
+	order.PutUint16(tiff[18:], value)
+	seg := append([]byte("Exif\x00\x00"), tiff...)
+	data := []byte{0xFF, 0xD8, 0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(data[4:], uint16(len(seg)+2))
+	return append(append(data, seg...), 0xFF, 0xDA)
+}
+
+func TestOrientation(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/exif_gps_orientation6.jpg")
+	require.NoError(t, err)
+	assert.Equal(t, 6, orientation(fixture))
+
+	assert.Equal(t, 8, orientation(exifJPEG(binary.LittleEndian, 0x0112, 8)), "Intel byte order")
+	assert.Equal(t, 3, orientation(exifJPEG(binary.BigEndian, 0x0112, 3)), "Motorola byte order")
+	assert.Equal(t, 1, orientation(exifJPEG(binary.BigEndian, 0x0112, 9)), "out of range")
+	assert.Equal(t, 1, orientation(exifJPEG(binary.BigEndian, 0x010F, 6)), "no orientation tag")
+
+	truncated := exifJPEG(binary.BigEndian, 0x0112, 6)
+	assert.Equal(t, 1, orientation(truncated[:20]), "segment runs past the end")
+	assert.Equal(t, 1, orientation([]byte("GIF89a")), "not a JPEG")
+	assert.Equal(t, 1, orientation(nil))
+}
+
+func TestOrient(t *testing.T) {
+	// A 2x1 image, red then blue.
+	src := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	red, blue := color.RGBA{255, 0, 0, 255}, color.RGBA{0, 0, 255, 255}
+	src.Set(0, 0, red)
+	src.Set(1, 0, blue)
+
+	for o, want := range map[int][]color.RGBA{
+		1: {red, blue}, // as stored, 2x1
+		2: {blue, red}, // mirrored, 2x1
+		3: {blue, red}, // rotated 180, 2x1
+		6: {red, blue}, // rotated 90 clockwise, 1x2 top to bottom
+		8: {blue, red}, // rotated 90 counter-clockwise, 1x2 top to bottom
+	} {
+		img := orient(src, o)
+		var got []color.RGBA
+		b := img.Bounds()
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				got = append(got, color.RGBAModel.Convert(img.At(x, y)).(color.RGBA))
+			}
+		}
+		assert.Equal(t, want, got, "orientation %d", o)
+		if o >= 5 {
+			assert.Equal(t, image.Rect(0, 0, 1, 2), b, "orientation %d swaps width and height", o)
+		}
+	}
+}
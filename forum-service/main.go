@@ -1,35 +1,122 @@
 package main
 
 import (
+	"context"
+	_ "expvar"
 	"log"
 	"net"
+	"net/http"
+	"os"
+	"time"
 
+	"forum-service/blob"
+	"forum-service/cache"
 	cf "forum-service/config"
+	"forum-service/migrations"
+	"forum-service/moderation"
 	"forum-service/storage"
 
 	pb "forum-service/forum-protos/genprotos"
 	service "forum-service/service"
+	"forum-service/worker"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
 func main() {
 	config := cf.Load()
 	em := cf.NewErrorManager()
-	db, err := storage.NewPostgresStorage(config)
+
+	// forum healthcheck exits non-zero unless the running server is serving.
+	if len(os.Args) > 1 && os.Args[1] == "healthcheck" {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		em.CheckErr(service.Probe(ctx, config.FORUM_SERVICE_PORT))
+		return
+	}
+
+	// forum migrate up | down [N] | status | force VERSION
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		db, err := storage.NewPostgresStorage(config)
+		em.CheckErr(err)
+		defer db.Close()
+		m, err := migrations.New(db.Db)
+		em.CheckErr(err)
+		em.CheckErr(m.Command(context.Background(), os.Args[2:], os.Stdout))
+		return
+	}
+
+	db, err := storage.New(config)
+	em.CheckErr(err)
+	defer db.Close()
+
+	if db.Db != nil {
+		m, err := migrations.New(db.Db)
+		em.CheckErr(err)
+		if config.MIGRATE_ON_START {
+			err = m.Up(context.Background())
+		} else {
+			err = m.Check(context.Background())
+		}
+		em.CheckErr(err)
+	}
+
+	// forum repair-counters recomputes the cached comment and tag counts.
+	if len(os.Args) > 1 && os.Args[1] == "repair-counters" {
+		repair, err := db.CounterS.Repair(context.Background())
+		em.CheckErr(err)
+		log.Printf("Repaired the counters of %d posts and %d tags", repair.Posts, repair.Tags)
+		return
+	}
+
+	reads, err := cache.New(config)
 	em.CheckErr(err)
-	defer db.Db.Close()
+	if reads != nil {
+		db.WithCache(reads, config.CACHE_TTL, config.REQUEST_TIMEOUT)
+	}
+	if config.METRICS_ADDR != "" {
+		go func() {
+			log.Printf("metrics listening at %s", config.METRICS_ADDR)
+			if err := http.ListenAndServe(config.METRICS_ADDR, nil); err != nil {
+				log.Printf("metrics server stopped: %v", err)
+			}
+		}()
+	}
 
 	listener, err := net.Listen("tcp", config.FORUM_SERVICE_PORT)
 	if err != nil {
 		log.Fatalf("failed to listen: %v", err)
 	}
 
-	s := grpc.NewServer()
-	pb.RegisterPostServiceServer(s, service.NewPostService(db))
+	pipeline := moderation.New(config, db.ModerationS)
+
+	blobs, err := blob.New(config)
+	em.CheckErr(err)
+
+	go worker.NewImageWorker(db.AttachmentS, blobs, config).Run(context.Background())
+	if db.Health != nil {
+		go db.Health.Run(context.Background(), config.DB_HEALTH_INTERVAL)
+	}
+	if db.Replicas != nil {
+		go db.Replicas.Run(context.Background(), config.DB_REPLICA_CHECK_INTERVAL)
+	}
+
+	// Uploads arrive as a single message; leave room for the other fields.
+	s := grpc.NewServer(grpc.MaxRecvMsgSize(int(config.ATTACHMENT_MAX_SIZE)+1<<20),
+		grpc.ChainUnaryInterceptor(service.Deadline(config.REQUEST_TIMEOUT), service.ReadRouting()))
+	pb.RegisterPostServiceServer(s, service.NewPostService(db, pipeline))
 	pb.RegisterCategoryServiceServer(s, service.NewCategoryService(db))
-	pb.RegisterCommentServiceServer(s, service.NewCommentService(db))
+	pb.RegisterCommentServiceServer(s, service.NewCommentService(db, pipeline))
 	pb.RegisterTagServiceServer(s, service.NewTagService(db))
+	pb.RegisterModerationServiceServer(s, service.NewModerationService(db))
+	pb.RegisterReportServiceServer(s, service.NewReportService(db, config.REPORT_HIDE_THRESHOLD))
+	pb.RegisterAttachmentServiceServer(s, service.NewAttachmentService(db, blobs, config.ATTACHMENT_MAX_SIZE, config.ATTACHMENT_ALLOWED_TYPES))
+
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(s, healthServer)
+	go service.ReportHealth(context.Background(), healthServer, db.Ready, time.Second)
 
 	log.Printf("server listening at %v", listener.Addr())
 	if err := s.Serve(listener); err != nil {
@@ -0,0 +1,14 @@
+-- Down migration for moderation
+DROP TABLE IF EXISTS moderation_flags;
+
+ALTER TABLE comments
+    DROP COLUMN IF EXISTS moderation_reason,
+    DROP COLUMN IF EXISTS moderated_at,
+    DROP COLUMN IF EXISTS moderated_by,
+    DROP COLUMN IF EXISTS status;
+
+ALTER TABLE posts
+    DROP COLUMN IF EXISTS moderation_reason,
+    DROP COLUMN IF EXISTS moderated_at,
+    DROP COLUMN IF EXISTS moderated_by,
+    DROP COLUMN IF EXISTS status;
\ No newline at end of file
@@ -0,0 +1,24 @@
+-- Up migration for moderation
+ALTER TABLE posts
+    ADD COLUMN status VARCHAR(16) NOT NULL DEFAULT 'published',
+    ADD COLUMN moderated_by UUID,
+    ADD COLUMN moderated_at TIMESTAMP,
+    ADD COLUMN moderation_reason TEXT;
+
+ALTER TABLE comments
+    ADD COLUMN status VARCHAR(16) NOT NULL DEFAULT 'published',
+    ADD COLUMN moderated_by UUID,
+    ADD COLUMN moderated_at TIMESTAMP,
+    ADD COLUMN moderation_reason TEXT;
+
+-- Why the pipeline held a post or comment for review
+CREATE TABLE moderation_flags (
+    flag_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    content_type VARCHAR(16) NOT NULL,
+    content_id UUID NOT NULL,
+    check_name VARCHAR(64) NOT NULL,
+    reason TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX moderation_flags_content_idx ON moderation_flags (content_type, content_id);
\ No newline at end of file
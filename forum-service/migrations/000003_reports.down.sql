@@ -0,0 +1,5 @@
+-- Down migration for moderation log
+DROP TABLE IF EXISTS moderation_log;
+
+-- Down migration for reports
+DROP TABLE IF EXISTS reports;
\ No newline at end of file
@@ -0,0 +1,31 @@
+-- Up migration for reports
+CREATE TABLE reports (
+    report_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    content_type VARCHAR(16) NOT NULL,
+    content_id UUID NOT NULL,
+    reporter_id UUID NOT NULL,
+    reason TEXT NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'open',
+    resolution VARCHAR(16),
+    resolved_by UUID,
+    resolved_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (content_type, content_id, reporter_id)
+);
+
+CREATE INDEX reports_open_idx ON reports (content_type, content_id) WHERE status = 'open';
+
+-- Up migration for moderation log
+CREATE TABLE moderation_log (
+    log_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    moderator_id UUID,
+    action VARCHAR(32) NOT NULL,
+    content_type VARCHAR(16) NOT NULL,
+    content_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    report_id UUID REFERENCES reports(report_id),
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX moderation_log_user_idx ON moderation_log (user_id);
\ No newline at end of file
@@ -0,0 +1,2 @@
+-- Down migration for mutes
+DROP TABLE IF EXISTS mutes;
\ No newline at end of file
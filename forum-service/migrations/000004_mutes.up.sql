@@ -0,0 +1,8 @@
+-- Up migration for mutes
+CREATE TABLE mutes (
+    user_id UUID PRIMARY KEY,
+    reason TEXT NOT NULL,
+    muted_by UUID,
+    expires_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
\ No newline at end of file
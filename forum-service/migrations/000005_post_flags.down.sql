@@ -0,0 +1,7 @@
+-- Down migration for post flags
+DROP INDEX IF EXISTS posts_featured_idx;
+
+ALTER TABLE posts
+    DROP COLUMN IF EXISTS featured,
+    DROP COLUMN IF EXISTS pinned,
+    DROP COLUMN IF EXISTS locked;
\ No newline at end of file
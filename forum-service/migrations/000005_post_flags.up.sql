@@ -0,0 +1,7 @@
+-- Up migration for post flags
+ALTER TABLE posts
+    ADD COLUMN locked BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN pinned BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN featured BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX posts_featured_idx ON posts (created_at) WHERE featured;
\ No newline at end of file
@@ -0,0 +1,5 @@
+-- Down migration for rendering
+DROP TABLE IF EXISTS rendered_bodies;
+
+ALTER TABLE comments DROP COLUMN IF EXISTS revision;
+ALTER TABLE posts DROP COLUMN IF EXISTS revision;
\ No newline at end of file
@@ -0,0 +1,14 @@
+-- Up migration for rendering
+ALTER TABLE posts ADD COLUMN revision BIGINT NOT NULL DEFAULT 1;
+ALTER TABLE comments ADD COLUMN revision BIGINT NOT NULL DEFAULT 1;
+
+-- One cached rendering per post or comment, replaced when the body changes.
+CREATE TABLE rendered_bodies (
+    content_type VARCHAR(16) NOT NULL,
+    content_id UUID NOT NULL,
+    revision BIGINT NOT NULL,
+    renderer INT NOT NULL,
+    html TEXT NOT NULL,
+    rendered_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (content_type, content_id)
+);
\ No newline at end of file
@@ -0,0 +1,2 @@
+-- Down migration for attachments
+DROP TABLE IF EXISTS attachments;
\ No newline at end of file
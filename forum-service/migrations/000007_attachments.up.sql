@@ -0,0 +1,15 @@
+-- Up migration for attachments
+CREATE TABLE attachments (
+    attachment_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    content_type VARCHAR(16) NOT NULL,
+    content_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    filename VARCHAR(255) NOT NULL,
+    mime_type VARCHAR(127) NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    blob_key TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at BIGINT DEFAULT 0
+);
+
+CREATE INDEX attachments_content_idx ON attachments (content_type, content_id) WHERE deleted_at = 0;
\ No newline at end of file
@@ -0,0 +1,12 @@
+-- Down migration for attachment variants
+DROP TABLE IF EXISTS attachment_variants;
+
+DROP INDEX IF EXISTS attachments_processing_idx;
+
+ALTER TABLE attachments
+    DROP COLUMN IF EXISTS height,
+    DROP COLUMN IF EXISTS width,
+    DROP COLUMN IF EXISTS last_error,
+    DROP COLUMN IF EXISTS next_attempt_at,
+    DROP COLUMN IF EXISTS attempts,
+    DROP COLUMN IF EXISTS processing_status;
\ No newline at end of file
@@ -0,0 +1,22 @@
+-- Up migration for attachment variants
+ALTER TABLE attachments
+    ADD COLUMN processing_status VARCHAR(16) NOT NULL DEFAULT 'skipped',
+    ADD COLUMN attempts INT NOT NULL DEFAULT 0,
+    ADD COLUMN next_attempt_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    ADD COLUMN last_error TEXT NOT NULL DEFAULT '',
+    ADD COLUMN width INT NOT NULL DEFAULT 0,
+    ADD COLUMN height INT NOT NULL DEFAULT 0;
+
+CREATE INDEX attachments_processing_idx ON attachments (next_attempt_at)
+    WHERE processing_status IN ('pending', 'processing') AND deleted_at = 0;
+
+CREATE TABLE attachment_variants (
+    attachment_id UUID NOT NULL REFERENCES attachments(attachment_id),
+    variant VARCHAR(32) NOT NULL,
+    width INT NOT NULL,
+    height INT NOT NULL,
+    mime_type VARCHAR(127) NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    blob_key TEXT NOT NULL,
+    PRIMARY KEY (attachment_id, variant)
+);
\ No newline at end of file
@@ -0,0 +1,5 @@
+-- Down migration for polls
+DROP TABLE IF EXISTS poll_votes;
+DROP TABLE IF EXISTS poll_ballots;
+DROP TABLE IF EXISTS poll_options;
+DROP TABLE IF EXISTS polls;
\ No newline at end of file
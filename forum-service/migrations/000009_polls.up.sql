@@ -0,0 +1,37 @@
+-- Up migration for polls
+CREATE TABLE polls (
+    poll_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    post_id UUID NOT NULL UNIQUE REFERENCES posts(post_id),
+    question TEXT NOT NULL,
+    multiple BOOLEAN NOT NULL DEFAULT FALSE,
+    hide_results BOOLEAN NOT NULL DEFAULT FALSE,
+    voter_count BIGINT NOT NULL DEFAULT 0,
+    closes_at TIMESTAMP,
+    closed_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE poll_options (
+    option_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    poll_id UUID NOT NULL REFERENCES polls(poll_id),
+    position INT NOT NULL,
+    text VARCHAR(255) NOT NULL,
+    vote_count BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE INDEX poll_options_poll_idx ON poll_options (poll_id, position);
+
+-- One ballot per user; poll_votes holds the options it chose.
+CREATE TABLE poll_ballots (
+    poll_id UUID NOT NULL REFERENCES polls(poll_id),
+    user_id UUID NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (poll_id, user_id)
+);
+
+CREATE TABLE poll_votes (
+    poll_id UUID NOT NULL REFERENCES polls(poll_id),
+    option_id UUID NOT NULL REFERENCES poll_options(option_id),
+    user_id UUID NOT NULL,
+    PRIMARY KEY (poll_id, option_id, user_id)
+);
\ No newline at end of file
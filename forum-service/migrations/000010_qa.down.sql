@@ -0,0 +1,6 @@
+-- Down migration for Q&A categories
+DROP INDEX IF EXISTS posts_unanswered_idx;
+
+ALTER TABLE posts DROP COLUMN IF EXISTS accepted_comment_id;
+
+ALTER TABLE categories DROP COLUMN IF EXISTS qa;
\ No newline at end of file
@@ -0,0 +1,6 @@
+-- Up migration for Q&A categories
+ALTER TABLE categories ADD COLUMN qa BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE posts ADD COLUMN accepted_comment_id UUID REFERENCES comments(comment_id);
+
+CREATE INDEX posts_unanswered_idx ON posts (category_id, created_at) WHERE accepted_comment_id IS NULL;
\ No newline at end of file
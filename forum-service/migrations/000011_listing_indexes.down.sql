@@ -0,0 +1,10 @@
+-- Down migration for listing indexes
+DROP INDEX IF EXISTS tags_tag_idx;
+DROP INDEX IF EXISTS tags_post_idx;
+DROP INDEX IF EXISTS comments_user_created_idx;
+DROP INDEX IF EXISTS comments_post_listing_idx;
+DROP INDEX IF EXISTS posts_accepted_comment_idx;
+DROP INDEX IF EXISTS posts_tags_trgm_idx;
+DROP INDEX IF EXISTS posts_user_created_idx;
+DROP INDEX IF EXISTS posts_category_listing_idx;
+DROP INDEX IF EXISTS posts_listing_idx;
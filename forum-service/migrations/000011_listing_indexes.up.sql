@@ -0,0 +1,25 @@
+-- Up migration for listing indexes
+-- Post listings filter on status and one of category or author, skip deleted
+-- rows and page newest first.
+CREATE INDEX posts_listing_idx ON posts (status, created_at DESC) WHERE deleted_at = 0;
+CREATE INDEX posts_category_listing_idx ON posts (category_id, status, pinned DESC, created_at DESC) WHERE deleted_at = 0;
+
+-- Covers author listings as well as the moderation checks on recent posts,
+-- which also count deleted ones.
+CREATE INDEX posts_user_created_idx ON posts (user_id, created_at);
+
+-- Tag filters match substrings of posts.tags case-insensitively.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE INDEX posts_tags_trgm_idx ON posts USING gin (tags gin_trgm_ops) WHERE deleted_at = 0;
+
+-- Deleting a comment clears it as an accepted answer.
+CREATE INDEX posts_accepted_comment_idx ON posts (accepted_comment_id) WHERE accepted_comment_id IS NOT NULL;
+
+-- Threads list the live comments of one post, oldest first.
+CREATE INDEX comments_post_listing_idx ON comments (post_id, created_at) WHERE deleted_at = 0;
+CREATE INDEX comments_user_created_idx ON comments (user_id, created_at);
+
+-- Post updates replace a post's tags; popular tags group by tag, which an
+-- index-only scan over tags_tag_idx serves without sorting.
+CREATE INDEX tags_post_idx ON tags (post_id);
+CREATE INDEX tags_tag_idx ON tags (tag);
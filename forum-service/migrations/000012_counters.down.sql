@@ -0,0 +1,6 @@
+-- Down migration for counter caches
+DROP TABLE IF EXISTS tag_counts;
+
+ALTER TABLE posts
+    DROP COLUMN IF EXISTS last_activity_at,
+    DROP COLUMN IF EXISTS comment_count;
@@ -0,0 +1,20 @@
+-- Up migration for counter caches
+-- A post's comment_count counts its live, published comments, and
+-- last_activity_at is the newest of its creation and those comments.
+ALTER TABLE posts
+    ADD COLUMN comment_count INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN last_activity_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;
+
+UPDATE posts p SET
+    comment_count = (SELECT COUNT(*) FROM comments c WHERE c.post_id = p.post_id AND c.deleted_at = 0 AND c.status = 'published'),
+    last_activity_at = COALESCE(GREATEST(p.created_at, (SELECT MAX(c.created_at) FROM comments c WHERE c.post_id = p.post_id AND c.deleted_at = 0 AND c.status = 'published')), CURRENT_TIMESTAMP);
+
+-- usage counts the rows of tags per tag, like GetPopular used to.
+CREATE TABLE tag_counts (
+    tag VARCHAR(255) PRIMARY KEY,
+    usage BIGINT NOT NULL DEFAULT 0
+);
+
+INSERT INTO tag_counts (tag, usage) SELECT tag, COUNT(*) FROM tags GROUP BY tag;
+
+CREATE INDEX tag_counts_popular_idx ON tag_counts (usage DESC, tag) WHERE usage > 0;
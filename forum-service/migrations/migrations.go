@@ -0,0 +1,318 @@
+// Package migrations holds the forum schema and applies it. Files follow
+// golang-migrate's naming, NNNNNN_name.up.sql and NNNNNN_name.down.sql, and
+// the version is kept in its schema_migrations table, so a database the
+// migrate CLI has migrated carries on where it was.
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+// lockKey is the advisory lock that lets one migrator at a time touch the
+// schema, so replicas starting together don't race.
+const lockKey int64 = 0x666f72756d // "forum"
+
+var (
+	ErrDirty       = errors.New("a migration failed halfway; repair the schema and run migrate force")
+	ErrSchemaAhead = errors.New("the schema is newer than this binary")
+)
+
+const usage = "usage: migrate up | down [N] | status | force VERSION"
+
+type Migration struct {
+	Version  int64
+	Name     string
+	Up, Down string
+}
+
+var fileName = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// Load reads the embedded migrations in version order.
+func Load() ([]Migration, error) {
+	entries, err := files.ReadDir(".")
+	if err != nil {
+		return nil, err
+	}
+	byVersion := map[int64]*Migration{}
+	for _, e := range entries {
+		match := fileName.FindStringSubmatch(e.Name())
+		if match == nil {
+			return nil, fmt.Errorf("migration %s: name must be NNNNNN_name.up.sql or .down.sql", e.Name())
+		}
+		version, _ := strconv.ParseInt(match[1], 10, 64)
+		body, err := files.ReadFile(e.Name())
+		if err != nil {
+			return nil, err
+		}
+		mg, ok := byVersion[version]
+		if !ok {
+			mg = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = mg
+		}
+		if match[3] == "up" {
+			mg.Up = string(body)
+		} else {
+			mg.Down = string(body)
+		}
+	}
+
+	var migrations []Migration
+	for _, mg := range byVersion {
+		if mg.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", mg.Version, mg.Name)
+		}
+		migrations = append(migrations, *mg)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+type Migrator struct {
+	DB         *sql.DB
+	Migrations []Migration
+	Logf       func(format string, args ...interface{})
+}
+
+func New(db *sql.DB) (*Migrator, error) {
+	migrations, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{DB: db, Migrations: migrations, Logf: log.Printf}, nil
+}
+
+// Latest is the version this binary brings the schema to.
+func (m *Migrator) Latest() int64 {
+	if len(m.Migrations) == 0 {
+		return 0
+	}
+	return m.Migrations[len(m.Migrations)-1].Version
+}
+
+// Up applies every pending migration.
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		version, dirty, err := current(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := m.check(version, dirty); err != nil {
+			return err
+		}
+		for _, mg := range m.Migrations {
+			if mg.Version <= version {
+				continue
+			}
+			m.Logf("Applying migration %d_%s", mg.Version, mg.Name)
+			if err := apply(ctx, conn, mg.Version, mg.Up); err != nil {
+				return fmt.Errorf("migration %d_%s: %w", mg.Version, mg.Name, err)
+			}
+		}
+		return nil
+	})
+}
+
+// Check fails if the schema is dirty or newer than this binary, without
+// applying anything.
+func (m *Migrator) Check(ctx context.Context) error {
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		version, dirty, err := current(ctx, conn)
+		if err != nil {
+			return err
+		}
+		return m.check(version, dirty)
+	})
+}
+
+// Down reverts the last steps migrations.
+func (m *Migrator) Down(ctx context.Context, steps int) error {
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		version, dirty, err := current(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := m.check(version, dirty); err != nil {
+			return err
+		}
+		for ; steps > 0 && version > 0; steps-- {
+			i := m.index(version)
+			if i < 0 {
+				return fmt.Errorf("no migration for version %d", version)
+			}
+			mg := m.Migrations[i]
+			if mg.Down == "" {
+				return fmt.Errorf("migration %d_%s has no down file", mg.Version, mg.Name)
+			}
+			var previous int64
+			if i > 0 {
+				previous = m.Migrations[i-1].Version
+			}
+			m.Logf("Reverting migration %d_%s", mg.Version, mg.Name)
+			if err := apply(ctx, conn, previous, mg.Down); err != nil {
+				return fmt.Errorf("migration %d_%s: %w", mg.Version, mg.Name, err)
+			}
+			version = previous
+		}
+		return nil
+	})
+}
+
+// Force records version as applied and clean without running anything, to
+// recover after repairing a failed migration by hand. 0 means none applied.
+func (m *Migrator) Force(ctx context.Context, version int64) error {
+	if version != 0 && m.index(version) < 0 {
+		return fmt.Errorf("no migration for version %d", version)
+	}
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		return setVersion(ctx, conn, version, false)
+	})
+}
+
+// Status writes the current version and whether each migration is applied.
+func (m *Migrator) Status(ctx context.Context, w io.Writer) error {
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		version, dirty, err := current(ctx, conn)
+		if err != nil {
+			return err
+		}
+		state := "clean"
+		if dirty {
+			state = "dirty"
+		}
+		fmt.Fprintf(w, "version %d (%s), latest %d\n", version, state, m.Latest())
+		for _, mg := range m.Migrations {
+			applied := "pending"
+			if mg.Version <= version {
+				applied = "applied"
+			}
+			fmt.Fprintf(w, "%06d_%s\t%s\n", mg.Version, mg.Name, applied)
+		}
+		return nil
+	})
+}
+
+// Command runs the migrate subcommand with its arguments.
+func (m *Migrator) Command(ctx context.Context, args []string, w io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+	switch args[0] {
+	case "up":
+		return m.Up(ctx)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return errors.New(usage)
+			}
+			steps = n
+		}
+		return m.Down(ctx, steps)
+	case "status":
+		return m.Status(ctx, w)
+	case "force":
+		if len(args) < 2 {
+			return errors.New(usage)
+		}
+		version, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return errors.New(usage)
+		}
+		return m.Force(ctx, version)
+	}
+	return errors.New(usage)
+}
+
+func (m *Migrator) check(version int64, dirty bool) error {
+	if version > m.Latest() {
+		return fmt.Errorf("%w: schema is at version %d, this binary knows up to %d", ErrSchemaAhead, version, m.Latest())
+	}
+	if dirty {
+		return fmt.Errorf("%w (version %d)", ErrDirty, version)
+	}
+	return nil
+}
+
+func (m *Migrator) index(version int64) int {
+	for i, mg := range m.Migrations {
+		if mg.Version == version {
+			return i
+		}
+	}
+	return -1
+}
+
+// withLock runs fn on one connection holding the advisory lock; the lock
+// belongs to the session, so every statement must use that connection.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.DB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockKey); err != nil {
+		return err
+	}
+	defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", lockKey)
+
+	query := "CREATE TABLE IF NOT EXISTS schema_migrations (version BIGINT NOT NULL PRIMARY KEY, dirty BOOLEAN NOT NULL)"
+	if _, err := conn.ExecContext(ctx, query); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+func current(ctx context.Context, conn *sql.Conn) (int64, bool, error) {
+	var version int64
+	var dirty bool
+	err := conn.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	return version, dirty, err
+}
+
+// apply runs a migration file the way golang-migrate does: the target version
+// is recorded as dirty first and only marked clean once the file succeeded.
+// The file is sent as one query, so Postgres runs its statements in a single
+// implicit transaction.
+func apply(ctx context.Context, conn *sql.Conn, target int64, body string) error {
+	if err := setVersion(ctx, conn, target, true); err != nil {
+		return err
+	}
+	if _, err := conn.ExecContext(ctx, body); err != nil {
+		return err
+	}
+	return setVersion(ctx, conn, target, false)
+}
+
+func setVersion(ctx context.Context, conn *sql.Conn, version int64, dirty bool) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, "DELETE FROM schema_migrations"); err != nil {
+		return err
+	}
+	if version > 0 || dirty {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO schema_migrations (version, dirty) VALUES ($1, $2)", version, dirty); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
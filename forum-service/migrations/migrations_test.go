@@ -0,0 +1,148 @@
+package migrations_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"forum-service/migrations"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var ctx = context.Background()
+
+// newMigrator returns a migrator for three small migrations, the last of
+// them without a down file unless down3 is set.
+func newMigrator(t *testing.T, down3 string) (*migrations.Migrator, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return &migrations.Migrator{
+		DB: db,
+		Migrations: []migrations.Migration{
+			{Version: 1, Name: "one", Up: "CREATE TABLE one ()", Down: "DROP TABLE one"},
+			{Version: 2, Name: "two", Up: "CREATE TABLE two ()", Down: "DROP TABLE two"},
+			{Version: 3, Name: "three", Up: "CREATE TABLE three ()", Down: down3},
+		},
+		Logf: t.Logf,
+	}, mock
+}
+
+// expectLocked expects the advisory lock and the version read; unlock has
+// to be expected after whatever fn does.
+func expectLocked(mock sqlmock.Sqlmock, version int64, dirty bool) {
+	expectLock(mock)
+	mock.ExpectQuery("SELECT version, dirty FROM schema_migrations").
+		WillReturnRows(sqlmock.NewRows([]string{"version", "dirty"}).AddRow(version, dirty))
+}
+
+func expectLock(mock sqlmock.Sqlmock) {
+	mock.ExpectExec("SELECT pg_advisory_lock").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+func expectUnlock(mock sqlmock.Sqlmock) {
+	mock.ExpectExec("SELECT pg_advisory_unlock").WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+func expectVersion(mock sqlmock.Sqlmock, version int64, dirty bool) {
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM schema_migrations").WillReturnResult(sqlmock.NewResult(0, 1))
+	if version > 0 || dirty {
+		mock.ExpectExec("INSERT INTO schema_migrations").
+			WithArgs(version, dirty).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectCommit()
+}
+
+func TestLoad(t *testing.T) {
+	all, err := migrations.Load()
+	require.NoError(t, err)
+	require.NotEmpty(t, all)
+	for i, mg := range all {
+		assert.NotEmpty(t, mg.Down, "%d_%s has a down file", mg.Version, mg.Name)
+		if i > 0 {
+			assert.Greater(t, mg.Version, all[i-1].Version)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	m, mock := newMigrator(t, "")
+	for _, tc := range []struct {
+		version int64
+		dirty   bool
+		want    error
+	}{
+		{3, false, nil},
+		{2, true, migrations.ErrDirty},
+		{4, false, migrations.ErrSchemaAhead},
+	} {
+		expectLocked(mock, tc.version, tc.dirty)
+		expectUnlock(mock)
+		err := m.Check(ctx)
+		if tc.want == nil {
+			assert.NoError(t, err)
+		} else {
+			assert.ErrorIs(t, err, tc.want)
+		}
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDown(t *testing.T) {
+	m, mock := newMigrator(t, "DROP TABLE three")
+
+	// Each step is recorded dirty at the version it goes back to, then clean.
+	expectLocked(mock, 3, false)
+	expectVersion(mock, 2, true)
+	mock.ExpectExec("DROP TABLE three").WillReturnResult(sqlmock.NewResult(0, 0))
+	expectVersion(mock, 2, false)
+	expectVersion(mock, 1, true)
+	mock.ExpectExec("DROP TABLE two").WillReturnResult(sqlmock.NewResult(0, 0))
+	expectVersion(mock, 1, false)
+	expectUnlock(mock)
+	require.NoError(t, m.Down(ctx, 2))
+
+	// A failing file leaves the schema dirty.
+	expectLocked(mock, 1, false)
+	expectVersion(mock, 0, true)
+	mock.ExpectExec("DROP TABLE one").WillReturnError(errors.New("table is in use"))
+	expectUnlock(mock)
+	assert.ErrorContains(t, m.Down(ctx, 5), "table is in use")
+
+	expectLocked(mock, 0, true)
+	expectUnlock(mock)
+	assert.ErrorIs(t, m.Down(ctx, 1), migrations.ErrDirty)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDownNeedsDownFile(t *testing.T) {
+	m, mock := newMigrator(t, "")
+
+	expectLocked(mock, 3, false)
+	expectUnlock(mock)
+	assert.ErrorContains(t, m.Down(ctx, 1), "has no down file")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestForce(t *testing.T) {
+	m, mock := newMigrator(t, "")
+
+	expectLock(mock)
+	expectVersion(mock, 2, false)
+	expectUnlock(mock)
+	require.NoError(t, m.Force(ctx, 2))
+
+	expectLock(mock)
+	expectVersion(mock, 0, false)
+	expectUnlock(mock)
+	require.NoError(t, m.Force(ctx, 0))
+
+	assert.ErrorContains(t, m.Force(ctx, 7), "no migration for version 7")
+	assert.NoError(t, mock.ExpectationsWereMet(), "an unknown version doesn't touch the database")
+}
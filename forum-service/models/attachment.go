@@ -0,0 +1,21 @@
+package models
+
+// Processing states of attachments. Only images are processed; other files
+// are stored as ProcessingSkipped.
+const (
+	ProcessingPending    = "pending"
+	ProcessingInProgress = "processing"
+	ProcessingReady      = "ready"
+	ProcessingFailed     = "failed"
+	ProcessingSkipped    = "skipped"
+)
+
+// AttachmentVariant is a stored rendition of an image attachment.
+type AttachmentVariant struct {
+	Name     string
+	Width    int
+	Height   int
+	MimeType string
+	Size     int64
+	BlobKey  string
+}
@@ -0,0 +1,8 @@
+package models
+
+// CounterRepair tells how many cached counters a repair found wrong and
+// recomputed.
+type CounterRepair struct {
+	Posts int64 // comment_count or last_activity_at
+	Tags  int64 // usage
+}
@@ -0,0 +1,71 @@
+package models
+
+import "errors"
+
+// Publication states of posts and comments.
+const (
+	StatusPublished = "published"
+	StatusPending   = "pending"
+	StatusRejected  = "rejected"
+	StatusHidden    = "hidden"
+)
+
+// Report states.
+const (
+	ReportOpen     = "open"
+	ReportResolved = "resolved"
+)
+
+// Moderation log actions. Dismiss, hide, delete and warn also resolve reports.
+const (
+	ActionApprove   = "approve"
+	ActionReject    = "reject"
+	ActionAutoHide  = "auto_hide"
+	ActionDismiss   = "dismiss"
+	ActionHide      = "hide"
+	ActionDelete    = "delete"
+	ActionWarn      = "warn"
+	ActionMute      = "mute"
+	ActionUnmute    = "unmute"
+	ActionLock      = "lock"
+	ActionUnlock    = "unlock"
+	ActionPin       = "pin"
+	ActionUnpin     = "unpin"
+	ActionFeature   = "feature"
+	ActionUnfeature = "unfeature"
+)
+
+// StatusActions are the logged actions that set a post's or comment's
+// status. The latest of them tells who hid something: dismissing a report
+// only undoes an ActionAutoHide.
+var StatusActions = []string{ActionAutoHide, ActionHide, ActionApprove, ActionReject}
+
+// Post flags moderators toggle.
+const (
+	FlagLocked   = "locked"
+	FlagPinned   = "pinned"
+	FlagFeatured = "featured"
+)
+
+var (
+	ErrPostLocked = errors.New("post is locked")
+	ErrNotMuted   = errors.New("user is not muted")
+)
+
+var (
+	ErrAlreadyReported = errors.New("content already reported by this user")
+	ErrReportResolved  = errors.New("report already resolved")
+)
+
+// Content types moderation flags refer to.
+const (
+	ContentPost    = "post"
+	ContentComment = "comment"
+	ContentUser    = "user" // moderation log entries about a user, e.g. mutes
+)
+
+// ModerationFlag records why a moderation check held content for review.
+type ModerationFlag struct {
+	Check  string `json:"check"`
+	Reason string `json:"reason"`
+}
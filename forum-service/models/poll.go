@@ -0,0 +1,11 @@
+package models
+
+import "errors"
+
+var (
+	ErrPollExists   = errors.New("post already has a poll")
+	ErrPollNotFound = errors.New("poll not found")
+	ErrPollClosed   = errors.New("poll is closed")
+	ErrAlreadyVoted = errors.New("user already voted in this poll")
+	ErrInvalidVote  = errors.New("vote must name options of this poll, exactly one unless the poll is multiple choice")
+)
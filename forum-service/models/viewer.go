@@ -0,0 +1,8 @@
+package models
+
+// Viewer is who a listing is for. Besides published content they may see
+// their own in any status; moderators see everything.
+type Viewer struct {
+	UserID    string
+	Moderator bool
+}
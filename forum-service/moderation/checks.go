@@ -0,0 +1,122 @@
+package moderation
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// BannedWords flags content containing any of the listed words.
+type BannedWords struct {
+	re *regexp.Regexp
+}
+
+func NewBannedWords(words []string) *BannedWords {
+	quoted := make([]string, len(words))
+	for i, w := range words {
+		quoted[i] = regexp.QuoteMeta(strings.ToLower(w))
+	}
+	return &BannedWords{re: regexp.MustCompile(`(?i)\b(` + strings.Join(quoted, "|") + `)\b`)}
+}
+
+func (*BannedWords) Name() string { return "banned_words" }
+
+func (b *BannedWords) Run(ctx context.Context, c *Content) (Action, string, error) {
+	if w := b.re.FindString(c.Text()); w != "" {
+		return Flag, fmt.Sprintf("contains banned word %q", strings.ToLower(w)), nil
+	}
+	return Allow, "", nil
+}
+
+var linkRe = regexp.MustCompile(`(?i)\b(https?://|www\.)\S+`)
+
+// LinkLimit flags content with more than MaxLinks links from users who have
+// no published content older than Age.
+type LinkLimit struct {
+	store    Store
+	MaxLinks int
+	Age      time.Duration
+}
+
+func NewLinkLimit(store Store, maxLinks int, age time.Duration) *LinkLimit {
+	return &LinkLimit{store: store, MaxLinks: maxLinks, Age: age}
+}
+
+func (*LinkLimit) Name() string { return "link_limit" }
+
+func (l *LinkLimit) Run(ctx context.Context, c *Content) (Action, string, error) {
+	links := len(linkRe.FindAllString(c.Text(), -1))
+	if links <= l.MaxLinks {
+		return Allow, "", nil
+	}
+	established, err := l.store.IsEstablished(ctx, c.UserID, l.Age)
+	if err != nil {
+		return Allow, "", err
+	}
+	if established {
+		return Allow, "", nil
+	}
+	return Flag, fmt.Sprintf("%d links from a new account", links), nil
+}
+
+// Duplicate flags text the same user already posted within Window.
+type Duplicate struct {
+	store  Store
+	Window time.Duration
+}
+
+func NewDuplicate(store Store, window time.Duration) *Duplicate {
+	return &Duplicate{store: store, Window: window}
+}
+
+func (*Duplicate) Name() string { return "duplicate" }
+
+func (d *Duplicate) Run(ctx context.Context, c *Content) (Action, string, error) {
+	body := normalize(c.Body)
+	if body == "" {
+		return Allow, "", nil
+	}
+	duplicate, err := d.store.HasDuplicate(ctx, c.UserID, body, d.Window)
+	if err != nil {
+		return Allow, "", err
+	}
+	if duplicate {
+		return Flag, "same text was posted recently", nil
+	}
+	return Allow, "", nil
+}
+
+// PostingRate rejects content from users who created Limit posts and
+// comments within Window. Edits create nothing and are not limited.
+type PostingRate struct {
+	store  Store
+	Limit  int
+	Window time.Duration
+}
+
+func NewPostingRate(store Store, limit int, window time.Duration) *PostingRate {
+	return &PostingRate{store: store, Limit: limit, Window: window}
+}
+
+func (*PostingRate) Name() string { return "posting_rate" }
+
+func (r *PostingRate) Run(ctx context.Context, c *Content) (Action, string, error) {
+	if c.Edit {
+		return Allow, "", nil
+	}
+	count, err := r.store.CountRecent(ctx, c.UserID, r.Window)
+	if err != nil {
+		return Allow, "", err
+	}
+	if count >= r.Limit {
+		return Reject, fmt.Sprintf("posting too fast, at most %d posts and comments per %s", r.Limit, r.Window), nil
+	}
+	return Allow, "", nil
+}
+
+// normalize matches the normalization the duplicate query applies in SQL.
+func normalize(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
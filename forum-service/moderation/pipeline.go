@@ -0,0 +1,98 @@
+package moderation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"forum-service/config"
+	"forum-service/models"
+	"strings"
+	"time"
+)
+
+// ErrRejected is returned by Pipeline.Run when a check refuses content outright.
+var ErrRejected = errors.New("content rejected")
+
+type Action int
+
+const (
+	Allow  Action = iota
+	Flag          // hold for moderator review
+	Reject        // refuse to store
+)
+
+// Content is a post or comment about to be stored.
+type Content struct {
+	Type   string // models.ContentPost or models.ContentComment
+	UserID string
+	Title  string
+	Body   string
+	Edit   bool // new text for content that is already stored
+}
+
+// Text is everything in the content a reader sees.
+func (c *Content) Text() string {
+	return strings.TrimSpace(c.Title + "\n" + c.Body)
+}
+
+type Check interface {
+	Name() string
+	Run(context.Context, *Content) (Action, string, error)
+}
+
+// Store is the data checks look at. storage.ModerationI satisfies it.
+type Store interface {
+	CountRecent(ctx context.Context, userID string, window time.Duration) (int, error)
+	IsEstablished(ctx context.Context, userID string, age time.Duration) (bool, error)
+	HasDuplicate(ctx context.Context, userID, body string, window time.Duration) (bool, error)
+}
+
+// Pipeline runs checks in order before content is persisted.
+type Pipeline struct {
+	checks []Check
+}
+
+func NewPipeline(checks ...Check) *Pipeline {
+	return &Pipeline{checks: checks}
+}
+
+// New builds the pipeline described by the MODERATION_* settings.
+func New(cfg config.Config, store Store) *Pipeline {
+	var checks []Check
+	if cfg.MODERATION_RATE_LIMIT > 0 {
+		checks = append(checks, NewPostingRate(store, cfg.MODERATION_RATE_LIMIT, cfg.MODERATION_RATE_WINDOW))
+	}
+	if len(cfg.MODERATION_BANNED_WORDS) > 0 {
+		checks = append(checks, NewBannedWords(cfg.MODERATION_BANNED_WORDS))
+	}
+	if cfg.MODERATION_NEW_ACCOUNT_AGE > 0 {
+		checks = append(checks, NewLinkLimit(store, cfg.MODERATION_NEW_ACCOUNT_MAX_LINKS, cfg.MODERATION_NEW_ACCOUNT_AGE))
+	}
+	if cfg.MODERATION_DUPLICATE_WINDOW > 0 {
+		checks = append(checks, NewDuplicate(store, cfg.MODERATION_DUPLICATE_WINDOW))
+	}
+	return NewPipeline(checks...)
+}
+
+// Run returns the status the content should be stored with and the flags
+// explaining a pending status. A rejection stops the pipeline and is
+// reported as an error wrapping ErrRejected.
+func (p *Pipeline) Run(ctx context.Context, c *Content) (string, []models.ModerationFlag, error) {
+	var flags []models.ModerationFlag
+	for _, check := range p.checks {
+		action, reason, err := check.Run(ctx, c)
+		if err != nil {
+			return "", nil, fmt.Errorf("moderation check %s: %w", check.Name(), err)
+		}
+		switch action {
+		case Reject:
+			return "", nil, fmt.Errorf("%w: %s", ErrRejected, reason)
+		case Flag:
+			flags = append(flags, models.ModerationFlag{Check: check.Name(), Reason: reason})
+		}
+	}
+	if len(flags) > 0 {
+		return models.StatusPending, flags, nil
+	}
+	return models.StatusPublished, nil, nil
+}
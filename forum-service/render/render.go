@@ -0,0 +1,142 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"regexp"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+// Version identifies the Markdown options and sanitizer policy below. Bump it
+// whenever either changes so cached renderings are rebuilt.
+const Version = 1
+
+const (
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+)
+
+var ErrUnknownFormat = errors.New("format must be html or markdown")
+
+// ParseFormat validates a read-time format; an empty value means markdown.
+func ParseFormat(format string) (string, error) {
+	switch format {
+	case "", FormatMarkdown:
+		return FormatMarkdown, nil
+	case FormatHTML:
+		return FormatHTML, nil
+	}
+	return "", ErrUnknownFormat
+}
+
+// Raw HTML in the source is dropped by goldmark (no html.WithUnsafe), and the
+// output is sanitized again so a renderer bug cannot leak markup.
+var markdown = goldmark.New(
+	goldmark.WithExtensions(extension.GFM),
+)
+
+var policy = newPolicy()
+
+func newPolicy() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	p.RequireNoFollowOnLinks(true)
+	p.RequireNoReferrerOnLinks(true)
+	p.AddTargetBlankToFullyQualifiedLinks(true)
+	p.AllowURLSchemes("http", "https", "mailto")
+	// Fenced code blocks carry their language for client-side highlighting.
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[\w+#-]+$`)).OnElements("code")
+	p.AllowAttrs("type", "checked", "disabled").OnElements("input")
+	return p
+}
+
+// HTML renders Markdown source to sanitized HTML.
+func HTML(source string) (string, error) {
+	var buf bytes.Buffer
+	if err := markdown.Convert([]byte(source), &buf); err != nil {
+		return "", err
+	}
+	return policy.Sanitize(buf.String()), nil
+}
+
+// Key names one revision of a post or comment body.
+type Key struct {
+	ContentID string
+	Revision  int64
+}
+
+// Body is one revision of a body to render.
+type Body struct {
+	Key
+	Source string
+}
+
+// Cache stores renderings keyed by content and revision. storage.RenderI
+// satisfies it.
+type Cache interface {
+	// GetAll returns the current renderings it has of keys.
+	GetAll(ctx context.Context, contentType string, version int, keys []Key) (map[Key]string, error)
+	PutAll(ctx context.Context, contentType string, version int, html map[Key]string) error
+}
+
+// Renderer renders each revision of a post or comment once.
+type Renderer struct {
+	cache Cache
+}
+
+func NewRenderer(cache Cache) *Renderer {
+	return &Renderer{cache: cache}
+}
+
+// Render returns the HTML for one revision of a body, from the cache when
+// possible.
+func (r *Renderer) Render(ctx context.Context, contentType, contentID string, revision int64, source string) (string, error) {
+	html, err := r.RenderAll(ctx, contentType, []Body{{Key: Key{contentID, revision}, Source: source}})
+	if err != nil {
+		return "", err
+	}
+	return html[0], nil
+}
+
+// RenderAll returns the HTML for each of bodies, in order. The cache is read
+// and written once for all of them; a failing cache only costs re-renders.
+func (r *Renderer) RenderAll(ctx context.Context, contentType string, bodies []Body) ([]string, error) {
+	if len(bodies) == 0 {
+		return nil, nil
+	}
+	var cached map[Key]string
+	if r.cache != nil {
+		keys := make([]Key, len(bodies))
+		for i, b := range bodies {
+			keys[i] = b.Key
+		}
+		var err error
+		if cached, err = r.cache.GetAll(ctx, contentType, Version, keys); err != nil {
+			log.Println("reading rendered bodies:", err)
+		}
+	}
+
+	out := make([]string, len(bodies))
+	rendered := map[Key]string{}
+	for i, b := range bodies {
+		if html, ok := cached[b.Key]; ok {
+			out[i] = html
+			continue
+		}
+		html, err := HTML(b.Source)
+		if err != nil {
+			return nil, err
+		}
+		out[i], rendered[b.Key] = html, html
+	}
+	if r.cache != nil && len(rendered) > 0 {
+		if err := r.cache.PutAll(ctx, contentType, Version, rendered); err != nil {
+			log.Println("storing rendered bodies:", err)
+		}
+	}
+	return out, nil
+}
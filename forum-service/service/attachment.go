@@ -0,0 +1,276 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"forum-service/blob"
+	pb "forum-service/forum-protos/genprotos"
+	"forum-service/imaging"
+	"forum-service/models"
+	st "forum-service/storage"
+	"log"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type AttachmentService struct {
+	storage      st.Storage
+	blobs        blob.Store
+	maxSize      int64
+	allowedTypes map[string]bool
+	pb.UnimplementedAttachmentServiceServer
+}
+
+// NewAttachmentService accepts files up to maxSize bytes whose sniffed type
+// is one of allowedTypes.
+func NewAttachmentService(storage *st.Storage, blobs blob.Store, maxSize int64, allowedTypes []string) *AttachmentService {
+	allowed := make(map[string]bool, len(allowedTypes))
+	for _, t := range allowedTypes {
+		allowed[t] = true
+	}
+	return &AttachmentService{storage: *storage, blobs: blobs, maxSize: maxSize, allowedTypes: allowed}
+}
+
+func (s *AttachmentService) Upload(ctx context.Context, req *pb.AttachmentCReq) (*pb.AttachmentRes, error) {
+	if len(req.Data) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "file is empty")
+	}
+	if int64(len(req.Data)) > s.maxSize {
+		return nil, status.Errorf(codes.InvalidArgument, "file is larger than %d bytes", s.maxSize)
+	}
+	mimeType := sniff(req.Data)
+	if !s.allowedTypes[mimeType] {
+		return nil, status.Errorf(codes.InvalidArgument, "file type %s is not allowed", mimeType)
+	}
+	if err := s.checkOwner(ctx, req.ContentType, req.ContentId, req.UserId); err != nil {
+		return nil, err
+	}
+	if err := checkMuted(ctx, s.storage, req.UserId); err != nil {
+		return nil, err
+	}
+
+	a := &pb.AttachmentRes{
+		AttachmentId: uuid.NewString(),
+		ContentType:  req.ContentType,
+		ContentId:    req.ContentId,
+		UserId:       req.UserId,
+		Filename:     cleanFilename(req.Filename),
+		MimeType:     mimeType,
+		Size:         int64(len(req.Data)),
+	}
+	// Images are held back until the worker has stripped their metadata.
+	if imaging.Supported(mimeType) {
+		a.ProcessingStatus = models.ProcessingPending
+	}
+	key := "attachments/" + a.AttachmentId
+	if err := s.blobs.Put(ctx, key, bytes.NewReader(req.Data), a.Size, mimeType); err != nil {
+		return nil, err
+	}
+
+	resp, err := s.storage.AttachmentS.Create(ctx, a, key)
+	if err != nil {
+		if err := s.blobs.Delete(ctx, key); err != nil {
+			log.Println("orphaned blob", key, err)
+		}
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (s *AttachmentService) GetByID(ctx context.Context, req *pb.AttachmentGReqOrDReq) (*pb.AttachmentRes, error) {
+	a, _, err := s.get(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	variants, err := s.storage.AttachmentS.GetVariants(ctx, a.AttachmentId)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range variants {
+		a.Variants = append(a.Variants, &pb.AttachmentVariant{
+			Name:     v.Name,
+			Width:    int32(v.Width),
+			Height:   int32(v.Height),
+			MimeType: v.MimeType,
+			Size:     v.Size,
+		})
+	}
+	return a, nil
+}
+
+func (s *AttachmentService) GetAll(ctx context.Context, req *pb.AttachmentGAReq) (*pb.AttachmentGARes, error) {
+	if err := checkVisible(ctx, s.storage, req.ContentType, req.ContentId); err != nil {
+		return nil, err
+	}
+	return s.storage.AttachmentS.GetAll(ctx, req)
+}
+
+// get loads an attachment the caller may read: its post or comment has to
+// be visible to them.
+func (s *AttachmentService) get(ctx context.Context, req *pb.AttachmentGReqOrDReq) (*pb.AttachmentRes, string, error) {
+	a, key, err := s.storage.AttachmentS.GetByID(ctx, req)
+	if err != nil {
+		return nil, "", notFound(err)
+	}
+	if err := checkVisible(ctx, s.storage, a.ContentType, a.ContentId); err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, "", errNotVisible("attachment")
+		}
+		return nil, "", err
+	}
+	return a, key, nil
+}
+
+// Download returns the bytes of an attachment, or of one of its image
+// variants when req.Variant names one.
+func (s *AttachmentService) Download(ctx context.Context, req *pb.AttachmentGReqOrDReq) (*pb.AttachmentDataRes, error) {
+	a, key, err := s.get(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	switch a.ProcessingStatus {
+	case models.ProcessingPending, models.ProcessingInProgress:
+		return nil, status.Error(codes.Unavailable, "attachment is still being processed")
+	case models.ProcessingFailed:
+		return nil, status.Error(codes.FailedPrecondition, "attachment could not be processed")
+	}
+
+	if req.Variant != "" && req.Variant != "original" {
+		v, err := s.variant(ctx, a.AttachmentId, req.Variant)
+		if err != nil {
+			return nil, err
+		}
+		key = v.BlobKey
+		a.MimeType, a.Size, a.Width, a.Height = v.MimeType, v.Size, int32(v.Width), int32(v.Height)
+	}
+
+	data, err := s.read(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.AttachmentDataRes{Attachment: a, Data: data}, nil
+}
+
+func (s *AttachmentService) variant(ctx context.Context, attachmentID, name string) (*models.AttachmentVariant, error) {
+	variants, err := s.storage.AttachmentS.GetVariants(ctx, attachmentID)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range variants {
+		if v.Name == name {
+			return &v, nil
+		}
+	}
+	return nil, status.Errorf(codes.NotFound, "variant %s not found", name)
+}
+
+// Delete lets the uploader remove an attachment.
+func (s *AttachmentService) Delete(ctx context.Context, req *pb.AttachmentGReqOrDReq) (*pb.Void, error) {
+	a, key, err := s.storage.AttachmentS.GetByID(ctx, req)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	if a.UserId != req.UserId {
+		return nil, status.Error(codes.PermissionDenied, "only the uploader can delete an attachment")
+	}
+	variants, err := s.storage.AttachmentS.GetVariants(ctx, a.AttachmentId)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.storage.AttachmentS.Delete(ctx, req); err != nil {
+		return nil, err
+	}
+	keys := []string{key}
+	for _, v := range variants {
+		keys = append(keys, v.BlobKey)
+	}
+	for _, key := range keys {
+		if err := s.blobs.Delete(ctx, key); err != nil {
+			log.Println("orphaned blob", key, err)
+		}
+	}
+	return &pb.Void{}, nil
+}
+
+func (s *AttachmentService) read(ctx context.Context, key string) ([]byte, error) {
+	r, err := s.blobs.Get(ctx, key)
+	if err == blob.ErrNotFound {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(r); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// checkOwner allows attaching files only to the uploader's own posts and
+// comments, while they are published or held for review; hidden and
+// rejected content takes no more files.
+func (s *AttachmentService) checkOwner(ctx context.Context, contentType, contentID, userID string) error {
+	var author, contentStatus string
+	switch contentType {
+	case models.ContentPost:
+		p, err := s.storage.PostS.GetByID(ctx, &pb.PostGReqOrDReq{PostId: contentID})
+		if err != nil {
+			return notFound(err)
+		}
+		author, contentStatus = p.UserId, p.Status
+	case models.ContentComment:
+		c, err := s.storage.CommentS.GetByID(ctx, &pb.CommentGReqOrDReq{CommentId: contentID})
+		if err != nil {
+			return notFound(err)
+		}
+		author, contentStatus = c.UserId, c.Status
+	default:
+		return status.Errorf(codes.InvalidArgument, "content_type must be %q or %q", models.ContentPost, models.ContentComment)
+	}
+	if author != userID {
+		return status.Error(codes.PermissionDenied, fmt.Sprintf("only the author can attach files to a %s", contentType))
+	}
+	if contentStatus != models.StatusPublished && contentStatus != models.StatusPending {
+		return status.Errorf(codes.FailedPrecondition, "%s is %s", contentType, contentStatus)
+	}
+	return nil
+}
+
+// sniff detects the media type from the file contents; the client's claimed
+// type and extension are ignored.
+func sniff(data []byte) string {
+	mimeType, _, err := mime.ParseMediaType(http.DetectContentType(data))
+	if err != nil {
+		return "application/octet-stream"
+	}
+	return mimeType
+}
+
+func cleanFilename(name string) string {
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	if name == "." || name == "/" {
+		return "file"
+	}
+	if len(name) > 255 {
+		name = name[len(name)-255:]
+	}
+	return name
+}
+
+// notFound maps the storage "... not found" errors to codes.NotFound.
+func notFound(err error) error {
+	if strings.HasSuffix(err.Error(), "not found") {
+		return status.Error(codes.NotFound, err.Error())
+	}
+	return err
+}
@@ -0,0 +1,94 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"forum-service/blob"
+	pb "forum-service/forum-protos/genprotos"
+	"forum-service/service"
+	"forum-service/storage"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestAttachmentsFollowTheirPost(t *testing.T) {
+	st := storage.NewMemoryStorage()
+	blobs, err := blob.NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+	attachments := service.NewAttachmentService(st, blobs, 1<<20, []string{"text/plain"})
+	ctx := context.Background()
+
+	category, err := st.CategoryS.Create(ctx, &pb.CategoryCReqOrCResOrGResOrUReqOrURes{CategoryId: uuid.NewString(), Name: "General"})
+	require.NoError(t, err)
+	held, err := st.PostS.Create(ctx, &pb.PostCReqOrCResOrGResOrUResp{
+		PostId: uuid.NewString(), UserId: "author", Title: "Held", Body: "Held", CategoryId: category.CategoryId, Status: "pending",
+	}, nil)
+	require.NoError(t, err)
+	a, err := attachments.Upload(ctx, &pb.AttachmentCReq{ContentType: "post", ContentId: held.PostId, UserId: "author", Filename: "notes.txt", Data: []byte("notes")})
+	require.NoError(t, err)
+	req := &pb.AttachmentGReqOrDReq{AttachmentId: a.AttachmentId}
+	list := &pb.AttachmentGAReq{ContentType: "post", ContentId: held.PostId}
+
+	// A held post's files are for its author and moderators only.
+	for _, ctx := range []context.Context{context.Background(), as("stranger", "user")} {
+		_, err = attachments.GetByID(ctx, req)
+		assert.Equal(t, codes.NotFound, status.Code(err))
+		_, err = attachments.Download(ctx, req)
+		assert.Equal(t, codes.NotFound, status.Code(err))
+		_, err = attachments.GetAll(ctx, list)
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	}
+	for _, ctx := range []context.Context{as("author", "user"), as("mod", "moderator")} {
+		_, err = attachments.GetByID(ctx, req)
+		assert.NoError(t, err)
+		got, err := attachments.Download(ctx, req)
+		require.NoError(t, err)
+		assert.Equal(t, "notes", string(got.Data))
+		all, err := attachments.GetAll(ctx, list)
+		require.NoError(t, err)
+		assert.Len(t, all.Attachments, 1)
+	}
+
+	// Once the post is deleted nobody gets its files.
+	_, err = st.PostS.Delete(ctx, &pb.PostGReqOrDReq{PostId: held.PostId})
+	require.NoError(t, err)
+	for _, ctx := range []context.Context{as("author", "user"), as("mod", "moderator")} {
+		_, err = attachments.GetByID(ctx, req)
+		assert.Equal(t, codes.NotFound, status.Code(err))
+		_, err = attachments.Download(ctx, req)
+		assert.Equal(t, codes.NotFound, status.Code(err))
+		_, err = attachments.GetAll(ctx, list)
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	}
+}
+
+func TestAttachmentsFollowTheirComment(t *testing.T) {
+	st := storage.NewMemoryStorage()
+	blobs, err := blob.NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+	attachments := service.NewAttachmentService(st, blobs, 1<<20, []string{"text/plain"})
+	ctx := context.Background()
+
+	category, err := st.CategoryS.Create(ctx, &pb.CategoryCReqOrCResOrGResOrUReqOrURes{CategoryId: uuid.NewString(), Name: "General"})
+	require.NoError(t, err)
+	post, err := st.PostS.Create(ctx, &pb.PostCReqOrCResOrGResOrUResp{
+		PostId: uuid.NewString(), UserId: "author", Title: "Post", Body: "Post", CategoryId: category.CategoryId,
+	}, nil)
+	require.NoError(t, err)
+	held, err := st.CommentS.Create(ctx, &pb.CommentCReqOrCResOrGResOrURes{
+		CommentId: uuid.NewString(), UserId: "replier", PostId: post.PostId, Body: "Reply", Status: "pending",
+	})
+	require.NoError(t, err)
+	a, err := attachments.Upload(ctx, &pb.AttachmentCReq{ContentType: "comment", ContentId: held.CommentId, UserId: "replier", Filename: "notes.txt", Data: []byte("notes")})
+	require.NoError(t, err)
+
+	_, err = attachments.Download(as("author", "user"), &pb.AttachmentGReqOrDReq{AttachmentId: a.AttachmentId})
+	assert.Equal(t, codes.NotFound, status.Code(err), "the post's author can't see a held reply")
+	_, err = attachments.Download(as("replier", "user"), &pb.AttachmentGReqOrDReq{AttachmentId: a.AttachmentId})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+
+	pb "forum-service/forum-protos/genprotos"
+	"forum-service/models"
+	st "forum-service/storage"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// The gateway names the authenticated user a request is made for, and
+// their role, in these metadata keys.
+const (
+	UserIDHeader   = "x-user-id"
+	UserRoleHeader = "x-user-role"
+)
+
+// caller is the user a request is made for; both fields are empty when the
+// gateway didn't say.
+type caller struct {
+	userID, role string
+}
+
+func callerFrom(ctx context.Context) caller {
+	md, _ := metadata.FromIncomingContext(ctx)
+	first := func(key string) string {
+		if v := md.Get(key); len(v) > 0 {
+			return v[0]
+		}
+		return ""
+	}
+	return caller{userID: first(UserIDHeader), role: first(UserRoleHeader)}
+}
+
+// or returns c's user ID, or fallback when the gateway didn't name one.
+func (c caller) or(fallback string) string {
+	if c.userID != "" {
+		return c.userID
+	}
+	return fallback
+}
+
+// viewer is c as storage listings take it.
+func (c caller) viewer() models.Viewer {
+	return models.Viewer{UserID: c.userID, Moderator: c.moderator()}
+}
+
+func (c caller) moderator() bool {
+	return c.role == "moderator" || c.role == "admin"
+}
+
+// canSee tells whether c may read content by author in status: published
+// content is public, the rest is for its author and moderators.
+func (c caller) canSee(author, contentStatus string) bool {
+	return contentStatus == models.StatusPublished || (c.userID != "" && c.userID == author) || c.moderator()
+}
+
+// errNotVisible is what callers who can't see a piece of content get, the
+// same as if it didn't exist.
+func errNotVisible(contentType string) error {
+	return status.Errorf(codes.NotFound, "%s not found", contentType)
+}
+
+// checkVisible refuses a post or comment the caller can't see, or that was
+// deleted, as if it didn't exist. A comment is only as visible as the post
+// it replies to.
+func checkVisible(ctx context.Context, storage st.Storage, contentType, contentID string) error {
+	c := callerFrom(ctx)
+	postID := contentID
+	switch contentType {
+	case models.ContentPost:
+	case models.ContentComment:
+		comment, err := storage.CommentS.GetByID(ctx, &pb.CommentGReqOrDReq{CommentId: contentID})
+		if err != nil {
+			return notFound(err)
+		}
+		if !c.canSee(comment.UserId, comment.Status) {
+			return errNotVisible(contentType)
+		}
+		postID = comment.PostId
+	default:
+		return status.Errorf(codes.InvalidArgument, "content_type must be %q or %q", models.ContentPost, models.ContentComment)
+	}
+	post, err := storage.PostS.GetByID(ctx, &pb.PostGReqOrDReq{PostId: postID})
+	if err != nil {
+		return errNotVisible(contentType)
+	}
+	if !c.canSee(post.UserId, post.Status) {
+		return errNotVisible(contentType)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	pb "forum-service/forum-protos/genprotos"
+	"forum-service/service"
+	"forum-service/storage"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// as makes ctx look like a request the gateway forwarded for userID.
+func as(userID, role string) context.Context {
+	return metadata.NewIncomingContext(context.Background(),
+		metadata.Pairs(service.UserIDHeader, userID, service.UserRoleHeader, role))
+}
+
+func TestHeldContentVisibility(t *testing.T) {
+	st := storage.NewMemoryStorage()
+	posts := service.NewPostService(st, nil)
+	comments := service.NewCommentService(st, nil)
+	ctx := context.Background()
+
+	category, err := st.CategoryS.Create(ctx, &pb.CategoryCReqOrCResOrGResOrUReqOrURes{CategoryId: uuid.NewString(), Name: "General"})
+	require.NoError(t, err)
+	held, err := st.PostS.Create(ctx, &pb.PostCReqOrCResOrGResOrUResp{
+		PostId: uuid.NewString(), UserId: "author", Title: "Held", Body: "Held", CategoryId: category.CategoryId, Status: "pending",
+	}, nil)
+	require.NoError(t, err)
+	comment, err := st.CommentS.Create(ctx, &pb.CommentCReqOrCResOrGResOrURes{
+		CommentId: uuid.NewString(), UserId: "author", PostId: held.PostId, Body: "Reply", Status: "published",
+	})
+	require.NoError(t, err)
+
+	get := func(ctx context.Context) codes.Code {
+		_, err := posts.GetByID(ctx, &pb.PostGReqOrDReq{PostId: held.PostId})
+		return status.Code(err)
+	}
+	assert.Equal(t, codes.NotFound, get(as("stranger", "user")))
+	assert.Equal(t, codes.NotFound, get(ctx), "nobody in particular")
+	assert.Equal(t, codes.OK, get(as("author", "user")))
+	assert.Equal(t, codes.OK, get(as("mod", "moderator")))
+
+	_, err = comments.GetByID(as("stranger", "user"), &pb.CommentGReqOrDReq{CommentId: comment.CommentId})
+	assert.Equal(t, codes.NotFound, status.Code(err), "replies to a held post are held with it")
+	list := func(ctx context.Context) int32 {
+		res, err := comments.GetAll(ctx, &pb.CommentGAReq{Filter: &pb.CommentFilter{UserId: "author"}, Pagination: &pb.Pagination{}})
+		require.NoError(t, err)
+		return res.Count
+	}
+	assert.Zero(t, list(as("stranger", "user")), "nor are they listed")
+	assert.Equal(t, int32(1), list(as("author", "user")))
+
+	_, err = posts.Delete(ctx, &pb.PostGReqOrDReq{PostId: held.PostId})
+	require.NoError(t, err)
+	assert.Equal(t, codes.NotFound, get(as("mod", "moderator")), "deleted posts are gone for everyone")
+}
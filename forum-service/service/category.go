@@ -19,7 +19,7 @@ func NewCategoryService(storage *st.Storage) *CategoryService {
 
 func (s *CategoryService) Create(ctx context.Context, category *pb.CategoryCReqOrCResOrGResOrUReqOrURes) (*pb.CategoryCReqOrCResOrGResOrUReqOrURes, error) {
 	category.CategoryId = uuid.NewString()
-	resp, err := s.storage.CategoryS.Create(category)
+	resp, err := s.storage.CategoryS.Create(ctx, category)
 
 	if err != nil {
 		return nil, err
@@ -29,7 +29,7 @@ func (s *CategoryService) Create(ctx context.Context, category *pb.CategoryCReqO
 }
 
 func (s *CategoryService) GetByID(ctx context.Context, idReq *pb.CategoryGReqOrDReq) (*pb.CategoryCReqOrCResOrGResOrUReqOrURes, error) {
-	resp, err := s.storage.CategoryS.GetByID(idReq)
+	resp, err := s.storage.CategoryS.GetByID(ctx, idReq)
 
 	if err != nil {
 		return nil, err
@@ -39,7 +39,7 @@ func (s *CategoryService) GetByID(ctx context.Context, idReq *pb.CategoryGReqOrD
 }
 
 func (s *CategoryService) GetAll(ctx context.Context, allCategories *pb.CategoryGAReq) (*pb.CategoryGARes, error) {
-	orders, err := s.storage.CategoryS.GetAll(allCategories)
+	orders, err := s.storage.CategoryS.GetAll(ctx, allCategories)
 	if err != nil {
 		return nil, err
 	}
@@ -47,8 +47,8 @@ func (s *CategoryService) GetAll(ctx context.Context, allCategories *pb.Category
 	return orders, nil
 }
 
-func (s *CategoryService) Update(ctx context.Context, category *pb.CategoryCReqOrCResOrGResOrUReqOrURes) (*pb.CategoryCReqOrCResOrGResOrUReqOrURes, error) {
-	resp, err := s.storage.CategoryS.Update(category)
+func (s *CategoryService) Update(ctx context.Context, category *pb.CategoryUReq) (*pb.CategoryCReqOrCResOrGResOrUReqOrURes, error) {
+	resp, err := s.storage.CategoryS.Update(ctx, category)
 
 	if err != nil {
 		return nil, err
@@ -58,7 +58,7 @@ func (s *CategoryService) Update(ctx context.Context, category *pb.CategoryCReqO
 }
 
 func (s *CategoryService) Delete(ctx context.Context, idReq *pb.CategoryGReqOrDReq) (*pb.Void, error) {
-	_, err := s.storage.CategoryS.Delete(idReq)
+	_, err := s.storage.CategoryS.Delete(ctx, idReq)
 
 	return nil, err
 }
@@ -3,35 +3,97 @@ package service
 import (
 	"context"
 	pb "forum-service/forum-protos/genprotos"
+	"forum-service/models"
+	"forum-service/moderation"
+	"forum-service/render"
 	st "forum-service/storage"
 
 	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type CommentService struct {
-	storage st.Storage
+	storage    st.Storage
+	moderation *moderation.Pipeline
+	renderer   *render.Renderer
 	pb.UnimplementedCommentServiceServer
 }
 
-func NewCommentService(storage *st.Storage) *CommentService {
-	return &CommentService{storage: *storage}
+func NewCommentService(storage *st.Storage, pipeline *moderation.Pipeline) *CommentService {
+	return &CommentService{storage: *storage, moderation: pipeline, renderer: render.NewRenderer(storage.RenderS)}
 }
 
 func (s *CommentService) Create(ctx context.Context, comment *pb.CommentCReqOrCResOrGResOrURes) (*pb.CommentCReqOrCResOrGResOrURes, error) {
 	comment.CommentId = uuid.NewString()
-	resp, err := s.storage.CommentS.Create(comment)
 
-	if err != nil {
+	if err := checkMuted(ctx, s.storage, comment.UserId); err != nil {
 		return nil, err
 	}
 
+	post, err := s.storage.PostS.GetByID(ctx, &pb.PostGReqOrDReq{PostId: comment.PostId})
+	if err != nil {
+		return nil, notFound(err)
+	}
+	if post.Status != models.StatusPublished {
+		return nil, errNotVisible(models.ContentPost)
+	}
+
+	state, flags, err := s.moderation.Run(ctx, &moderation.Content{
+		Type:   models.ContentComment,
+		UserID: comment.UserId,
+		Body:   comment.Body,
+	})
+	if err != nil {
+		return nil, moderationErr(err)
+	}
+	comment.Status = state
+
+	// A pending comment is stored together with the flags explaining it.
+	var resp *pb.CommentCReqOrCResOrGResOrURes
+	err = s.storage.Tx.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		resp, err = s.storage.CommentS.Create(ctx, comment)
+		if err != nil {
+			return err
+		}
+		if len(flags) > 0 {
+			return s.storage.ModerationS.Flag(ctx, models.ContentComment, resp.CommentId, flags)
+		}
+		return nil
+	})
+	if err == models.ErrPostLocked {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	if err != nil {
+		return nil, notFound(err)
+	}
+
 	return resp, nil
 }
 
 func (s *CommentService) GetByID(ctx context.Context, idReq *pb.CommentGReqOrDReq) (*pb.CommentCReqOrCResOrGResOrURes, error) {
-	resp, err := s.storage.CommentS.GetByID(idReq)
+	format, err := render.ParseFormat(idReq.Format)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	resp, err := s.storage.CommentS.GetByID(ctx, idReq)
 
 	if err != nil {
+		return nil, notFound(err)
+	}
+	// A comment is only as visible as the post it replies to.
+	c := callerFrom(ctx)
+	if !c.canSee(resp.UserId, resp.Status) {
+		return nil, errNotVisible(models.ContentComment)
+	}
+	post, err := s.storage.PostS.GetByID(ctx, &pb.PostGReqOrDReq{PostId: resp.PostId})
+	if err != nil || !c.canSee(post.UserId, post.Status) {
+		return nil, errNotVisible(models.ContentComment)
+	}
+
+	if err := s.render(ctx, format, resp); err != nil {
 		return nil, err
 	}
 
@@ -39,39 +101,86 @@ func (s *CommentService) GetByID(ctx context.Context, idReq *pb.CommentGReqOrDRe
 }
 
 func (s *CommentService) GetAll(ctx context.Context, allComments *pb.CommentGAReq) (*pb.CommentGARes, error) {
-	comments, err := s.storage.CommentS.GetAll(allComments)
+	format, err := render.ParseFormat(allComments.Format)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	comments, err := s.storage.CommentS.GetAll(ctx, allComments, callerFrom(ctx).viewer())
 
 	if err != nil {
 		return nil, err
 	}
 
+	if err := s.render(ctx, format, comments.Comments...); err != nil {
+		return nil, err
+	}
+
 	return comments, nil
 }
 
-func (s *CommentService) Update(ctx context.Context, comment *pb.CommentUReq) (*pb.CommentCReqOrCResOrGResOrURes, error) {
-	resp, err := s.storage.CommentS.Update(comment)
-
+// render fills BodyHtml when the caller asked for HTML.
+func (s *CommentService) render(ctx context.Context, format string, comments ...*pb.CommentCReqOrCResOrGResOrURes) error {
+	if format != render.FormatHTML {
+		return nil
+	}
+	bodies := make([]render.Body, len(comments))
+	for i, c := range comments {
+		bodies[i] = render.Body{Key: render.Key{ContentID: c.CommentId, Revision: c.Revision}, Source: c.Body}
+	}
+	html, err := s.renderer.RenderAll(ctx, models.ContentComment, bodies)
 	if err != nil {
-		return nil, err
+		return err
 	}
-
-	return resp, nil
+	for i, c := range comments {
+		c.BodyHtml = html[i]
+	}
+	return nil
 }
 
-func (s *CommentService) Delete(ctx context.Context, idReq *pb.CommentGReqOrDReq) (*pb.Void, error) {
-	tx, err := s.storage.Db.Begin()
+func (s *CommentService) Update(ctx context.Context, comment *pb.CommentUReq) (*pb.CommentCReqOrCResOrGResOrURes, error) {
+	existing, err := s.storage.CommentS.GetByID(ctx, &pb.CommentGReqOrDReq{CommentId: comment.CommentId})
 	if err != nil {
-		return nil, err
+		return nil, notFound(err)
 	}
-	_, err = s.storage.CommentS.Delete(tx, idReq)
-	if err != nil {
-		tx.Rollback()
+	if err := checkMuted(ctx, s.storage, callerFrom(ctx).or(existing.UserId)); err != nil {
 		return nil, err
 	}
-	err = tx.Commit()
+
+	// A new body goes through the same checks as a new comment.
+	state, flags := existing.Status, []models.ModerationFlag(nil)
+	if comment.Body != existing.Body {
+		checked, f, err := s.moderation.Run(ctx, &moderation.Content{
+			Type:   models.ContentComment,
+			UserID: existing.UserId,
+			Body:   comment.Body,
+			Edit:   true,
+		})
+		if err != nil {
+			return nil, moderationErr(err)
+		}
+		state, flags = editStatus(existing.Status, checked), f
+	}
+
+	var resp *pb.CommentCReqOrCResOrGResOrURes
+	err = s.storage.Tx.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		resp, err = s.storage.CommentS.Update(ctx, comment, state)
+		if err != nil {
+			return err
+		}
+		if len(flags) > 0 {
+			return s.storage.ModerationS.Flag(ctx, models.ContentComment, resp.CommentId, flags)
+		}
+		return nil
+	})
 	if err != nil {
-		tx.Rollback()
 		return nil, err
 	}
-	return &pb.Void{}, nil
+
+	return resp, nil
+}
+
+func (s *CommentService) Delete(ctx context.Context, idReq *pb.CommentGReqOrDReq) (*pb.Void, error) {
+	return s.storage.CommentS.Delete(ctx, idReq)
 }
@@ -0,0 +1,31 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// Deadline bounds requests that arrive without a deadline of their own, so a
+// client that never gives up can't hold a database connection forever. A
+// deadline set by the caller always wins.
+func Deadline(timeout time.Duration) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if _, ok := ctx.Deadline(); !ok && timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		resp, err := handler(ctx, req)
+		// Report an aborted query as DeadlineExceeded or Canceled rather than
+		// the driver's error.
+		if err != nil && ctx.Err() != nil {
+			if _, ok := status.FromError(err); !ok {
+				return nil, status.FromContextError(ctx.Err()).Err()
+			}
+		}
+		return resp, err
+	}
+}
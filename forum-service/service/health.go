@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// ReportHealth keeps the overall status of the grpc.health.v1 service in
+// step with ready, checking it every interval until ctx is done. The server
+// is serving while ready returns nil, i.e. its database is reachable.
+func ReportHealth(ctx context.Context, srv *health.Server, ready func() error, interval time.Duration) {
+	update := func() {
+		status := healthpb.HealthCheckResponse_SERVING
+		if ready() != nil {
+			status = healthpb.HealthCheckResponse_NOT_SERVING
+		}
+		srv.SetServingStatus("", status)
+	}
+	update()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			srv.Shutdown()
+			return
+		case <-ticker.C:
+			update()
+		}
+	}
+}
+
+// Probe asks the server listening on addr, e.g. ":50051", whether it is
+// serving. Container health checks run it through "forum healthcheck", as
+// the image has no grpc_health_probe.
+func Probe(ctx context.Context, addr string) error {
+	if strings.HasPrefix(addr, ":") {
+		addr = "localhost" + addr
+	}
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	res, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		return err
+	}
+	if res.Status != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("forum service is %s", res.Status)
+	}
+	return nil
+}
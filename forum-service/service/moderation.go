@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"errors"
+	pb "forum-service/forum-protos/genprotos"
+	"forum-service/models"
+	"forum-service/moderation"
+	st "forum-service/storage"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ModerationService lets moderators publish or reject content the
+// moderation pipeline held back, work through user reports, mute users and
+// lock, pin or feature posts. Every decision is written to the moderation
+// log. Callers are expected to have checked the moderator role already.
+type ModerationService struct {
+	storage st.Storage
+	pb.UnimplementedModerationServiceServer
+}
+
+func NewModerationService(storage *st.Storage) *ModerationService {
+	return &ModerationService{storage: *storage}
+}
+
+func (s *ModerationService) ApprovePost(ctx context.Context, req *pb.ModerationDecision) (*pb.Void, error) {
+	return s.storage.ModerationS.SetPostStatus(ctx, req, models.StatusPublished)
+}
+
+func (s *ModerationService) RejectPost(ctx context.Context, req *pb.ModerationDecision) (*pb.Void, error) {
+	return s.storage.ModerationS.SetPostStatus(ctx, req, models.StatusRejected)
+}
+
+func (s *ModerationService) ApproveComment(ctx context.Context, req *pb.ModerationDecision) (*pb.Void, error) {
+	return s.storage.ModerationS.SetCommentStatus(ctx, req, models.StatusPublished)
+}
+
+func (s *ModerationService) RejectComment(ctx context.Context, req *pb.ModerationDecision) (*pb.Void, error) {
+	return s.storage.ModerationS.SetCommentStatus(ctx, req, models.StatusRejected)
+}
+
+// GetReports is the moderation queue: open reports, oldest first, unless
+// the filter asks for another status.
+func (s *ModerationService) GetReports(ctx context.Context, req *pb.ReportGAReq) (*pb.ReportGARes, error) {
+	return s.storage.ReportS.GetAll(ctx, req)
+}
+
+func (s *ModerationService) ResolveReport(ctx context.Context, req *pb.ReportResolveReq) (*pb.Void, error) {
+	switch req.Action {
+	case models.ActionDismiss, models.ActionHide, models.ActionDelete, models.ActionWarn:
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "action must be one of %s, %s, %s, %s",
+			models.ActionDismiss, models.ActionHide, models.ActionDelete, models.ActionWarn)
+	}
+	resp, err := s.storage.ModerationS.Resolve(ctx, req)
+	if err == models.ErrReportResolved {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return resp, err
+}
+
+func (s *ModerationService) GetLog(ctx context.Context, req *pb.ModerationLogGAReq) (*pb.ModerationLogGARes, error) {
+	return s.storage.ModerationS.GetLog(ctx, req)
+}
+
+// roleRank orders roles as auth-service does for bans: staff can only mute
+// users ranked below them.
+var roleRank = map[string]int{"user": 0, "moderator": 1, "admin": 2}
+
+// MuteUser makes a user read-only for req.Duration, or for good if it is
+// empty. The gateway names the user's role in req.TargetRole.
+func (s *ModerationService) MuteUser(ctx context.Context, req *pb.MuteReq) (*pb.Void, error) {
+	if req.UserId == "" || req.Reason == "" {
+		return nil, status.Error(codes.InvalidArgument, "user_id and reason are required")
+	}
+	if roleRank[req.TargetRole] >= roleRank[callerFrom(ctx).role] {
+		return nil, status.Error(codes.PermissionDenied, "can't mute a user with the same or a higher role")
+	}
+	var expiresAt *time.Time
+	if req.Duration != "" {
+		d, err := time.ParseDuration(req.Duration)
+		if err != nil || d <= 0 {
+			return nil, status.Error(codes.InvalidArgument, "duration must be a positive length like \"72h\"")
+		}
+		t := time.Now().Add(d)
+		expiresAt = &t
+	}
+	return s.storage.ModerationS.Mute(ctx, req, expiresAt)
+}
+
+func (s *ModerationService) UnmuteUser(ctx context.Context, req *pb.MuteReq) (*pb.Void, error) {
+	res, err := s.storage.ModerationS.Unmute(ctx, req)
+	if err == models.ErrNotMuted {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	return res, err
+}
+
+// LockPost stops or, with Value false, allows new comments on a post.
+func (s *ModerationService) LockPost(ctx context.Context, req *pb.PostFlagReq) (*pb.PostCReqOrCResOrGResOrUResp, error) {
+	return s.setPostFlag(ctx, req, models.FlagLocked, toggleAction(req.Value, models.ActionLock, models.ActionUnlock))
+}
+
+// PinPost keeps a post at the top of its category.
+func (s *ModerationService) PinPost(ctx context.Context, req *pb.PostFlagReq) (*pb.PostCReqOrCResOrGResOrUResp, error) {
+	return s.setPostFlag(ctx, req, models.FlagPinned, toggleAction(req.Value, models.ActionPin, models.ActionUnpin))
+}
+
+func (s *ModerationService) FeaturePost(ctx context.Context, req *pb.PostFlagReq) (*pb.PostCReqOrCResOrGResOrUResp, error) {
+	return s.setPostFlag(ctx, req, models.FlagFeatured, toggleAction(req.Value, models.ActionFeature, models.ActionUnfeature))
+}
+
+func (s *ModerationService) setPostFlag(ctx context.Context, req *pb.PostFlagReq, flag, action string) (*pb.PostCReqOrCResOrGResOrUResp, error) {
+	p, err := s.storage.ModerationS.SetPostFlag(ctx, req, flag, action)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return p, nil
+}
+
+func toggleAction(value bool, on, off string) string {
+	if value {
+		return on
+	}
+	return off
+}
+
+// checkMuted refuses writes from users a moderator made read-only.
+func checkMuted(ctx context.Context, storage st.Storage, userID string) error {
+	muted, err := storage.ModerationS.IsMuted(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if muted {
+		return status.Error(codes.PermissionDenied, "user is muted")
+	}
+	return nil
+}
+
+// moderationErr turns a pipeline rejection into a gRPC status the gateway
+// can show to the author; other errors pass through.
+func moderationErr(err error) error {
+	if errors.Is(err, moderation.ErrRejected) {
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return err
+}
+
+// editStatus is the status an edit leaves content in. An edit can hold
+// published content for review, but held, hidden and rejected content stays
+// that way until a moderator decides.
+func editStatus(current, checked string) string {
+	if current == models.StatusPublished {
+		return checked
+	}
+	return current
+}
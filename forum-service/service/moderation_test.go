@@ -0,0 +1,96 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	pb "forum-service/forum-protos/genprotos"
+	"forum-service/moderation"
+	"forum-service/service"
+	"forum-service/storage"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnmute(t *testing.T) {
+	st := storage.NewMemoryStorage()
+	moderation := service.NewModerationService(st)
+	ctx := context.Background()
+
+	_, err := moderation.MuteUser(as("moderator", "moderator"), &pb.MuteReq{UserId: "user", ModeratorId: "moderator", Reason: "spam", TargetRole: "user"})
+	require.NoError(t, err)
+	_, err = moderation.UnmuteUser(ctx, &pb.MuteReq{UserId: "user", ModeratorId: "moderator"})
+	require.NoError(t, err)
+
+	_, err = moderation.UnmuteUser(ctx, &pb.MuteReq{UserId: "user", ModeratorId: "moderator"})
+	assert.Equal(t, codes.NotFound, status.Code(err), "the user is no longer muted")
+}
+
+func TestMuteNeedsHigherRole(t *testing.T) {
+	moderation := service.NewModerationService(storage.NewMemoryStorage())
+
+	mute := func(callerRole, targetRole string) codes.Code {
+		_, err := moderation.MuteUser(as("staff", callerRole), &pb.MuteReq{UserId: uuid.NewString(), ModeratorId: "staff", Reason: "spam", TargetRole: targetRole})
+		return status.Code(err)
+	}
+	assert.Equal(t, codes.OK, mute("moderator", "user"))
+	assert.Equal(t, codes.OK, mute("admin", "moderator"))
+	assert.Equal(t, codes.PermissionDenied, mute("moderator", "moderator"), "moderators can't mute each other")
+	assert.Equal(t, codes.PermissionDenied, mute("moderator", "admin"))
+	assert.Equal(t, codes.PermissionDenied, mute("admin", "admin"))
+	assert.Equal(t, codes.PermissionDenied, mute("", "user"), "the gateway didn't name the caller's role")
+}
+
+func TestFlagMissingPost(t *testing.T) {
+	moderation := service.NewModerationService(storage.NewMemoryStorage())
+	ctx := context.Background()
+
+	_, err := moderation.LockPost(ctx, &pb.PostFlagReq{PostId: uuid.NewString(), ModeratorId: "moderator", Value: true})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = moderation.PinPost(ctx, &pb.PostFlagReq{PostId: uuid.NewString(), ModeratorId: "moderator", Value: true})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestLockedPostRefusesComments(t *testing.T) {
+	st := storage.NewMemoryStorage()
+	comments := service.NewCommentService(st, moderation.NewPipeline())
+	moderator := service.NewModerationService(st)
+	ctx := context.Background()
+
+	category, err := st.CategoryS.Create(ctx, &pb.CategoryCReqOrCResOrGResOrUReqOrURes{CategoryId: uuid.NewString(), Name: "General"})
+	require.NoError(t, err)
+	post, err := st.PostS.Create(ctx, &pb.PostCReqOrCResOrGResOrUResp{PostId: uuid.NewString(), UserId: "author", Title: "Hello", Body: "Hello", CategoryId: category.CategoryId}, nil)
+	require.NoError(t, err)
+	_, err = moderator.LockPost(ctx, &pb.PostFlagReq{PostId: post.PostId, ModeratorId: "moderator", Value: true})
+	require.NoError(t, err)
+
+	_, err = comments.Create(ctx, &pb.CommentCReqOrCResOrGResOrURes{UserId: "replier", PostId: post.PostId, Body: "Late"})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
+
+func TestMutedCallerCantEdit(t *testing.T) {
+	st := storage.NewMemoryStorage()
+	posts := service.NewPostService(st, nil)
+	moderation := service.NewModerationService(st)
+	ctx := context.Background()
+
+	category, err := st.CategoryS.Create(ctx, &pb.CategoryCReqOrCResOrGResOrUReqOrURes{CategoryId: uuid.NewString(), Name: "General"})
+	require.NoError(t, err)
+	post, err := st.PostS.Create(ctx, &pb.PostCReqOrCResOrGResOrUResp{
+		PostId: uuid.NewString(), UserId: "author", Title: "Hello", Body: "Hello", CategoryId: category.CategoryId, Status: "published",
+	}, nil)
+	require.NoError(t, err)
+	_, err = moderation.MuteUser(as("admin", "admin"), &pb.MuteReq{UserId: "muted", ModeratorId: "admin", Reason: "spam", TargetRole: "moderator"})
+	require.NoError(t, err)
+
+	edit := &pb.PostUReq{PostId: post.PostId, Title: "Hello", Body: "Hello", CategoryId: category.CategoryId, Tags: "#greeting"}
+	_, err = posts.Update(as("muted", "moderator"), edit)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err), "the caller is muted, not the author")
+
+	_, err = posts.Update(as("author", "user"), edit)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"errors"
+	pb "forum-service/forum-protos/genprotos"
+	"forum-service/models"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const maxPollOptions = 20
+
+// CreatePoll lets a post's author attach a poll to it.
+func (s *PostService) CreatePoll(ctx context.Context, req *pb.PollCReq) (*pb.PollRes, error) {
+	req.Question = strings.TrimSpace(req.Question)
+	if req.Question == "" {
+		return nil, status.Error(codes.InvalidArgument, "question is required")
+	}
+	seen := make(map[string]bool, len(req.Options))
+	for i, option := range req.Options {
+		option = strings.TrimSpace(option)
+		if option == "" || seen[option] {
+			return nil, status.Error(codes.InvalidArgument, "options must be distinct and non-empty")
+		}
+		seen[option] = true
+		req.Options[i] = option
+	}
+	if len(req.Options) < 2 || len(req.Options) > maxPollOptions {
+		return nil, status.Errorf(codes.InvalidArgument, "a poll needs between 2 and %d options", maxPollOptions)
+	}
+	var closesAt *time.Time
+	if req.ClosesAt != "" {
+		t, err := time.Parse(time.RFC3339, req.ClosesAt)
+		if err != nil || !t.After(time.Now()) {
+			return nil, status.Error(codes.InvalidArgument, "closes_at must be a future RFC 3339 time")
+		}
+		// Timestamps are stored without a zone, in UTC.
+		t = t.UTC()
+		closesAt = &t
+	}
+
+	if _, err := s.ownPost(ctx, req.PostId, req.UserId); err != nil {
+		return nil, err
+	}
+
+	resp, err := s.storage.PollS.Create(ctx, req, closesAt)
+	if err != nil {
+		return nil, pollErr(err)
+	}
+	return results(resp), nil
+}
+
+// GetPoll shows a poll to whoever can see its post.
+func (s *PostService) GetPoll(ctx context.Context, req *pb.PollGReq) (*pb.PollRes, error) {
+	if err := checkVisible(ctx, s.storage, models.ContentPost, req.PostId); err != nil {
+		return nil, err
+	}
+	resp, err := s.storage.PollS.Get(ctx, req)
+	if err != nil {
+		return nil, pollErr(err)
+	}
+	return results(resp), nil
+}
+
+// VotePoll casts the user's only ballot and returns the updated counts. The
+// post is checked serializably with the vote, so none lands after a
+// moderator locks or hides it.
+func (s *PostService) VotePoll(ctx context.Context, req *pb.PollVoteReq) (*pb.PollRes, error) {
+	err := s.storage.Tx.InTxWith(ctx, serializable, func(ctx context.Context) error {
+		post, err := s.storage.PostS.GetByID(ctx, &pb.PostGReqOrDReq{PostId: req.PostId})
+		if err != nil {
+			return notFound(err)
+		}
+		if post.Status != models.StatusPublished {
+			return errNotVisible(models.ContentPost)
+		}
+		if post.Locked {
+			return status.Error(codes.FailedPrecondition, models.ErrPostLocked.Error())
+		}
+		if err := s.storage.PollS.Vote(ctx, req); err != nil {
+			return pollErr(err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetPoll(ctx, &pb.PollGReq{PostId: req.PostId, UserId: req.UserId})
+}
+
+// ClosePoll lets a post's author end voting early.
+func (s *PostService) ClosePoll(ctx context.Context, req *pb.PollGReq) (*pb.PollRes, error) {
+	if _, err := s.ownPost(ctx, req.PostId, req.UserId); err != nil {
+		return nil, err
+	}
+	if err := s.storage.PollS.Close(ctx, req.PostId); err != nil {
+		return nil, pollErr(err)
+	}
+	return s.GetPoll(ctx, req)
+}
+
+// results blanks the counts of a poll that hides them until the reader has
+// voted or the poll has closed.
+func results(p *pb.PollRes) *pb.PollRes {
+	p.ResultsVisible = !p.HideResults || p.Voted || p.Closed
+	if !p.ResultsVisible {
+		p.Voters = 0
+		for _, o := range p.Options {
+			o.Votes = 0
+		}
+	}
+	return p
+}
+
+func pollErr(err error) error {
+	switch {
+	case errors.Is(err, models.ErrPollNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, models.ErrPollExists), errors.Is(err, models.ErrAlreadyVoted):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, models.ErrPollClosed):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, models.ErrInvalidVote):
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return err
+}
@@ -0,0 +1,44 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	pb "forum-service/forum-protos/genprotos"
+	"forum-service/service"
+	"forum-service/storage"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestPollFollowsItsPost(t *testing.T) {
+	st := storage.NewMemoryStorage()
+	posts := service.NewPostService(st, nil)
+	ctx := context.Background()
+
+	category, err := st.CategoryS.Create(ctx, &pb.CategoryCReqOrCResOrGResOrUReqOrURes{CategoryId: uuid.NewString(), Name: "General"})
+	require.NoError(t, err)
+	held, err := st.PostS.Create(ctx, &pb.PostCReqOrCResOrGResOrUResp{
+		PostId: uuid.NewString(), UserId: "author", Title: "Held", Body: "Held", CategoryId: category.CategoryId, Status: "pending",
+	}, nil)
+	require.NoError(t, err)
+	_, err = posts.CreatePoll(ctx, &pb.PollCReq{PostId: held.PostId, UserId: "author", Question: "Which?", Options: []string{"A", "B"}})
+	require.NoError(t, err)
+	req := &pb.PollGReq{PostId: held.PostId}
+
+	_, err = posts.GetPoll(as("stranger", "user"), req)
+	assert.Equal(t, codes.NotFound, status.Code(err), "a held post's poll is hidden too")
+	_, err = posts.GetPoll(as("author", "user"), req)
+	assert.NoError(t, err)
+	_, err = posts.GetPoll(as("mod", "moderator"), req)
+	assert.NoError(t, err)
+
+	_, err = st.PostS.Delete(ctx, &pb.PostGReqOrDReq{PostId: held.PostId})
+	require.NoError(t, err)
+	_, err = posts.GetPoll(as("author", "user"), req)
+	assert.Equal(t, codes.NotFound, status.Code(err), "a deleted post's poll is gone")
+}
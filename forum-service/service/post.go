@@ -2,20 +2,33 @@ package service
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	pb "forum-service/forum-protos/genprotos"
+	"forum-service/models"
+	"forum-service/moderation"
+	"forum-service/render"
 	st "forum-service/storage"
 
 	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type PostService struct {
-	storage st.Storage
+	storage    st.Storage
+	moderation *moderation.Pipeline
+	renderer   *render.Renderer
 	pb.UnimplementedPostServiceServer
 }
 
-func NewPostService(storage *st.Storage) *PostService {
-	return &PostService{storage: *storage}
+// serializable is for units of work that check something and then write on
+// the strength of it; a concurrent change to what was checked makes one of
+// them fail and be run again.
+var serializable = &sql.TxOptions{Isolation: sql.LevelSerializable}
+
+func NewPostService(storage *st.Storage, pipeline *moderation.Pipeline) *PostService {
+	return &PostService{storage: *storage, moderation: pipeline, renderer: render.NewRenderer(storage.RenderS)}
 }
 
 func (s *PostService) Create(ctx context.Context, post *pb.PostCReqOrCResOrGResOrUResp) (*pb.PostCReqOrCResOrGResOrUResp, error) {
@@ -24,8 +37,35 @@ func (s *PostService) Create(ctx context.Context, post *pb.PostCReqOrCResOrGResO
 	if !valid {
 		return nil, errors.New("invalid tags")
 	}
-	resp, err := s.storage.PostS.Create(post, tags)
 
+	if err := checkMuted(ctx, s.storage, post.UserId); err != nil {
+		return nil, err
+	}
+
+	state, flags, err := s.moderation.Run(ctx, &moderation.Content{
+		Type:   models.ContentPost,
+		UserID: post.UserId,
+		Title:  post.Title,
+		Body:   post.Body,
+	})
+	if err != nil {
+		return nil, moderationErr(err)
+	}
+	post.Status = state
+
+	// A pending post is stored together with the flags explaining it.
+	var resp *pb.PostCReqOrCResOrGResOrUResp
+	err = s.storage.Tx.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		resp, err = s.storage.PostS.Create(ctx, post, tags)
+		if err != nil {
+			return err
+		}
+		if len(flags) > 0 {
+			return s.storage.ModerationS.Flag(ctx, models.ContentPost, resp.PostId, flags)
+		}
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -34,9 +74,21 @@ func (s *PostService) Create(ctx context.Context, post *pb.PostCReqOrCResOrGResO
 }
 
 func (s *PostService) GetByID(ctx context.Context, idReq *pb.PostGReqOrDReq) (*pb.PostCReqOrCResOrGResOrUResp, error) {
-	resp, err := s.storage.PostS.GetByID(idReq)
+	format, err := render.ParseFormat(idReq.Format)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	resp, err := s.storage.PostS.GetByID(ctx, idReq)
 
 	if err != nil {
+		return nil, notFound(err)
+	}
+	if !callerFrom(ctx).canSee(resp.UserId, resp.Status) {
+		return nil, errNotVisible(models.ContentPost)
+	}
+
+	if err := s.render(ctx, format, resp); err != nil {
 		return nil, err
 	}
 
@@ -44,23 +96,91 @@ func (s *PostService) GetByID(ctx context.Context, idReq *pb.PostGReqOrDReq) (*p
 }
 
 func (s *PostService) GetAll(ctx context.Context, allPosts *pb.PostGAReq) (*pb.PostGARes, error) {
-	posts, err := s.storage.PostS.GetAll(allPosts)
+	format, err := render.ParseFormat(allPosts.Format)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	switch allPosts.Filter.Answered {
+	case "", "true", "false":
+	default:
+		return nil, status.Error(codes.InvalidArgument, "answered must be true or false")
+	}
+
+	posts, err := s.storage.PostS.GetAll(ctx, allPosts)
 
 	if err != nil {
 		return nil, err
 	}
 
+	if err := s.render(ctx, format, posts.Posts...); err != nil {
+		return nil, err
+	}
+
 	return posts, nil
 }
 
+// render fills BodyHtml when the caller asked for HTML.
+func (s *PostService) render(ctx context.Context, format string, posts ...*pb.PostCReqOrCResOrGResOrUResp) error {
+	if format != render.FormatHTML {
+		return nil
+	}
+	bodies := make([]render.Body, len(posts))
+	for i, p := range posts {
+		bodies[i] = render.Body{Key: render.Key{ContentID: p.PostId, Revision: p.Revision}, Source: p.Body}
+	}
+	html, err := s.renderer.RenderAll(ctx, models.ContentPost, bodies)
+	if err != nil {
+		return err
+	}
+	for i, p := range posts {
+		p.BodyHtml = html[i]
+	}
+	return nil
+}
+
 func (s *PostService) Update(ctx context.Context, post *pb.PostUReq) (*pb.PostCReqOrCResOrGResOrUResp, error) {
 	valid, tags := ValidateTags(post.Tags)
 	if !valid {
 		return nil, errors.New("invalid tags")
 	}
 
-	resp, err := s.storage.PostS.Update(post, tags)
+	existing, err := s.storage.PostS.GetByID(ctx, &pb.PostGReqOrDReq{PostId: post.PostId})
+	if err != nil {
+		return nil, notFound(err)
+	}
+	// Whoever makes the edit must not be muted, whether the author or staff.
+	if err := checkMuted(ctx, s.storage, callerFrom(ctx).or(existing.UserId)); err != nil {
+		return nil, err
+	}
+
+	// New text goes through the same checks as a new post.
+	state, flags := existing.Status, []models.ModerationFlag(nil)
+	if post.Title != existing.Title || post.Body != existing.Body {
+		checked, f, err := s.moderation.Run(ctx, &moderation.Content{
+			Type:   models.ContentPost,
+			UserID: existing.UserId,
+			Title:  post.Title,
+			Body:   post.Body,
+			Edit:   true,
+		})
+		if err != nil {
+			return nil, moderationErr(err)
+		}
+		state, flags = editStatus(existing.Status, checked), f
+	}
 
+	var resp *pb.PostCReqOrCResOrGResOrUResp
+	err = s.storage.Tx.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		resp, err = s.storage.PostS.Update(ctx, post, tags, state)
+		if err != nil {
+			return err
+		}
+		if len(flags) > 0 {
+			return s.storage.ModerationS.Flag(ctx, models.ContentPost, resp.PostId, flags)
+		}
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -69,6 +189,60 @@ func (s *PostService) Update(ctx context.Context, post *pb.PostUReq) (*pb.PostCR
 }
 
 func (s *PostService) Delete(ctx context.Context, idReq *pb.PostGReqOrDReq) (*pb.Void, error) {
-	_, err := s.storage.PostS.Delete(idReq)
+	_, err := s.storage.PostS.Delete(ctx, idReq)
 	return nil, err
 }
+
+// AcceptAnswer lets the author of a question in a Q&A category mark the
+// comment that solved it. Accepting another comment replaces the answer.
+// The checks and the write run serializably, so a comment hidden or deleted
+// meanwhile is never accepted.
+func (s *PostService) AcceptAnswer(ctx context.Context, req *pb.AcceptAnswerReq) (*pb.PostCReqOrCResOrGResOrUResp, error) {
+	var resp *pb.PostCReqOrCResOrGResOrUResp
+	err := s.storage.Tx.InTxWith(ctx, serializable, func(ctx context.Context) error {
+		post, err := s.ownPost(ctx, req.PostId, req.UserId)
+		if err != nil {
+			return err
+		}
+		category, err := s.storage.CategoryS.GetByID(ctx, &pb.CategoryGReqOrDReq{CategoryId: post.CategoryId})
+		if err != nil {
+			return err
+		}
+		if !category.Qa {
+			return status.Error(codes.FailedPrecondition, "answers can only be accepted in Q&A categories")
+		}
+		comment, err := s.storage.CommentS.GetByID(ctx, &pb.CommentGReqOrDReq{CommentId: req.CommentId})
+		if err != nil {
+			return notFound(err)
+		}
+		if comment.PostId != post.PostId || comment.Status != models.StatusPublished {
+			return status.Error(codes.InvalidArgument, "comment is not a published reply to this post")
+		}
+
+		resp, err = s.storage.PostS.SetAcceptedAnswer(ctx, post.PostId, comment.CommentId)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (s *PostService) UnacceptAnswer(ctx context.Context, req *pb.AcceptAnswerReq) (*pb.PostCReqOrCResOrGResOrUResp, error) {
+	if _, err := s.ownPost(ctx, req.PostId, req.UserId); err != nil {
+		return nil, err
+	}
+	return s.storage.PostS.SetAcceptedAnswer(ctx, req.PostId, "")
+}
+
+// ownPost loads a post, refusing anyone but its author.
+func (s *PostService) ownPost(ctx context.Context, postID, userID string) (*pb.PostCReqOrCResOrGResOrUResp, error) {
+	post, err := s.storage.PostS.GetByID(ctx, &pb.PostGReqOrDReq{PostId: postID})
+	if err != nil {
+		return nil, notFound(err)
+	}
+	if post.UserId != userID {
+		return nil, status.Error(codes.PermissionDenied, "only the post author can do this")
+	}
+	return post, nil
+}
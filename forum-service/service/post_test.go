@@ -0,0 +1,52 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "forum-service/forum-protos/genprotos"
+	"forum-service/moderation"
+	"forum-service/service"
+	"forum-service/storage"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEditsAreModerated(t *testing.T) {
+	st := storage.NewMemoryStorage()
+	pipeline := moderation.NewPipeline(
+		moderation.NewPostingRate(st.ModerationS, 2, time.Hour),
+		moderation.NewBannedWords([]string{"casino"}),
+	)
+	posts := service.NewPostService(st, pipeline)
+	comments := service.NewCommentService(st, pipeline)
+	ctx := context.Background()
+
+	category, err := st.CategoryS.Create(ctx, &pb.CategoryCReqOrCResOrGResOrUReqOrURes{CategoryId: uuid.NewString(), Name: "General"})
+	require.NoError(t, err)
+	post, err := posts.Create(ctx, &pb.PostCReqOrCResOrGResOrUResp{UserId: "author", Title: "Hello", Body: "Hello", CategoryId: category.CategoryId, Tags: "#greeting"})
+	require.NoError(t, err)
+	require.Equal(t, "published", post.Status)
+	comment, err := comments.Create(ctx, &pb.CommentCReqOrCResOrGResOrURes{UserId: "author", PostId: post.PostId, Body: "First"})
+	require.NoError(t, err)
+	require.Equal(t, "published", comment.Status)
+
+	edited, err := posts.Update(ctx, &pb.PostUReq{PostId: post.PostId, Title: "Hello", Body: "Hello again", CategoryId: category.CategoryId, Tags: "#greeting"})
+	require.NoError(t, err)
+	assert.Equal(t, "published", edited.Status, "edits don't count against the posting rate")
+
+	edited, err = posts.Update(ctx, &pb.PostUReq{PostId: post.PostId, Title: "Hello", Body: "Visit my casino", CategoryId: category.CategoryId, Tags: "#greeting"})
+	require.NoError(t, err)
+	assert.Equal(t, "pending", edited.Status, "flagged edits are held")
+
+	edited, err = posts.Update(ctx, &pb.PostUReq{PostId: post.PostId, Title: "Hello", Body: "Hello", CategoryId: category.CategoryId, Tags: "#greeting"})
+	require.NoError(t, err)
+	assert.Equal(t, "pending", edited.Status, "only a moderator releases held posts")
+
+	editedComment, err := comments.Update(ctx, &pb.CommentUReq{CommentId: comment.CommentId, Body: "casino"})
+	require.NoError(t, err)
+	assert.Equal(t, "pending", editedComment.Status)
+}
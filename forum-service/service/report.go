@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	pb "forum-service/forum-protos/genprotos"
+	"forum-service/models"
+	st "forum-service/storage"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type ReportService struct {
+	storage       st.Storage
+	hideThreshold int
+	pb.UnimplementedReportServiceServer
+}
+
+// NewReportService hides content once it has hideThreshold open reports;
+// zero disables auto-hiding.
+func NewReportService(storage *st.Storage, hideThreshold int) *ReportService {
+	return &ReportService{storage: *storage, hideThreshold: hideThreshold}
+}
+
+func (s *ReportService) Create(ctx context.Context, req *pb.ReportCReq) (*pb.ReportRes, error) {
+	if req.ContentType != models.ContentPost && req.ContentType != models.ContentComment {
+		return nil, status.Errorf(codes.InvalidArgument, "content_type must be %q or %q", models.ContentPost, models.ContentComment)
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Reason == "" {
+		return nil, status.Error(codes.InvalidArgument, "reason is required")
+	}
+	// Content the reporter can't see can't be reported either, or reports
+	// would tell held content apart from missing content.
+	if err := checkVisible(ctx, s.storage, req.ContentType, req.ContentId); err != nil {
+		return nil, err
+	}
+
+	// The report and the hiding it triggers are stored together.
+	var resp *pb.ReportRes
+	err := s.storage.Tx.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		resp, err = s.storage.ReportS.Create(ctx, req)
+		if err != nil || s.hideThreshold <= 0 {
+			return err
+		}
+		count, err := s.storage.ReportS.CountOpen(ctx, req.ContentType, req.ContentId)
+		if err != nil || count < s.hideThreshold {
+			return err
+		}
+		_, err = s.storage.ModerationS.AutoHide(ctx, req.ContentType, req.ContentId)
+		return err
+	})
+	if err == models.ErrAlreadyReported {
+		return nil, status.Error(codes.AlreadyExists, err.Error())
+	}
+	if err != nil {
+		return nil, notFound(err)
+	}
+
+	return resp, nil
+}
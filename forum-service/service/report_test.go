@@ -0,0 +1,86 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	pb "forum-service/forum-protos/genprotos"
+	"forum-service/models"
+	"forum-service/service"
+	"forum-service/storage"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// failingAutoHide is a moderation store whose auto-hide always fails.
+type failingAutoHide struct {
+	storage.ModerationI
+}
+
+func (failingAutoHide) AutoHide(context.Context, string, string) (bool, error) {
+	return false, errors.New("auto-hide failed")
+}
+
+func TestReportsHideContent(t *testing.T) {
+	st := storage.NewMemoryStorage()
+	reports := service.NewReportService(st, 2)
+	ctx := context.Background()
+
+	category, err := st.CategoryS.Create(ctx, &pb.CategoryCReqOrCResOrGResOrUReqOrURes{CategoryId: uuid.NewString(), Name: "General"})
+	require.NoError(t, err)
+	post, err := st.PostS.Create(ctx, &pb.PostCReqOrCResOrGResOrUResp{
+		PostId: uuid.NewString(), UserId: "author", Title: "Spam", Body: "Spam", CategoryId: category.CategoryId, Status: "published",
+	}, nil)
+	require.NoError(t, err)
+	report := func(reports *service.ReportService, reporter string) error {
+		_, err := reports.Create(ctx, &pb.ReportCReq{ContentType: models.ContentPost, ContentId: post.PostId, ReporterId: reporter, Reason: "spam"})
+		return err
+	}
+	open := func() int {
+		n, err := st.ReportS.CountOpen(ctx, models.ContentPost, post.PostId)
+		require.NoError(t, err)
+		return n
+	}
+
+	require.NoError(t, report(reports, "reader1"))
+
+	// Reaching the threshold hides the post in the same transaction, so a
+	// failure there drops the report too.
+	failing := *st
+	failing.ModerationS = failingAutoHide{st.ModerationS}
+	assert.Error(t, report(service.NewReportService(&failing, 2), "reader2"))
+	assert.Equal(t, 1, open(), "the report was rolled back")
+
+	require.NoError(t, report(reports, "reader2"))
+	assert.Equal(t, 2, open())
+	got, err := st.PostS.GetByID(ctx, &pb.PostGReqOrDReq{PostId: post.PostId})
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusHidden, got.Status)
+}
+
+func TestReportsNeedVisibleContent(t *testing.T) {
+	st := storage.NewMemoryStorage()
+	reports := service.NewReportService(st, 0)
+	ctx := context.Background()
+
+	category, err := st.CategoryS.Create(ctx, &pb.CategoryCReqOrCResOrGResOrUReqOrURes{CategoryId: uuid.NewString(), Name: "General"})
+	require.NoError(t, err)
+	held, err := st.PostS.Create(ctx, &pb.PostCReqOrCResOrGResOrUResp{
+		PostId: uuid.NewString(), UserId: "author", Title: "Held", Body: "Held", CategoryId: category.CategoryId, Status: "pending",
+	}, nil)
+	require.NoError(t, err)
+	report := func(ctx context.Context, contentID string) error {
+		_, err := reports.Create(ctx, &pb.ReportCReq{ContentType: models.ContentPost, ContentId: contentID, ReporterId: "reader", Reason: "spam"})
+		return err
+	}
+
+	// Held and missing content look the same to other users.
+	assert.Equal(t, codes.NotFound, status.Code(report(as("reader", "user"), held.PostId)))
+	assert.Equal(t, codes.NotFound, status.Code(report(as("reader", "user"), uuid.NewString())))
+	assert.NoError(t, report(as("mod", "moderator"), held.PostId))
+}
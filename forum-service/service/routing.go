@@ -0,0 +1,30 @@
+package service
+
+import (
+	"context"
+	"path"
+	"strings"
+
+	st "forum-service/storage"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// ReadPrimaryHeader is the metadata key a client sets to read from the
+// primary, e.g. right after its own write, when replicas may lag behind. The
+// gateway sets it for READ_PRIMARY_WINDOW after each user's writes.
+const ReadPrimaryHeader = "x-read-primary"
+
+// ReadRouting lets only plain reads, the Get methods, go to read replicas.
+// Everything else may write, and whatever it reads before writing, such as
+// the post whose owner it checks, must be current, so it uses the primary.
+func ReadRouting() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		if !strings.HasPrefix(path.Base(info.FullMethod), "Get") || len(md.Get(ReadPrimaryHeader)) > 0 {
+			ctx = st.WithPrimary(ctx)
+		}
+		return handler(ctx, req)
+	}
+}
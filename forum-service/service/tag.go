@@ -17,7 +17,7 @@ func NewTagService(storage *st.Storage) *TagService {
 }
 
 func (s *TagService) GetPopular(ctx context.Context, req *pb.Pagination) (*pb.TagPopularRes, error) {
-	return s.storage.TagS.GetPopular(req)
+	return s.storage.TagS.GetPopular(ctx, req)
 }
 
 func ValidateTags(tags string) (bool, []string) {
@@ -0,0 +1,235 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"forum-service/cache"
+	pb "forum-service/forum-protos/genprotos"
+	"forum-service/models"
+	managers "forum-service/storage/postgres"
+)
+
+// WithCache reads single posts, category lists and popular tags through c.
+// Post writes, comments and moderation of a post drop its cached copy;
+// category and tag writes start new generations of the lists. Reads that
+// must see current data, in a unit of work or after WithPrimary, skip the
+// cache. Values are dropped once the write that changed them commits. A
+// load shared by several reads gets up to timeout.
+func (s *Storage) WithCache(c cache.Cache, ttl, timeout time.Duration) {
+	i := invalidator{l: cache.NewLoader(c, ttl, timeout), tx: s.Tx}
+	s.PostS = &cachedPosts{PostI: s.PostS, invalidator: i}
+	s.CategoryS = &cachedCategories{CategoryI: s.CategoryS, invalidator: i}
+	s.TagS = &cachedTags{TagI: s.TagS, invalidator: i}
+	s.ModerationS = &moderationForgettingPosts{ModerationI: s.ModerationS, invalidator: i, reports: s.ReportS, comments: s.CommentS}
+	s.CommentS = &commentsForgettingPosts{CommentI: s.CommentS, invalidator: i}
+}
+
+// invalidator drops cached values after the unit of work that changed them
+// commits. Dropping them sooner would let a read between the write and the
+// commit cache the old row again.
+type invalidator struct {
+	l  *cache.Loader
+	tx TransactorI
+}
+
+// fresh tells whether reads with ctx must skip the cache: they run in a
+// unit of work, on any backend, or asked for the primary.
+func (i invalidator) fresh(ctx context.Context) bool {
+	return i.tx.InUnit(ctx) || managers.UsesPrimary(ctx)
+}
+
+func (i invalidator) forget(ctx context.Context, keys ...string) {
+	i.tx.AfterCommit(ctx, func() { i.l.Forget(context.WithoutCancel(ctx), keys...) })
+}
+
+func (i invalidator) bump(ctx context.Context, family string) {
+	i.tx.AfterCommit(ctx, func() { i.l.Bump(context.WithoutCancel(ctx), family) })
+}
+
+func postKey(id string) string {
+	return "post:" + id
+}
+
+type cachedPosts struct {
+	PostI
+	invalidator
+}
+
+func (p *cachedPosts) GetByID(ctx context.Context, req *pb.PostGReqOrDReq) (*pb.PostCReqOrCResOrGResOrUResp, error) {
+	if p.fresh(ctx) {
+		return p.PostI.GetByID(ctx, req)
+	}
+	return cache.Load(ctx, p.l, "post", postKey(req.PostId), func(ctx context.Context) (*pb.PostCReqOrCResOrGResOrUResp, error) {
+		return p.PostI.GetByID(ctx, req)
+	})
+}
+
+func (p *cachedPosts) Update(ctx context.Context, req *pb.PostUReq, tags []string, status string) (*pb.PostCReqOrCResOrGResOrUResp, error) {
+	defer p.forget(ctx, postKey(req.PostId))
+	return p.PostI.Update(ctx, req, tags, status)
+}
+
+func (p *cachedPosts) Delete(ctx context.Context, req *pb.PostGReqOrDReq) (*pb.Void, error) {
+	defer p.forget(ctx, postKey(req.PostId))
+	return p.PostI.Delete(ctx, req)
+}
+
+func (p *cachedPosts) SetAcceptedAnswer(ctx context.Context, postID, commentID string) (*pb.PostCReqOrCResOrGResOrUResp, error) {
+	defer p.forget(ctx, postKey(postID))
+	return p.PostI.SetAcceptedAnswer(ctx, postID, commentID)
+}
+
+type cachedCategories struct {
+	CategoryI
+	invalidator
+}
+
+func (c *cachedCategories) GetAll(ctx context.Context, req *pb.CategoryGAReq) (*pb.CategoryGARes, error) {
+	if c.fresh(ctx) {
+		return c.CategoryI.GetAll(ctx, req)
+	}
+	params, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	key := "categories:" + c.l.Generation(ctx, "categories") + ":" + string(params)
+	return cache.Load(ctx, c.l, "categories", key, func(ctx context.Context) (*pb.CategoryGARes, error) {
+		return c.CategoryI.GetAll(ctx, req)
+	})
+}
+
+func (c *cachedCategories) Create(ctx context.Context, req *pb.CategoryCReqOrCResOrGResOrUReqOrURes) (*pb.CategoryCReqOrCResOrGResOrUReqOrURes, error) {
+	defer c.bump(ctx, "categories")
+	return c.CategoryI.Create(ctx, req)
+}
+
+func (c *cachedCategories) Update(ctx context.Context, req *pb.CategoryUReq) (*pb.CategoryCReqOrCResOrGResOrUReqOrURes, error) {
+	defer c.bump(ctx, "categories")
+	return c.CategoryI.Update(ctx, req)
+}
+
+func (c *cachedCategories) Delete(ctx context.Context, req *pb.CategoryGReqOrDReq) (*pb.Void, error) {
+	defer c.bump(ctx, "categories")
+	return c.CategoryI.Delete(ctx, req)
+}
+
+type cachedTags struct {
+	TagI
+	invalidator
+}
+
+func (t *cachedTags) GetPopular(ctx context.Context, req *pb.Pagination) (*pb.TagPopularRes, error) {
+	if t.fresh(ctx) {
+		return t.TagI.GetPopular(ctx, req)
+	}
+	params, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	key := "tags:" + t.l.Generation(ctx, "tags") + ":" + string(params)
+	return cache.Load(ctx, t.l, "popular_tags", key, func(ctx context.Context) (*pb.TagPopularRes, error) {
+		return t.TagI.GetPopular(ctx, req)
+	})
+}
+
+func (t *cachedTags) Create(ctx context.Context, req *pb.TagCReqOrCRes) (*pb.TagCReqOrCRes, error) {
+	defer t.bump(ctx, "tags")
+	return t.TagI.Create(ctx, req)
+}
+
+func (t *cachedTags) Delete(ctx context.Context, req *pb.TagGReqOrDReq) (*pb.Void, error) {
+	defer t.bump(ctx, "tags")
+	return t.TagI.Delete(ctx, req)
+}
+
+// commentsForgettingPosts drops a post's cached copy when its comment count
+// may have changed.
+type commentsForgettingPosts struct {
+	CommentI
+	invalidator
+}
+
+func (c *commentsForgettingPosts) Create(ctx context.Context, req *pb.CommentCReqOrCResOrGResOrURes) (*pb.CommentCReqOrCResOrGResOrURes, error) {
+	defer c.forget(ctx, postKey(req.PostId))
+	return c.CommentI.Create(ctx, req)
+}
+
+// Update can hold a published comment, which changes its post's count.
+func (c *commentsForgettingPosts) Update(ctx context.Context, req *pb.CommentUReq, status string) (*pb.CommentCReqOrCResOrGResOrURes, error) {
+	resp, err := c.CommentI.Update(ctx, req, status)
+	if err == nil {
+		c.forget(ctx, postKey(resp.PostId))
+	}
+	return resp, err
+}
+
+func (c *commentsForgettingPosts) Delete(ctx context.Context, req *pb.CommentGReqOrDReq) (*pb.Void, error) {
+	// The request only names the comment; its post is looked up first.
+	if comment, err := c.CommentI.GetByID(ctx, req); err == nil {
+		defer c.forget(ctx, postKey(comment.PostId))
+	}
+	return c.CommentI.Delete(ctx, req)
+}
+
+func (c *commentsForgettingPosts) DeleteByPostID(ctx context.Context, req *pb.CommentGReqOrDReqByPostID) (*pb.Void, error) {
+	defer c.forget(ctx, postKey(req.PostId))
+	return c.CommentI.DeleteByPostID(ctx, req)
+}
+
+// moderationForgettingPosts drops a post's cached copy when moderation
+// changes it, or changes which of its comments count.
+type moderationForgettingPosts struct {
+	ModerationI
+	invalidator
+	reports  ReportI
+	comments CommentI
+}
+
+// postOf returns the post content is or replies to. It is looked up before
+// the change, which may delete the content.
+func (m *moderationForgettingPosts) postOf(ctx context.Context, contentType, contentID string) (string, bool) {
+	if contentType == models.ContentPost {
+		return contentID, true
+	}
+	comment, err := m.comments.GetByID(managers.WithPrimary(ctx), &pb.CommentGReqOrDReq{CommentId: contentID})
+	if err != nil {
+		return "", false
+	}
+	return comment.PostId, true
+}
+
+func (m *moderationForgettingPosts) SetPostStatus(ctx context.Context, req *pb.ModerationDecision, status string) (*pb.Void, error) {
+	defer m.forget(ctx, postKey(req.Id))
+	return m.ModerationI.SetPostStatus(ctx, req, status)
+}
+
+func (m *moderationForgettingPosts) SetCommentStatus(ctx context.Context, req *pb.ModerationDecision, status string) (*pb.Void, error) {
+	if postID, ok := m.postOf(ctx, models.ContentComment, req.Id); ok {
+		defer m.forget(ctx, postKey(postID))
+	}
+	return m.ModerationI.SetCommentStatus(ctx, req, status)
+}
+
+func (m *moderationForgettingPosts) AutoHide(ctx context.Context, contentType, contentID string) (bool, error) {
+	if postID, ok := m.postOf(ctx, contentType, contentID); ok {
+		defer m.forget(ctx, postKey(postID))
+	}
+	return m.ModerationI.AutoHide(ctx, contentType, contentID)
+}
+
+// Resolve only names the report; the content behind it is looked up first.
+func (m *moderationForgettingPosts) Resolve(ctx context.Context, req *pb.ReportResolveReq) (*pb.Void, error) {
+	if report, err := m.reports.GetByID(ctx, req.ReportId); err == nil {
+		if postID, ok := m.postOf(ctx, report.ContentType, report.ContentId); ok {
+			defer m.forget(ctx, postKey(postID))
+		}
+	}
+	return m.ModerationI.Resolve(ctx, req)
+}
+
+func (m *moderationForgettingPosts) SetPostFlag(ctx context.Context, req *pb.PostFlagReq, flag, action string) (*pb.PostCReqOrCResOrGResOrUResp, error) {
+	defer m.forget(ctx, postKey(req.PostId))
+	return m.ModerationI.SetPostFlag(ctx, req, flag, action)
+}
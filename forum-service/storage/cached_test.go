@@ -0,0 +1,88 @@
+package storage_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"forum-service/cache"
+	pb "forum-service/forum-protos/genprotos"
+	"forum-service/storage"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCacheForgetsAfterCommit(t *testing.T) {
+	fmt.Println("Testing cache invalidation inside a unit of work...")
+	ctx := context.Background()
+	c := cache.NewLRU(10)
+	st := storage.NewMemoryStorage()
+	st.WithCache(c, time.Minute, time.Second)
+
+	category, err := st.CategoryS.Create(ctx, &pb.CategoryCReqOrCResOrGResOrUReqOrURes{CategoryId: uuid.NewString(), Name: "Cached"})
+	require.NoError(t, err)
+	post, err := st.PostS.Create(ctx, &pb.PostCReqOrCResOrGResOrUResp{PostId: uuid.NewString(), UserId: "author", Title: "Cached", Body: "Old", CategoryId: category.CategoryId}, nil)
+	require.NoError(t, err)
+	_, err = st.PostS.GetByID(ctx, &pb.PostGReqOrDReq{PostId: post.PostId})
+	require.NoError(t, err)
+	cached := func() bool {
+		_, ok, err := c.Get(ctx, "post:"+post.PostId)
+		require.NoError(t, err)
+		return ok
+	}
+	require.True(t, cached())
+
+	err = st.Tx.InTx(ctx, func(ctx context.Context) error {
+		_, err := st.PostS.Update(ctx, &pb.PostUReq{PostId: post.PostId, Title: "Cached", Body: "New", CategoryId: category.CategoryId}, nil, "published")
+		assert.True(t, cached(), "the copy stays until the update commits")
+		if err != nil {
+			return err
+		}
+		got, err := st.PostS.GetByID(ctx, &pb.PostGReqOrDReq{PostId: post.PostId})
+		require.NoError(t, err)
+		assert.Equal(t, "New", got.Body, "reads in the unit skip the cache")
+		return nil
+	})
+	require.NoError(t, err)
+	assert.False(t, cached(), "the commit drops the copy")
+
+	got, err := st.PostS.GetByID(ctx, &pb.PostGReqOrDReq{PostId: post.PostId})
+	require.NoError(t, err)
+	assert.Equal(t, "New", got.Body)
+	fmt.Println("OK. The cached copy was dropped after the commit.")
+}
+
+func TestCacheForgetsModeratedPosts(t *testing.T) {
+	fmt.Println("Testing cache invalidation by moderation...")
+	ctx := context.Background()
+	c := cache.NewLRU(10)
+	st := storage.NewMemoryStorage()
+	st.WithCache(c, time.Minute, time.Second)
+
+	category, err := st.CategoryS.Create(ctx, &pb.CategoryCReqOrCResOrGResOrUReqOrURes{CategoryId: uuid.NewString(), Name: "Moderated"})
+	require.NoError(t, err)
+	post, err := st.PostS.Create(ctx, &pb.PostCReqOrCResOrGResOrUResp{PostId: uuid.NewString(), UserId: "author", Title: "Post", Body: "Body", CategoryId: category.CategoryId}, nil)
+	require.NoError(t, err)
+	comment, err := st.CommentS.Create(ctx, &pb.CommentCReqOrCResOrGResOrURes{CommentId: uuid.NewString(), UserId: "replier", PostId: post.PostId, Body: "Reply"})
+	require.NoError(t, err)
+	commentCount := func() int64 {
+		got, err := st.PostS.GetByID(ctx, &pb.PostGReqOrDReq{PostId: post.PostId})
+		require.NoError(t, err)
+		return got.CommentCount
+	}
+	require.Equal(t, int64(1), commentCount())
+
+	report, err := st.ReportS.Create(ctx, &pb.ReportCReq{ContentType: "comment", ContentId: comment.CommentId, ReporterId: "reader", Reason: "spam"})
+	require.NoError(t, err)
+	_, err = st.ModerationS.Resolve(ctx, &pb.ReportResolveReq{ReportId: report.ReportId, Action: "hide"})
+	require.NoError(t, err)
+	assert.Zero(t, commentCount(), "hiding a reported comment drops its post's copy")
+
+	_, err = st.ModerationS.SetCommentStatus(ctx, &pb.ModerationDecision{Id: comment.CommentId}, "published")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), commentCount(), "approving a comment drops its post's copy")
+	fmt.Println("OK. Moderating a comment dropped its post's copy.")
+}
@@ -0,0 +1,27 @@
+package storage
+
+import (
+	"log"
+
+	"forum-service/storage/memory"
+)
+
+// NewMemoryStorage keeps everything in process memory, for tests and local
+// runs. It has no Db.
+func NewMemoryStorage() *Storage {
+	store := memory.NewStore()
+	log.Println("Using in-memory storage; data is lost on exit")
+	return &Storage{
+		Tx:          store,
+		PostS:       memory.NewPostManager(store),
+		CategoryS:   memory.NewCategoryManager(store),
+		TagS:        memory.NewTagManager(store),
+		CommentS:    memory.NewCommentManager(store),
+		ModerationS: memory.NewModerationManager(store),
+		ReportS:     memory.NewReportManager(store),
+		RenderS:     memory.NewRenderManager(store),
+		AttachmentS: memory.NewAttachmentManager(store),
+		PollS:       memory.NewPollManager(store),
+		CounterS:    memory.NewCounterManager(store),
+	}
+}
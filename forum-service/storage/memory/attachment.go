@@ -0,0 +1,235 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	pb "forum-service/forum-protos/genprotos"
+	"forum-service/models"
+	"slices"
+	"time"
+)
+
+type attachment struct {
+	id, contentType, contentID, userID, filename, mimeType string
+	size                                                   int64
+	blobKey                                                string
+	processingStatus, lastError                            string
+	width, height                                          int32
+	attempts                                               int
+	nextAttemptAt                                          time.Time
+	createdAt                                              time.Time
+	seq                                                    int64
+	deletedAt                                              int64
+}
+
+func (a attachment) pb() *pb.AttachmentRes {
+	return &pb.AttachmentRes{
+		AttachmentId:     a.id,
+		ContentType:      a.contentType,
+		ContentId:        a.contentID,
+		UserId:           a.userID,
+		Filename:         a.filename,
+		MimeType:         a.mimeType,
+		Size:             a.size,
+		ProcessingStatus: a.processingStatus,
+		Width:            a.width,
+		Height:           a.height,
+		CreatedAt:        a.createdAt.Format(time.RFC3339),
+	}
+}
+
+type AttachmentManager struct {
+	s *Store
+}
+
+func NewAttachmentManager(s *Store) *AttachmentManager {
+	return &AttachmentManager{s: s}
+}
+
+// Create records an attachment whose bytes are already stored under blobKey.
+func (m *AttachmentManager) Create(ctx context.Context, req *pb.AttachmentRes, blobKey string) (*pb.AttachmentRes, error) {
+	unlock, err := m.s.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if _, ok := m.s.data.attachments[req.AttachmentId]; ok {
+		return nil, fmt.Errorf("attachment %s already exists", req.AttachmentId)
+	}
+	processing := req.ProcessingStatus
+	if processing == "" {
+		processing = models.ProcessingSkipped
+	}
+	now := time.Now()
+	a := attachment{
+		id:               req.AttachmentId,
+		contentType:      req.ContentType,
+		contentID:        req.ContentId,
+		userID:           req.UserId,
+		filename:         req.Filename,
+		mimeType:         req.MimeType,
+		size:             req.Size,
+		blobKey:          blobKey,
+		processingStatus: processing,
+		nextAttemptAt:    now,
+		createdAt:        now,
+		seq:              m.s.next(),
+	}
+	m.s.data.attachments[a.id] = a
+	return a.pb(), nil
+}
+
+// GetByID returns an attachment and the key of its bytes.
+func (m *AttachmentManager) GetByID(ctx context.Context, req *pb.AttachmentGReqOrDReq) (*pb.AttachmentRes, string, error) {
+	unlock, err := m.s.lock(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	defer unlock()
+
+	a, ok := m.s.data.attachments[req.AttachmentId]
+	if !ok || a.deletedAt != 0 {
+		return nil, "", fmt.Errorf("attachment not found")
+	}
+	return a.pb(), a.blobKey, nil
+}
+
+func (m *AttachmentManager) GetAll(ctx context.Context, req *pb.AttachmentGAReq) (*pb.AttachmentGARes, error) {
+	unlock, err := m.s.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	var matched []attachment
+	for _, a := range m.s.data.attachments {
+		if a.contentType == req.ContentType && a.contentID == req.ContentId && a.deletedAt == 0 {
+			matched = append(matched, a)
+		}
+	}
+	slices.SortFunc(matched, func(a, b attachment) int { return cmp.Compare(a.seq, b.seq) })
+
+	attachments := &pb.AttachmentGARes{}
+	for _, a := range matched {
+		attachments.Attachments = append(attachments.Attachments, a.pb())
+		attachments.Count++
+	}
+	return attachments, nil
+}
+
+func (m *AttachmentManager) Delete(ctx context.Context, req *pb.AttachmentGReqOrDReq) (*pb.Void, error) {
+	unlock, err := m.s.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	a, ok := m.s.data.attachments[req.AttachmentId]
+	if !ok || a.deletedAt != 0 {
+		return nil, fmt.Errorf("attachment not found")
+	}
+	a.deletedAt = deletedAt()
+	m.s.data.attachments[a.id] = a
+	return &pb.Void{}, nil
+}
+
+// GetVariants lists the stored renditions of an image, smallest first.
+func (m *AttachmentManager) GetVariants(ctx context.Context, attachmentID string) ([]models.AttachmentVariant, error) {
+	unlock, err := m.s.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	variants := slices.Clone(m.s.data.variants[attachmentID])
+	slices.SortStableFunc(variants, func(a, b models.AttachmentVariant) int {
+		return cmp.Compare(a.Width*a.Height, b.Width*b.Height)
+	})
+	return variants, nil
+}
+
+// ClaimImage takes the next image due for processing and leases it for lease;
+// a worker that dies mid-job leaves it to be claimed again once the lease
+// runs out. It returns nil when nothing is due.
+func (m *AttachmentManager) ClaimImage(ctx context.Context, lease time.Duration) (*pb.AttachmentRes, string, int, error) {
+	unlock, err := m.s.lock(ctx)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	defer unlock()
+
+	now := time.Now()
+	var due *attachment
+	for _, a := range m.s.data.attachments {
+		if a.deletedAt != 0 || a.nextAttemptAt.After(now) ||
+			(a.processingStatus != models.ProcessingPending && a.processingStatus != models.ProcessingInProgress) {
+			continue
+		}
+		if due == nil || a.nextAttemptAt.Before(due.nextAttemptAt) {
+			due = &a
+		}
+	}
+	if due == nil {
+		return nil, "", 0, nil
+	}
+	a := *due
+	a.processingStatus = models.ProcessingInProgress
+	a.attempts++
+	a.nextAttemptAt = now.Add(lease)
+	m.s.data.attachments[a.id] = a
+	return a.pb(), a.blobKey, a.attempts, nil
+}
+
+// SaveVariants records a processed image: the cleaned original replaces the
+// upload's type, size and dimensions, and variants replace any earlier ones.
+func (m *AttachmentManager) SaveVariants(ctx context.Context, attachmentID string, original models.AttachmentVariant, variants []models.AttachmentVariant) error {
+	unlock, err := m.s.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	m.s.data.variants[attachmentID] = slices.Clone(variants)
+	if a, ok := m.s.data.attachments[attachmentID]; ok {
+		a.processingStatus = models.ProcessingReady
+		a.mimeType, a.size = original.MimeType, original.Size
+		a.width, a.height = int32(original.Width), int32(original.Height)
+		a.lastError = ""
+		m.s.data.attachments[a.id] = a
+	}
+	return nil
+}
+
+// RetryProcessing records a failed attempt and schedules the next one after
+// delay.
+func (m *AttachmentManager) RetryProcessing(ctx context.Context, attachmentID, reason string, delay time.Duration) error {
+	unlock, err := m.s.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if a, ok := m.s.data.attachments[attachmentID]; ok {
+		a.processingStatus, a.lastError = models.ProcessingPending, reason
+		a.nextAttemptAt = time.Now().Add(delay)
+		m.s.data.attachments[a.id] = a
+	}
+	return nil
+}
+
+// FailProcessing gives up on an image after its last attempt.
+func (m *AttachmentManager) FailProcessing(ctx context.Context, attachmentID, reason string) error {
+	unlock, err := m.s.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if a, ok := m.s.data.attachments[attachmentID]; ok {
+		a.processingStatus, a.lastError = models.ProcessingFailed, reason
+		m.s.data.attachments[a.id] = a
+	}
+	return nil
+}
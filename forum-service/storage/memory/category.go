@@ -0,0 +1,119 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+	pb "forum-service/forum-protos/genprotos"
+	"slices"
+)
+
+type category struct {
+	id, name  string
+	qa        bool
+	seq       int64
+	deletedAt int64
+}
+
+func (c category) pb() *pb.CategoryCReqOrCResOrGResOrUReqOrURes {
+	return &pb.CategoryCReqOrCResOrGResOrUReqOrURes{CategoryId: c.id, Name: c.name, Qa: c.qa}
+}
+
+type CategoryManager struct {
+	s *Store
+}
+
+func NewCategoryManager(s *Store) *CategoryManager {
+	return &CategoryManager{s: s}
+}
+
+func (m *CategoryManager) Create(ctx context.Context, req *pb.CategoryCReqOrCResOrGResOrUReqOrURes) (*pb.CategoryCReqOrCResOrGResOrUReqOrURes, error) {
+	unlock, err := m.s.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if _, ok := m.s.data.categories[req.CategoryId]; ok {
+		return nil, fmt.Errorf("category %s already exists", req.CategoryId)
+	}
+	c := category{id: req.CategoryId, name: req.Name, qa: req.Qa, seq: m.s.next()}
+	m.s.data.categories[c.id] = c
+	return c.pb(), nil
+}
+
+// Update renames a category, deleted or not, and sets its qa flag if the
+// request has one. Like the SQL update it returns sql.ErrNoRows for a
+// category that does not exist.
+func (m *CategoryManager) Update(ctx context.Context, req *pb.CategoryUReq) (*pb.CategoryCReqOrCResOrGResOrUReqOrURes, error) {
+	unlock, err := m.s.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	c, ok := m.s.data.categories[req.CategoryId]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	c.name = req.Name
+	if req.Qa != nil {
+		c.qa = *req.Qa
+	}
+	m.s.data.categories[c.id] = c
+	return c.pb(), nil
+}
+
+func (m *CategoryManager) GetByID(ctx context.Context, req *pb.CategoryGReqOrDReq) (*pb.CategoryCReqOrCResOrGResOrUReqOrURes, error) {
+	unlock, err := m.s.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	c, ok := m.s.data.categories[req.CategoryId]
+	if !ok || c.deletedAt != 0 {
+		return nil, fmt.Errorf("category not found")
+	}
+	return c.pb(), nil
+}
+
+func (m *CategoryManager) Delete(ctx context.Context, req *pb.CategoryGReqOrDReq) (*pb.Void, error) {
+	unlock, err := m.s.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if c, ok := m.s.data.categories[req.CategoryId]; ok {
+		c.deletedAt = deletedAt()
+		m.s.data.categories[c.id] = c
+	}
+	return nil, nil
+}
+
+// GetAll lists categories in the order they were created.
+func (m *CategoryManager) GetAll(ctx context.Context, req *pb.CategoryGAReq) (*pb.CategoryGARes, error) {
+	unlock, err := m.s.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	var matched []category
+	for _, c := range m.s.data.categories {
+		if c.deletedAt != 0 || (req.Filter.CategoryId != "" && c.id != req.Filter.CategoryId) {
+			continue
+		}
+		matched = append(matched, c)
+	}
+	slices.SortFunc(matched, func(a, b category) int { return cmp.Compare(a.seq, b.seq) })
+
+	categories := &pb.CategoryGARes{}
+	for _, c := range page(matched, req.Pagination.Limit, req.Pagination.Offset) {
+		categories.Categories = append(categories.Categories, c.pb())
+		categories.Count++
+	}
+	return categories, nil
+}
@@ -0,0 +1,223 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+	pb "forum-service/forum-protos/genprotos"
+	"forum-service/models"
+	"slices"
+	"time"
+)
+
+type comment struct {
+	id, userID, postID, body, status string
+	revision                         int64
+	moderatedBy, moderationReason    string
+	createdAt                        time.Time
+	seq                              int64
+	deletedAt                        int64
+}
+
+func (c comment) pb() *pb.CommentCReqOrCResOrGResOrURes {
+	return &pb.CommentCReqOrCResOrGResOrURes{
+		CommentId: c.id,
+		UserId:    c.userID,
+		PostId:    c.postID,
+		Body:      c.body,
+		Status:    c.status,
+		Revision:  c.revision,
+	}
+}
+
+type CommentManager struct {
+	s *Store
+}
+
+func NewCommentManager(s *Store) *CommentManager {
+	return &CommentManager{s: s}
+}
+
+func (m *CommentManager) Create(ctx context.Context, req *pb.CommentCReqOrCResOrGResOrURes) (*pb.CommentCReqOrCResOrGResOrURes, error) {
+	unlock, err := m.s.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if _, ok := m.s.data.comments[req.CommentId]; ok {
+		return nil, fmt.Errorf("comment %s already exists", req.CommentId)
+	}
+	p, ok := m.s.data.posts[req.PostId]
+	if !ok {
+		return nil, fmt.Errorf("post %s does not exist", req.PostId)
+	}
+	if p.locked {
+		return nil, models.ErrPostLocked
+	}
+	status := req.Status
+	if status == "" {
+		status = models.StatusPublished
+	}
+	c := comment{
+		id:        req.CommentId,
+		userID:    req.UserId,
+		postID:    req.PostId,
+		body:      req.Body,
+		status:    status,
+		revision:  1,
+		createdAt: time.Now(),
+		seq:       m.s.next(),
+	}
+	m.s.data.comments[c.id] = c
+	return c.pb(), nil
+}
+
+// Update replaces a comment's body and status. Like the SQL update it
+// returns sql.ErrNoRows for a comment that does not exist.
+func (m *CommentManager) Update(ctx context.Context, req *pb.CommentUReq, status string) (*pb.CommentCReqOrCResOrGResOrURes, error) {
+	unlock, err := m.s.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	c, ok := m.s.data.comments[req.CommentId]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	if c.body != req.Body {
+		c.revision++
+	}
+	c.body, c.status = req.Body, status
+	m.s.data.comments[c.id] = c
+	return c.pb(), nil
+}
+
+// GetByID returns a comment unless it was deleted.
+func (m *CommentManager) GetByID(ctx context.Context, req *pb.CommentGReqOrDReq) (*pb.CommentCReqOrCResOrGResOrURes, error) {
+	unlock, err := m.s.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	c, ok := m.s.data.comments[req.CommentId]
+	if !ok || c.deletedAt != 0 {
+		return nil, fmt.Errorf("comment not found")
+	}
+	com := c.pb()
+	com.Accepted = m.s.accepted(c)
+	return com, nil
+}
+
+func (m *CommentManager) DeleteByPostID(ctx context.Context, req *pb.CommentGReqOrDReqByPostID) (*pb.Void, error) {
+	unlock, err := m.s.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	m.s.deleteComments(req.PostId)
+	return nil, nil
+}
+
+func (m *CommentManager) Delete(ctx context.Context, req *pb.CommentGReqOrDReq) (*pb.Void, error) {
+	unlock, err := m.s.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	m.s.deleteComment(req.CommentId)
+	return &pb.Void{}, nil
+}
+
+func (m *CommentManager) GetAll(ctx context.Context, req *pb.CommentGAReq, viewer models.Viewer) (*pb.CommentGARes, error) {
+	unlock, err := m.s.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	f := req.Filter
+	status := f.Status
+	if status == "" {
+		status = models.StatusPublished
+	}
+	var matched []*pb.CommentCReqOrCResOrGResOrURes
+	seqs := map[*pb.CommentCReqOrCResOrGResOrURes]int64{}
+	for _, c := range m.s.data.comments {
+		if c.deletedAt != 0 || c.status != status ||
+			(f.PostId != "" && c.postID != f.PostId) ||
+			(f.UserId != "" && c.userID != f.UserId) ||
+			!m.s.visiblePost(c.postID, viewer) {
+			continue
+		}
+		// The list leaves the status out, as the SQL query does.
+		com := c.pb()
+		com.Status = ""
+		com.Accepted = m.s.accepted(c)
+		matched = append(matched, com)
+		seqs[com] = c.seq
+	}
+	// The accepted answer leads its thread.
+	slices.SortFunc(matched, func(a, b *pb.CommentCReqOrCResOrGResOrURes) int {
+		if a.Accepted != b.Accepted {
+			if a.Accepted {
+				return -1
+			}
+			return 1
+		}
+		return cmp.Compare(seqs[a], seqs[b])
+	})
+
+	comments := &pb.CommentGARes{}
+	for _, com := range page(matched, req.Pagination.Limit, req.Pagination.Offset) {
+		comments.Comments = append(comments.Comments, com)
+		comments.Count++
+	}
+	return comments, nil
+}
+
+// visiblePost tells whether viewer can see the post with postID.
+func (s *Store) visiblePost(postID string, viewer models.Viewer) bool {
+	p, ok := s.data.posts[postID]
+	if !ok || p.deletedAt != 0 {
+		return false
+	}
+	return viewer.Moderator || p.status == models.StatusPublished || (viewer.UserID != "" && p.userID == viewer.UserID)
+}
+
+// accepted reports whether c is the accepted answer to its post.
+func (s *Store) accepted(c comment) bool {
+	p, ok := s.data.posts[c.postID]
+	return ok && p.acceptedCommentID == c.id
+}
+
+// deleteComment soft deletes a comment. A deleted comment no longer answers
+// its question.
+func (s *Store) deleteComment(commentID string) {
+	if c, ok := s.data.comments[commentID]; ok && c.deletedAt == 0 {
+		c.deletedAt = deletedAt()
+		s.data.comments[commentID] = c
+	}
+	for id, p := range s.data.posts {
+		if p.acceptedCommentID == commentID {
+			p.acceptedCommentID = ""
+			s.data.posts[id] = p
+		}
+	}
+}
+
+// deleteComments soft deletes every comment on a post.
+func (s *Store) deleteComments(postID string) {
+	now := deletedAt()
+	for id, c := range s.data.comments {
+		if c.postID == postID && c.deletedAt == 0 {
+			c.deletedAt = now
+			s.data.comments[id] = c
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package memory
+
+import (
+	"context"
+	"forum-service/models"
+)
+
+type CounterManager struct {
+	s *Store
+}
+
+func NewCounterManager(s *Store) *CounterManager {
+	return &CounterManager{s: s}
+}
+
+// Repair has nothing to fix: the memory store counts comments and tags when
+// they are read instead of caching the counts.
+func (m *CounterManager) Repair(ctx context.Context) (models.CounterRepair, error) {
+	unlock, err := m.s.lock(ctx)
+	if err != nil {
+		return models.CounterRepair{}, err
+	}
+	defer unlock()
+	return models.CounterRepair{}, nil
+}
@@ -0,0 +1,12 @@
+package memory_test
+
+import (
+	"testing"
+
+	"forum-service/storage"
+	"forum-service/storage/storagetest"
+)
+
+func TestConformance(t *testing.T) {
+	storagetest.Run(t, storage.NewMemoryStorage())
+}
@@ -0,0 +1,457 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	pb "forum-service/forum-protos/genprotos"
+	"forum-service/models"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type flag struct {
+	contentType, contentID string
+	models.ModerationFlag
+}
+
+type logEntry struct {
+	id, moderatorID, action, contentType, contentID, userID, reportID, reason string
+	createdAt                                                                 time.Time
+	seq                                                                       int64
+}
+
+func (e logEntry) pb() *pb.ModerationLogEntry {
+	return &pb.ModerationLogEntry{
+		LogId:       e.id,
+		ModeratorId: e.moderatorID,
+		Action:      e.action,
+		ContentType: e.contentType,
+		ContentId:   e.contentID,
+		UserId:      e.userID,
+		ReportId:    e.reportID,
+		Reason:      e.reason,
+		CreatedAt:   e.createdAt.Format(time.RFC3339),
+	}
+}
+
+type mute struct {
+	reason, mutedBy string
+	expiresAt       *time.Time
+}
+
+func (m mute) active(now time.Time) bool {
+	return m.expiresAt == nil || m.expiresAt.After(now)
+}
+
+type ModerationManager struct {
+	s *Store
+}
+
+func NewModerationManager(s *Store) *ModerationManager {
+	return &ModerationManager{s: s}
+}
+
+// CountRecent counts posts and comments the user created within window.
+func (m *ModerationManager) CountRecent(ctx context.Context, userID string, window time.Duration) (int, error) {
+	unlock, err := m.s.lock(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	since := time.Now().Add(-window)
+	count := 0
+	for _, p := range m.s.data.posts {
+		if p.userID == userID && p.createdAt.After(since) {
+			count++
+		}
+	}
+	for _, c := range m.s.data.comments {
+		if c.userID == userID && c.createdAt.After(since) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// IsEstablished reports whether the user has published content older than age.
+func (m *ModerationManager) IsEstablished(ctx context.Context, userID string, age time.Duration) (bool, error) {
+	unlock, err := m.s.lock(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	before := time.Now().Add(-age)
+	for _, p := range m.s.data.posts {
+		if p.userID == userID && p.status == models.StatusPublished && p.createdAt.Before(before) {
+			return true, nil
+		}
+	}
+	for _, c := range m.s.data.comments {
+		if c.userID == userID && c.status == models.StatusPublished && c.createdAt.Before(before) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// HasDuplicate reports whether the user posted the same text within window.
+// body must already be normalized: lower case with single spaces.
+func (m *ModerationManager) HasDuplicate(ctx context.Context, userID, body string, window time.Duration) (bool, error) {
+	unlock, err := m.s.lock(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	since := time.Now().Add(-window)
+	for _, p := range m.s.data.posts {
+		if p.userID == userID && p.deletedAt == 0 && p.createdAt.After(since) && normalize(p.body) == body {
+			return true, nil
+		}
+	}
+	for _, c := range m.s.data.comments {
+		if c.userID == userID && c.deletedAt == 0 && c.createdAt.After(since) && normalize(c.body) == body {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *ModerationManager) Flag(ctx context.Context, contentType, contentID string, flags []models.ModerationFlag) error {
+	unlock, err := m.s.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for _, f := range flags {
+		m.s.data.flags = append(m.s.data.flags, flag{contentType: contentType, contentID: contentID, ModerationFlag: f})
+	}
+	return nil
+}
+
+func (m *ModerationManager) SetPostStatus(ctx context.Context, req *pb.ModerationDecision, status string) (*pb.Void, error) {
+	return m.setStatus(ctx, models.ContentPost, req, status)
+}
+
+func (m *ModerationManager) SetCommentStatus(ctx context.Context, req *pb.ModerationDecision, status string) (*pb.Void, error) {
+	return m.setStatus(ctx, models.ContentComment, req, status)
+}
+
+func (m *ModerationManager) setStatus(ctx context.Context, contentType string, req *pb.ModerationDecision, status string) (*pb.Void, error) {
+	unlock, err := m.s.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	action := models.ActionApprove
+	if status != models.StatusPublished {
+		action = models.ActionReject
+	}
+	author, deleted, err := m.s.contentAuthor(contentType, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	if deleted {
+		return nil, fmt.Errorf("%s not found", contentType)
+	}
+	if contentType == models.ContentComment {
+		c := m.s.data.comments[req.Id]
+		c.status, c.moderatedBy, c.moderationReason = status, req.ModeratorId, req.Reason
+		m.s.data.comments[c.id] = c
+	} else {
+		p := m.s.data.posts[req.Id]
+		p.status, p.moderatedBy, p.moderationReason = status, req.ModeratorId, req.Reason
+		m.s.data.posts[p.id] = p
+	}
+	m.s.insertLog(&pb.ModerationLogEntry{
+		ModeratorId: req.ModeratorId,
+		Action:      action,
+		ContentType: contentType,
+		ContentId:   req.Id,
+		UserId:      author,
+		Reason:      req.Reason,
+	})
+	return &pb.Void{}, nil
+}
+
+// AutoHide hides published content that collected too many reports. It
+// reports false if the content was not published.
+func (m *ModerationManager) AutoHide(ctx context.Context, contentType, contentID string) (bool, error) {
+	unlock, err := m.s.lock(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	author, deleted, err := m.s.contentAuthor(contentType, contentID)
+	if err != nil {
+		return false, err
+	}
+	if deleted || !m.s.setContentStatus(contentType, contentID, models.StatusPublished, models.StatusHidden) {
+		return false, nil
+	}
+	m.s.insertLog(&pb.ModerationLogEntry{
+		Action:      models.ActionAutoHide,
+		ContentType: contentType,
+		ContentId:   contentID,
+		UserId:      author,
+		Reason:      "report threshold reached",
+	})
+	return true, nil
+}
+
+// Resolve applies a moderator's action to reported content and closes every
+// open report on it.
+func (m *ModerationManager) Resolve(ctx context.Context, req *pb.ReportResolveReq) (*pb.Void, error) {
+	unlock, err := m.s.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	r, ok := m.s.data.reports[req.ReportId]
+	if !ok {
+		return nil, fmt.Errorf("report not found")
+	}
+	if r.status != models.ReportOpen {
+		return nil, models.ErrReportResolved
+	}
+	author, _, err := m.s.contentAuthor(r.contentType, r.contentID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch req.Action {
+	case models.ActionDismiss:
+		// Only the report threshold's hide is undone; a moderator's own
+		// decision stands.
+		if m.s.lastStatusAction(r.contentType, r.contentID) == models.ActionAutoHide {
+			m.s.setContentStatus(r.contentType, r.contentID, models.StatusHidden, models.StatusPublished)
+		}
+	case models.ActionHide:
+		m.s.setContentStatus(r.contentType, r.contentID, "", models.StatusHidden)
+	case models.ActionDelete:
+		if r.contentType == models.ContentPost {
+			m.s.deletePost(r.contentID)
+		} else {
+			m.s.deleteComment(r.contentID)
+		}
+	case models.ActionWarn:
+		// The warning itself is the log entry.
+	default:
+		return nil, fmt.Errorf("unknown action %q", req.Action)
+	}
+
+	for id, open := range m.s.data.reports {
+		if open.contentType == r.contentType && open.contentID == r.contentID && open.status == models.ReportOpen {
+			open.status, open.resolution, open.resolvedBy = models.ReportResolved, req.Action, req.ModeratorId
+			m.s.data.reports[id] = open
+		}
+	}
+	m.s.insertLog(&pb.ModerationLogEntry{
+		ModeratorId: req.ModeratorId,
+		Action:      req.Action,
+		ContentType: r.contentType,
+		ContentId:   r.contentID,
+		UserId:      author,
+		ReportId:    req.ReportId,
+		Reason:      req.Note,
+	})
+	return &pb.Void{}, nil
+}
+
+func (m *ModerationManager) GetLog(ctx context.Context, req *pb.ModerationLogGAReq) (*pb.ModerationLogGARes, error) {
+	unlock, err := m.s.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	f := req.Filter
+	var matched []logEntry
+	for _, e := range m.s.data.log {
+		if (f.ModeratorId != "" && e.moderatorID != f.ModeratorId) ||
+			(f.UserId != "" && e.userID != f.UserId) ||
+			(f.ContentId != "" && e.contentID != f.ContentId) ||
+			(f.Action != "" && e.action != f.Action) {
+			continue
+		}
+		matched = append(matched, e)
+	}
+	slices.SortFunc(matched, func(a, b logEntry) int { return cmp.Compare(b.seq, a.seq) })
+
+	entries := &pb.ModerationLogGARes{}
+	for _, e := range page(matched, req.Pagination.Limit, req.Pagination.Offset) {
+		entries.Entries = append(entries.Entries, e.pb())
+		entries.Count++
+	}
+	return entries, nil
+}
+
+// Mute makes the user read-only until expiresAt, or for good if it is nil.
+// Muting a muted user replaces the mute.
+func (m *ModerationManager) Mute(ctx context.Context, req *pb.MuteReq, expiresAt *time.Time) (*pb.Void, error) {
+	unlock, err := m.s.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	m.s.data.mutes[req.UserId] = mute{reason: req.Reason, mutedBy: req.ModeratorId, expiresAt: expiresAt}
+	m.s.insertLog(&pb.ModerationLogEntry{
+		ModeratorId: req.ModeratorId,
+		Action:      models.ActionMute,
+		ContentType: models.ContentUser,
+		ContentId:   req.UserId,
+		UserId:      req.UserId,
+		Reason:      req.Reason,
+	})
+	return &pb.Void{}, nil
+}
+
+func (m *ModerationManager) Unmute(ctx context.Context, req *pb.MuteReq) (*pb.Void, error) {
+	unlock, err := m.s.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	mu, ok := m.s.data.mutes[req.UserId]
+	if !ok || !mu.active(time.Now()) {
+		return nil, models.ErrNotMuted
+	}
+	delete(m.s.data.mutes, req.UserId)
+	m.s.insertLog(&pb.ModerationLogEntry{
+		ModeratorId: req.ModeratorId,
+		Action:      models.ActionUnmute,
+		ContentType: models.ContentUser,
+		ContentId:   req.UserId,
+		UserId:      req.UserId,
+		Reason:      req.Reason,
+	})
+	return &pb.Void{}, nil
+}
+
+func (m *ModerationManager) IsMuted(ctx context.Context, userID string) (bool, error) {
+	unlock, err := m.s.lock(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	mu, ok := m.s.data.mutes[userID]
+	return ok && mu.active(time.Now()), nil
+}
+
+// SetPostFlag sets one of the locked, pinned or featured flags of a post
+// and logs action.
+func (m *ModerationManager) SetPostFlag(ctx context.Context, req *pb.PostFlagReq, flag, action string) (*pb.PostCReqOrCResOrGResOrUResp, error) {
+	unlock, err := m.s.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	p, ok := m.s.data.posts[req.PostId]
+	if !ok || p.deletedAt != 0 {
+		return nil, fmt.Errorf("post not found")
+	}
+	switch flag {
+	case models.FlagLocked:
+		p.locked = req.Value
+	case models.FlagPinned:
+		p.pinned = req.Value
+	case models.FlagFeatured:
+		p.featured = req.Value
+	default:
+		return nil, fmt.Errorf("unknown post flag %q", flag)
+	}
+	m.s.data.posts[p.id] = p
+	m.s.insertLog(&pb.ModerationLogEntry{
+		ModeratorId: req.ModeratorId,
+		Action:      action,
+		ContentType: models.ContentPost,
+		ContentId:   req.PostId,
+		UserId:      p.userID,
+	})
+	return m.s.post(p), nil
+}
+
+// contentAuthor looks up who wrote a post or comment and whether it has
+// been deleted.
+func (s *Store) contentAuthor(contentType, contentID string) (string, bool, error) {
+	switch contentType {
+	case models.ContentPost:
+		if p, ok := s.data.posts[contentID]; ok {
+			return p.userID, p.deletedAt != 0, nil
+		}
+	case models.ContentComment:
+		if c, ok := s.data.comments[contentID]; ok {
+			return c.userID, c.deletedAt != 0, nil
+		}
+	default:
+		return "", false, fmt.Errorf("unknown content type %q", contentType)
+	}
+	return "", false, fmt.Errorf("%s not found", contentType)
+}
+
+// setContentStatus moves a post or comment to status if it is in from, or
+// whatever its status when from is empty. It reports whether it did.
+func (s *Store) setContentStatus(contentType, contentID, from, status string) bool {
+	if contentType == models.ContentComment {
+		c, ok := s.data.comments[contentID]
+		if !ok || (from != "" && c.status != from) {
+			return false
+		}
+		c.status = status
+		s.data.comments[contentID] = c
+		return true
+	}
+	p, ok := s.data.posts[contentID]
+	if !ok || (from != "" && p.status != from) {
+		return false
+	}
+	p.status = status
+	s.data.posts[contentID] = p
+	return true
+}
+
+// lastStatusAction returns the latest logged action that set the content's
+// status, or "".
+func (s *Store) lastStatusAction(contentType, contentID string) string {
+	for i := len(s.data.log) - 1; i >= 0; i-- {
+		e := s.data.log[i]
+		if e.contentType == contentType && e.contentID == contentID && slices.Contains(models.StatusActions, e.action) {
+			return e.action
+		}
+	}
+	return ""
+}
+
+func (s *Store) insertLog(e *pb.ModerationLogEntry) {
+	s.data.log = append(s.data.log, logEntry{
+		id:          uuid.NewString(),
+		moderatorID: e.ModeratorId,
+		action:      e.Action,
+		contentType: e.ContentType,
+		contentID:   e.ContentId,
+		userID:      e.UserId,
+		reportID:    e.ReportId,
+		reason:      e.Reason,
+		createdAt:   time.Now(),
+		seq:         s.next(),
+	})
+}
+
+// normalize folds whitespace and case like the duplicate check's SQL does.
+func normalize(body string) string {
+	return strings.ToLower(strings.Join(strings.Fields(body), " "))
+}
@@ -0,0 +1,172 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	pb "forum-service/forum-protos/genprotos"
+	"forum-service/models"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type poll struct {
+	id, postID, question  string
+	multiple, hideResults bool
+	closesAt, closedAt    *time.Time
+	voters                int64
+}
+
+func (p poll) closed(now time.Time) bool {
+	return p.closedAt != nil || (p.closesAt != nil && !p.closesAt.After(now))
+}
+
+type option struct {
+	id, text string
+	votes    int64
+}
+
+type ballotKey struct {
+	pollID, userID string
+}
+
+type PollManager struct {
+	s *Store
+}
+
+func NewPollManager(s *Store) *PollManager {
+	return &PollManager{s: s}
+}
+
+// Create adds a poll to a post. A post has at most one poll.
+func (m *PollManager) Create(ctx context.Context, req *pb.PollCReq, closesAt *time.Time) (*pb.PollRes, error) {
+	unlock, err := m.s.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if _, ok := m.s.data.posts[req.PostId]; !ok {
+		return nil, fmt.Errorf("post %s does not exist", req.PostId)
+	}
+	if _, ok := m.s.data.polls[req.PostId]; ok {
+		return nil, models.ErrPollExists
+	}
+	p := poll{
+		id:          uuid.NewString(),
+		postID:      req.PostId,
+		question:    req.Question,
+		multiple:    req.Multiple,
+		hideResults: req.HideResults,
+		closesAt:    closesAt,
+	}
+	options := make([]option, len(req.Options))
+	for i, text := range req.Options {
+		options[i] = option{id: uuid.NewString(), text: text}
+	}
+	m.s.data.polls[p.postID] = p
+	m.s.data.options[p.id] = options
+	return m.s.poll(p, req.UserId), nil
+}
+
+// Get returns the poll of a post with its current counts and whether
+// req.UserId has voted.
+func (m *PollManager) Get(ctx context.Context, req *pb.PollGReq) (*pb.PollRes, error) {
+	unlock, err := m.s.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	p, ok := m.s.data.polls[req.PostId]
+	if !ok {
+		return nil, models.ErrPollNotFound
+	}
+	return m.s.poll(p, req.UserId), nil
+}
+
+// Vote casts req.UserId's single ballot.
+func (m *PollManager) Vote(ctx context.Context, req *pb.PollVoteReq) error {
+	unlock, err := m.s.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	p, ok := m.s.data.polls[req.PostId]
+	if !ok {
+		return models.ErrPollNotFound
+	}
+	if p.closed(time.Now()) {
+		return models.ErrPollClosed
+	}
+	if len(req.OptionIds) == 0 || (!p.multiple && len(req.OptionIds) > 1) {
+		return models.ErrInvalidVote
+	}
+	options := slices.Clone(m.s.data.options[p.id])
+	chosen := map[string]bool{}
+	for _, id := range req.OptionIds {
+		chosen[id] = true
+	}
+	matched := 0
+	for i := range options {
+		if chosen[options[i].id] {
+			options[i].votes++
+			matched++
+		}
+	}
+	// A repeated option is as wrong as a foreign one.
+	if matched != len(req.OptionIds) {
+		return models.ErrInvalidVote
+	}
+	key := ballotKey{p.id, req.UserId}
+	if m.s.data.ballots[key] {
+		return models.ErrAlreadyVoted
+	}
+	m.s.data.ballots[key] = true
+	m.s.data.options[p.id] = options
+	p.voters++
+	m.s.data.polls[p.postID] = p
+	return nil
+}
+
+// Close ends voting now. Closing a closed poll is a no-op.
+func (m *PollManager) Close(ctx context.Context, postID string) error {
+	unlock, err := m.s.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	p, ok := m.s.data.polls[postID]
+	if !ok {
+		return models.ErrPollNotFound
+	}
+	if p.closedAt == nil {
+		now := time.Now()
+		p.closedAt = &now
+		m.s.data.polls[postID] = p
+	}
+	return nil
+}
+
+func (s *Store) poll(p poll, userID string) *pb.PollRes {
+	res := &pb.PollRes{
+		PollId:      p.id,
+		PostId:      p.postID,
+		Question:    p.question,
+		Multiple:    p.multiple,
+		HideResults: p.hideResults,
+		Voters:      p.voters,
+		Closed:      p.closed(time.Now()),
+		Voted:       s.data.ballots[ballotKey{p.id, userID}],
+	}
+	if p.closesAt != nil {
+		res.ClosesAt = p.closesAt.Format(time.RFC3339)
+	}
+	for _, o := range s.data.options[p.id] {
+		res.Options = append(res.Options, &pb.PollOption{OptionId: o.id, Text: o.text, Votes: o.votes})
+	}
+	return res
+}
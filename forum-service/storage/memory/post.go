@@ -0,0 +1,234 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+	pb "forum-service/forum-protos/genprotos"
+	"forum-service/models"
+	"slices"
+	"strings"
+	"time"
+)
+
+type post struct {
+	id, userID, title, body, categoryID, tags, status string
+	locked, pinned, featured                          bool
+	revision                                          int64
+	acceptedCommentID                                 string
+	moderatedBy, moderationReason                     string
+	createdAt                                         time.Time
+	seq                                               int64
+	deletedAt                                         int64
+}
+
+func (p post) pb() *pb.PostCReqOrCResOrGResOrUResp {
+	return &pb.PostCReqOrCResOrGResOrUResp{
+		PostId:            p.id,
+		UserId:            p.userID,
+		Title:             p.title,
+		Body:              p.body,
+		CategoryId:        p.categoryID,
+		Tags:              p.tags,
+		Status:            p.status,
+		Locked:            p.locked,
+		Pinned:            p.pinned,
+		Featured:          p.featured,
+		Revision:          p.revision,
+		AcceptedCommentId: p.acceptedCommentID,
+	}
+}
+
+// post adds the counters the SQL tables cache to p; here they are counted
+// on the spot.
+func (s *Store) post(p post) *pb.PostCReqOrCResOrGResOrUResp {
+	res := p.pb()
+	lastActivity := p.createdAt
+	for _, c := range s.data.comments {
+		if c.postID == p.id && c.deletedAt == 0 && c.status == models.StatusPublished {
+			res.CommentCount++
+			if c.createdAt.After(lastActivity) {
+				lastActivity = c.createdAt
+			}
+		}
+	}
+	res.LastActivityAt = lastActivity.Format(time.RFC3339)
+	return res
+}
+
+type PostManager struct {
+	s *Store
+}
+
+func NewPostManager(s *Store) *PostManager {
+	return &PostManager{s: s}
+}
+
+func (m *PostManager) Create(ctx context.Context, req *pb.PostCReqOrCResOrGResOrUResp, tags []string) (*pb.PostCReqOrCResOrGResOrUResp, error) {
+	unlock, err := m.s.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if _, ok := m.s.data.posts[req.PostId]; ok {
+		return nil, fmt.Errorf("post %s already exists", req.PostId)
+	}
+	if _, ok := m.s.data.categories[req.CategoryId]; !ok {
+		return nil, fmt.Errorf("category %s does not exist", req.CategoryId)
+	}
+	status := req.Status
+	if status == "" {
+		status = models.StatusPublished
+	}
+	p := post{
+		id:         req.PostId,
+		userID:     req.UserId,
+		title:      req.Title,
+		body:       req.Body,
+		categoryID: req.CategoryId,
+		tags:       req.Tags,
+		status:     status,
+		revision:   1,
+		createdAt:  time.Now(),
+		seq:        m.s.next(),
+	}
+	m.s.data.posts[p.id] = p
+	m.s.addTags(p.id, tags)
+	return m.s.post(p), nil
+}
+
+// Update replaces a post's text, category, tags and status. Like the SQL
+// update it returns sql.ErrNoRows for a post that does not exist.
+func (m *PostManager) Update(ctx context.Context, req *pb.PostUReq, tags []string, status string) (*pb.PostCReqOrCResOrGResOrUResp, error) {
+	unlock, err := m.s.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	p, ok := m.s.data.posts[req.PostId]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	if _, ok := m.s.data.categories[req.CategoryId]; !ok {
+		return nil, fmt.Errorf("category %s does not exist", req.CategoryId)
+	}
+	if p.body != req.Body {
+		p.revision++
+	}
+	p.title, p.body, p.categoryID, p.tags, p.status = req.Title, req.Body, req.CategoryId, req.Tags, status
+	m.s.data.posts[p.id] = p
+	m.s.deleteTags(p.id)
+	m.s.addTags(p.id, tags)
+	return m.s.post(p), nil
+}
+
+// GetByID returns a post unless it was deleted.
+func (m *PostManager) GetByID(ctx context.Context, req *pb.PostGReqOrDReq) (*pb.PostCReqOrCResOrGResOrUResp, error) {
+	unlock, err := m.s.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	p, ok := m.s.data.posts[req.PostId]
+	if !ok || p.deletedAt != 0 {
+		return nil, fmt.Errorf("post not found")
+	}
+	return m.s.post(p), nil
+}
+
+// Delete soft deletes a post and its comments. Its tags stay.
+func (m *PostManager) Delete(ctx context.Context, req *pb.PostGReqOrDReq) (*pb.Void, error) {
+	unlock, err := m.s.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	m.s.deletePost(req.PostId)
+	return &pb.Void{}, nil
+}
+
+func (m *PostManager) GetAll(ctx context.Context, req *pb.PostGAReq) (*pb.PostGARes, error) {
+	unlock, err := m.s.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	f := req.Filter
+	status := f.Status
+	if status == "" {
+		status = models.StatusPublished
+	}
+	var matched []post
+	for _, p := range m.s.data.posts {
+		switch {
+		case p.deletedAt != 0, p.status != status,
+			f.UserId != "" && p.userID != f.UserId,
+			f.CategoryId != "" && p.categoryID != f.CategoryId,
+			f.Tags != "" && !strings.Contains(strings.ToLower(p.tags), strings.ToLower(f.Tags)),
+			f.Body != "" && p.body != f.Body,
+			f.Title != "" && p.title != f.Title,
+			f.Featured && !p.featured:
+			continue
+		}
+		// Answered only applies to questions, i.e. posts in Q&A categories.
+		if f.Answered == "true" || f.Answered == "false" {
+			if !m.s.data.categories[p.categoryID].qa || (p.acceptedCommentID != "") != (f.Answered == "true") {
+				continue
+			}
+		}
+		matched = append(matched, p)
+	}
+	// Pinned posts lead their category.
+	slices.SortFunc(matched, func(a, b post) int {
+		if f.CategoryId != "" && a.pinned != b.pinned {
+			if a.pinned {
+				return -1
+			}
+			return 1
+		}
+		return cmp.Compare(b.seq, a.seq)
+	})
+
+	posts := &pb.PostGARes{}
+	for _, p := range page(matched, req.Pagination.Limit, req.Pagination.Offset) {
+		posts.Posts = append(posts.Posts, m.s.post(p))
+		posts.Count++
+	}
+	return posts, nil
+}
+
+// SetAcceptedAnswer marks commentID as the answer to a question, or clears
+// the answer when commentID is empty.
+func (m *PostManager) SetAcceptedAnswer(ctx context.Context, postID, commentID string) (*pb.PostCReqOrCResOrGResOrUResp, error) {
+	unlock, err := m.s.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	p, ok := m.s.data.posts[postID]
+	if !ok || p.deletedAt != 0 {
+		return nil, fmt.Errorf("post not found")
+	}
+	if _, ok := m.s.data.comments[commentID]; commentID != "" && !ok {
+		return nil, fmt.Errorf("comment %s does not exist", commentID)
+	}
+	p.acceptedCommentID = commentID
+	m.s.data.posts[p.id] = p
+	return m.s.post(p), nil
+}
+
+// deletePost soft deletes a post and its comments.
+func (s *Store) deletePost(postID string) {
+	if p, ok := s.data.posts[postID]; ok {
+		p.deletedAt = deletedAt()
+		s.data.posts[postID] = p
+	}
+	s.deleteComments(postID)
+}
@@ -0,0 +1,61 @@
+package memory
+
+import (
+	"context"
+	"forum-service/render"
+)
+
+type renderKey struct {
+	contentType, contentID string
+}
+
+type renderedBody struct {
+	revision int64
+	renderer int
+	html     string
+}
+
+type RenderManager struct {
+	s *Store
+}
+
+func NewRenderManager(s *Store) *RenderManager {
+	return &RenderManager{s: s}
+}
+
+// GetAll returns the cached renderings of keys that are still current.
+func (m *RenderManager) GetAll(ctx context.Context, contentType string, version int, keys []render.Key) (map[render.Key]string, error) {
+	unlock, err := m.s.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	html := map[render.Key]string{}
+	for _, k := range keys {
+		r, ok := m.s.data.renders[renderKey{contentType, k.ContentID}]
+		if ok && r.revision == k.Revision && r.renderer == version {
+			html[k] = r.html
+		}
+	}
+	return html, nil
+}
+
+// PutAll replaces the cached renderings, except where a newer revision is
+// already stored.
+func (m *RenderManager) PutAll(ctx context.Context, contentType string, version int, html map[render.Key]string) error {
+	unlock, err := m.s.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for k, h := range html {
+		key := renderKey{contentType, k.ContentID}
+		if r, ok := m.s.data.renders[key]; ok && r.revision > k.Revision {
+			continue
+		}
+		m.s.data.renders[key] = renderedBody{revision: k.Revision, renderer: version, html: h}
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	pb "forum-service/forum-protos/genprotos"
+	"forum-service/models"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type report struct {
+	id, contentType, contentID, reporterID, reason string
+	status, resolution, resolvedBy                 string
+	createdAt                                      time.Time
+	seq                                            int64
+}
+
+func (r report) pb() *pb.ReportRes {
+	return &pb.ReportRes{
+		ReportId:    r.id,
+		ContentType: r.contentType,
+		ContentId:   r.contentID,
+		ReporterId:  r.reporterID,
+		Reason:      r.reason,
+		Status:      r.status,
+		Resolution:  r.resolution,
+		CreatedAt:   r.createdAt.Format(time.RFC3339),
+	}
+}
+
+type ReportManager struct {
+	s *Store
+}
+
+func NewReportManager(s *Store) *ReportManager {
+	return &ReportManager{s: s}
+}
+
+// Create files a report. A user can report the same content only once.
+func (m *ReportManager) Create(ctx context.Context, req *pb.ReportCReq) (*pb.ReportRes, error) {
+	unlock, err := m.s.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	_, deleted, err := m.s.contentAuthor(req.ContentType, req.ContentId)
+	if err != nil {
+		return nil, err
+	}
+	if deleted {
+		return nil, fmt.Errorf("%s not found", req.ContentType)
+	}
+	for _, r := range m.s.data.reports {
+		if r.contentType == req.ContentType && r.contentID == req.ContentId && r.reporterID == req.ReporterId {
+			return nil, models.ErrAlreadyReported
+		}
+	}
+	r := report{
+		id:          uuid.NewString(),
+		contentType: req.ContentType,
+		contentID:   req.ContentId,
+		reporterID:  req.ReporterId,
+		reason:      req.Reason,
+		status:      models.ReportOpen,
+		createdAt:   time.Now(),
+		seq:         m.s.next(),
+	}
+	m.s.data.reports[r.id] = r
+	return r.pb(), nil
+}
+
+func (m *ReportManager) GetByID(ctx context.Context, reportID string) (*pb.ReportRes, error) {
+	unlock, err := m.s.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	r, ok := m.s.data.reports[reportID]
+	if !ok {
+		return nil, fmt.Errorf("report not found")
+	}
+	return r.pb(), nil
+}
+
+func (m *ReportManager) CountOpen(ctx context.Context, contentType, contentID string) (int, error) {
+	unlock, err := m.s.lock(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	count := 0
+	for _, r := range m.s.data.reports {
+		if r.contentType == contentType && r.contentID == contentID && r.status == models.ReportOpen {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (m *ReportManager) GetAll(ctx context.Context, req *pb.ReportGAReq) (*pb.ReportGARes, error) {
+	unlock, err := m.s.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	f := req.Filter
+	status := f.Status
+	if status == "" {
+		status = models.ReportOpen
+	}
+	var matched []report
+	for _, r := range m.s.data.reports {
+		if r.status != status ||
+			(f.ContentType != "" && r.contentType != f.ContentType) ||
+			(f.ContentId != "" && r.contentID != f.ContentId) {
+			continue
+		}
+		matched = append(matched, r)
+	}
+	slices.SortFunc(matched, func(a, b report) int { return cmp.Compare(a.seq, b.seq) })
+
+	reports := &pb.ReportGARes{}
+	for _, r := range page(matched, req.Pagination.Limit, req.Pagination.Offset) {
+		reports.Reports = append(reports.Reports, r.pb())
+		reports.Count++
+	}
+	return reports, nil
+}
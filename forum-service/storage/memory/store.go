@@ -0,0 +1,197 @@
+// Package memory keeps forum data in process memory. Its managers implement
+// the same storage interfaces, with the same semantics, as the PostgreSQL
+// ones; it is meant for tests and local runs, and nothing survives a restart.
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"forum-service/models"
+)
+
+// Store holds the data every manager of one backend shares. Records are
+// values: changing one means putting a new copy, so a snapshot of the maps is
+// enough to roll a unit of work back.
+type Store struct {
+	mu   sync.Mutex
+	data data
+	seq  int64
+}
+
+type data struct {
+	categories  map[string]category
+	posts       map[string]post
+	comments    map[string]comment
+	tags        []tag
+	flags       []flag
+	log         []logEntry
+	mutes       map[string]mute
+	reports     map[string]report
+	renders     map[renderKey]renderedBody
+	attachments map[string]attachment
+	variants    map[string][]models.AttachmentVariant
+	polls       map[string]poll // by post ID
+	options     map[string][]option
+	ballots     map[ballotKey]bool
+}
+
+func NewStore() *Store {
+	return &Store{data: data{
+		categories:  map[string]category{},
+		posts:       map[string]post{},
+		comments:    map[string]comment{},
+		mutes:       map[string]mute{},
+		reports:     map[string]report{},
+		renders:     map[renderKey]renderedBody{},
+		attachments: map[string]attachment{},
+		variants:    map[string][]models.AttachmentVariant{},
+		polls:       map[string]poll{},
+		options:     map[string][]option{},
+		ballots:     map[ballotKey]bool{},
+	}}
+}
+
+// clone copies the maps and slices; the records in them are never changed in
+// place, so they can be shared.
+func (d data) clone() data {
+	return data{
+		categories:  maps.Clone(d.categories),
+		posts:       maps.Clone(d.posts),
+		comments:    maps.Clone(d.comments),
+		tags:        slices.Clone(d.tags),
+		flags:       slices.Clone(d.flags),
+		log:         slices.Clone(d.log),
+		mutes:       maps.Clone(d.mutes),
+		reports:     maps.Clone(d.reports),
+		renders:     maps.Clone(d.renders),
+		attachments: maps.Clone(d.attachments),
+		variants:    maps.Clone(d.variants),
+		polls:       maps.Clone(d.polls),
+		options:     maps.Clone(d.options),
+		ballots:     maps.Clone(d.ballots),
+	}
+}
+
+type txKey struct{}
+
+// unit is the outermost unit of work a context runs in.
+type unit struct {
+	s           *Store
+	afterCommit []func()
+}
+
+// inUnit returns the store's unit of work ctx runs in, if any.
+func (s *Store) inUnit(ctx context.Context) (*unit, bool) {
+	u, ok := ctx.Value(txKey{}).(*unit)
+	return u, ok && u.s == s
+}
+
+// lock takes the store for one call and returns the function that gives it
+// back. A context inside one of the store's units of work holds it already.
+// Like a query, it fails once ctx is done.
+func (s *Store) lock(ctx context.Context) (func(), error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if _, ok := s.inUnit(ctx); ok {
+		return func() {}, nil
+	}
+	s.mu.Lock()
+	return s.mu.Unlock, nil
+}
+
+// next returns a sequence number that orders records created at the same time.
+func (s *Store) next() int64 {
+	s.seq++
+	return s.seq
+}
+
+// InTx runs fn as a unit of work.
+func (s *Store) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return s.InTxWith(ctx, nil, fn)
+}
+
+// InTxWith runs fn with the store to itself, so every unit of work is
+// serializable whatever opts asks for. When fn fails or panics the data is
+// restored as it was before fn; inside another unit of work that undoes only
+// fn's changes, like a savepoint.
+func (s *Store) InTxWith(ctx context.Context, opts *sql.TxOptions, fn func(ctx context.Context) error) error {
+	u, nested := s.inUnit(ctx)
+	if !nested {
+		u = &unit{s: s}
+	}
+	hooks := len(u.afterCommit)
+	err := s.run(ctx, u, fn)
+	if err != nil {
+		u.afterCommit = u.afterCommit[:hooks]
+		return err
+	}
+	if !nested {
+		for _, fn := range u.afterCommit {
+			fn()
+		}
+	}
+	return nil
+}
+
+// run locks the store, unless ctx holds it already, and runs fn in u.
+func (s *Store) run(ctx context.Context, u *unit, fn func(ctx context.Context) error) error {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	snapshot := s.data.clone()
+	defer func() {
+		if p := recover(); p != nil {
+			s.data = snapshot
+			panic(p)
+		}
+	}()
+	if err := fn(context.WithValue(ctx, txKey{}, u)); err != nil {
+		s.data = snapshot
+		return err
+	}
+	return nil
+}
+
+// AfterCommit runs fn once the unit of work ctx runs in has finished, or
+// right away outside of one. fn is dropped if the work is undone.
+func (s *Store) AfterCommit(ctx context.Context, fn func()) {
+	if u, ok := s.inUnit(ctx); ok {
+		u.afterCommit = append(u.afterCommit, fn)
+		return
+	}
+	fn()
+}
+
+// InUnit tells whether ctx runs in one of the store's units of work.
+func (s *Store) InUnit(ctx context.Context) bool {
+	_, ok := s.inUnit(ctx)
+	return ok
+}
+
+// page applies a limit and offset the way the SQL queries do: zero means none.
+func page[T any](items []T, limit, offset int64) []T {
+	if offset > 0 {
+		if offset >= int64(len(items)) {
+			return nil
+		}
+		items = items[offset:]
+	}
+	if limit > 0 && limit < int64(len(items)) {
+		items = items[:limit]
+	}
+	return items
+}
+
+// deletedAt is the soft delete marker, seconds since the epoch.
+func deletedAt() int64 {
+	return time.Now().Unix()
+}
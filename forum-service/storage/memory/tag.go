@@ -0,0 +1,85 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	pb "forum-service/forum-protos/genprotos"
+	"slices"
+)
+
+type tag struct {
+	tag, postID string
+}
+
+type TagManager struct {
+	s *Store
+}
+
+func NewTagManager(s *Store) *TagManager {
+	return &TagManager{s: s}
+}
+
+func (m *TagManager) Create(ctx context.Context, req *pb.TagCReqOrCRes) (*pb.TagCReqOrCRes, error) {
+	unlock, err := m.s.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if _, ok := m.s.data.posts[req.PostId]; !ok {
+		return nil, fmt.Errorf("post %s does not exist", req.PostId)
+	}
+	m.s.addTags(req.PostId, []string{req.Tag})
+	return &pb.TagCReqOrCRes{Tag: req.Tag, PostId: req.PostId}, nil
+}
+
+func (m *TagManager) Delete(ctx context.Context, req *pb.TagGReqOrDReq) (*pb.Void, error) {
+	unlock, err := m.s.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	m.s.deleteTags(req.PostId)
+	return &pb.Void{}, nil
+}
+
+// GetPopular counts how many posts use each tag, most used first. Like the
+// SQL query it counts the tags of deleted posts too.
+func (m *TagManager) GetPopular(ctx context.Context, req *pb.Pagination) (*pb.TagPopularRes, error) {
+	unlock, err := m.s.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	counts := map[string]int64{}
+	for _, t := range m.s.data.tags {
+		counts[t.tag]++
+	}
+	var popular []*pb.TagPopular
+	for name, count := range counts {
+		popular = append(popular, &pb.TagPopular{Tag: name, Count: count})
+	}
+	slices.SortFunc(popular, func(a, b *pb.TagPopular) int {
+		if c := cmp.Compare(b.Count, a.Count); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Tag, b.Tag)
+	})
+
+	tags := &pb.TagPopularRes{}
+	tags.Tags = page(popular, req.Limit, req.Offset)
+	return tags, nil
+}
+
+func (s *Store) addTags(postID string, tags []string) {
+	for _, t := range tags {
+		s.data.tags = append(s.data.tags, tag{tag: t, postID: postID})
+	}
+}
+
+func (s *Store) deleteTags(postID string) {
+	s.data.tags = slices.DeleteFunc(s.data.tags, func(t tag) bool { return t.postID == postID })
+}
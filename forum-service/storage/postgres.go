@@ -1,9 +1,12 @@
 package storage
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
+	"net"
+	"strconv"
 
 	"forum-service/config"
 	managers "forum-service/storage/postgres"
@@ -12,37 +15,137 @@ import (
 )
 
 type Storage struct {
-	Db        *sql.DB
-	PostS     PostI
-	CategoryS CategoryI
-	TagS      TagI
-	CommentS  CommentI
+	Db          *sql.DB
+	Replicas    *managers.Replicas // nil without read replicas
+	Health      *managers.Health   // nil without a database
+	Tx          TransactorI
+	PostS       PostI
+	CategoryS   CategoryI
+	TagS        TagI
+	CommentS    CommentI
+	ModerationS ModerationI
+	ReportS     ReportI
+	RenderS     RenderI
+	AttachmentS AttachmentI
+	PollS       PollI
+	CounterS    CounterI
 }
 
 func NewPostgresStorage(config config.Config) (*Storage, error) {
-	conn := fmt.Sprintf("host=%s user=%s dbname=%s password=%s port=%d sslmode=disable",
-		config.DB_HOST, config.DB_USER, config.DB_NAME, config.DB_PASSWORD, config.DB_PORT)
-	db, err := sql.Open("postgres", conn)
+	db, err := open(config, config.DB_HOST, config.DB_PORT)
 	if err != nil {
 		return nil, err
 	}
 
-	err = db.Ping()
+	err = managers.Connect(context.Background(), db, config.DB_CONNECT_ATTEMPTS, config.DB_CONNECT_BACKOFF)
 	if err != nil {
+		db.Close()
 		return nil, err
 	}
-	//db := &sql.DB{}
+	log.Println("Successfully connected to the database")
+
+	if len(config.DB_REPLICA_HOSTS) == 0 {
+		return NewPostgresStorageWithDB(db), nil
+	}
+	replicas := managers.NewReplicas(config.DB_REPLICA_MAX_LAG)
+	for _, host := range config.DB_REPLICA_HOSTS {
+		port := config.DB_PORT
+		if h, p, err := net.SplitHostPort(host); err == nil {
+			n, err := strconv.Atoi(p)
+			if err != nil {
+				return nil, fmt.Errorf("replica %s: bad port %q", host, p)
+			}
+			host, port = h, n
+		}
+		replica, err := open(config, host, port)
+		if err != nil {
+			return nil, err
+		}
+		replicas.Add(host, replica)
+	}
+	// A replica that is down now only means reads start on the primary.
+	ctx, cancel := context.WithTimeout(context.Background(), config.DB_REPLICA_CHECK_INTERVAL)
+	defer cancel()
+	log.Printf("%d of %d read replicas are healthy", replicas.Check(ctx), len(config.DB_REPLICA_HOSTS))
+	return NewPostgresStorageWithReplicas(db, replicas), nil
+}
+
+// open opens a pool on host with the configured limits.
+func open(config config.Config, host string, port int) (*sql.DB, error) {
+	dsn := fmt.Sprintf("host=%s user=%s dbname=%s password=%s port=%d sslmode=disable",
+		host, config.DB_USER, config.DB_NAME, config.DB_PASSWORD, port)
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(config.DB_MAX_OPEN_CONNS)
+	db.SetMaxIdleConns(config.DB_MAX_IDLE_CONNS)
+	db.SetConnMaxLifetime(config.DB_CONN_MAX_LIFETIME)
+	db.SetConnMaxIdleTime(config.DB_CONN_MAX_IDLE_TIME)
+	return db, nil
+}
+
+// NewPostgresStorageWithDB builds the storage on an open database.
+func NewPostgresStorageWithDB(db *sql.DB) *Storage {
+	return NewPostgresStorageWithReplicas(db, nil)
+}
+
+// NewPostgresStorageWithReplicas builds the storage on an open primary whose
+// post, category, comment and tag reads go to replicas.
+func NewPostgresStorageWithReplicas(db *sql.DB, replicas *managers.Replicas) *Storage {
 	c_repo := managers.NewCategoryManager(db)
 	t_repo := managers.NewTagManager(db)
 	cm_repo := managers.NewCommentManager(db)
 	p_repo := managers.NewPostManager(db, t_repo, cm_repo)
+	m_repo := managers.NewModerationManager(db, cm_repo)
+	r_repo := managers.NewReportManager(db)
+	rn_repo := managers.NewRenderManager(db)
+	a_repo := managers.NewAttachmentManager(db)
+	pl_repo := managers.NewPollManager(db)
+	cn_repo := managers.NewCounterManager(db)
+	c_repo.Replicas, t_repo.Replicas, cm_repo.Replicas, p_repo.Replicas = replicas, replicas, replicas, replicas
 
-	log.Println("Successfully connected to the database")
 	return &Storage{
-		Db:        db,
-		PostS:     p_repo,
-		CategoryS: c_repo,
-		TagS:      t_repo,
-		CommentS:  cm_repo,
-	}, nil
+		Db:          db,
+		Replicas:    replicas,
+		Health:      managers.NewHealth(db),
+		Tx:          managers.NewTransactor(db),
+		PostS:       p_repo,
+		CategoryS:   c_repo,
+		TagS:        t_repo,
+		CommentS:    cm_repo,
+		ModerationS: m_repo,
+		ReportS:     r_repo,
+		RenderS:     rn_repo,
+		AttachmentS: a_repo,
+		PollS:       pl_repo,
+		CounterS:    cn_repo,
+	}
+}
+
+// WithPrimary makes reads with the returned context see every write
+// committed before them, by reading from the primary instead of a replica.
+func WithPrimary(ctx context.Context) context.Context {
+	return managers.WithPrimary(ctx)
+}
+
+// Ready reports why the storage can't serve requests, or nil if it can: the
+// database failed its last health check. Storages without one are always
+// ready.
+func (s *Storage) Ready() error {
+	if s.Health == nil {
+		return nil
+	}
+	return s.Health.Err()
+}
+
+// Close releases the database connections, if the storage has any.
+func (s *Storage) Close() error {
+	if s.Db == nil {
+		return nil
+	}
+	if s.Replicas != nil {
+		s.Replicas.Close()
+	}
+	return s.Db.Close()
 }
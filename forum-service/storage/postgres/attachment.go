@@ -0,0 +1,184 @@
+package managers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	pb "forum-service/forum-protos/genprotos"
+	"forum-service/models"
+	"time"
+)
+
+type AttachmentManager struct {
+	Conn *sql.DB
+	tx   *Transactor
+}
+
+func NewAttachmentManager(conn *sql.DB) *AttachmentManager {
+	return &AttachmentManager{Conn: conn, tx: NewTransactor(conn)}
+}
+
+const attachmentColumns = "attachment_id, content_type, content_id, user_id, filename, mime_type, size_bytes, processing_status, width, height, created_at"
+
+func scanAttachment(row interface{ Scan(...interface{}) error }) (*pb.AttachmentRes, error) {
+	a := &pb.AttachmentRes{}
+	var createdAt time.Time
+	err := row.Scan(&a.AttachmentId, &a.ContentType, &a.ContentId, &a.UserId, &a.Filename, &a.MimeType, &a.Size, &a.ProcessingStatus, &a.Width, &a.Height, &createdAt)
+	if err != nil {
+		return nil, err
+	}
+	a.CreatedAt = createdAt.Format(time.RFC3339)
+	return a, nil
+}
+
+// Create records an attachment whose bytes are already stored under blobKey.
+func (m *AttachmentManager) Create(ctx context.Context, a *pb.AttachmentRes, blobKey string) (*pb.AttachmentRes, error) {
+	processing := a.ProcessingStatus
+	if processing == "" {
+		processing = models.ProcessingSkipped
+	}
+	query := `INSERT INTO attachments (attachment_id, content_type, content_id, user_id, filename, mime_type, size_bytes, blob_key, processing_status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING ` + attachmentColumns
+	return scanAttachment(conn(ctx, m.Conn).QueryRowContext(ctx, query, a.AttachmentId, a.ContentType, a.ContentId, a.UserId, a.Filename, a.MimeType, a.Size, blobKey, processing))
+}
+
+// GetByID returns an attachment and the key of its bytes.
+func (m *AttachmentManager) GetByID(ctx context.Context, req *pb.AttachmentGReqOrDReq) (*pb.AttachmentRes, string, error) {
+	query := "SELECT " + attachmentColumns + ", blob_key FROM attachments WHERE attachment_id = $1 AND deleted_at = 0"
+	a := &pb.AttachmentRes{}
+	var createdAt time.Time
+	var blobKey string
+	err := conn(ctx, m.Conn).QueryRowContext(ctx, query, req.AttachmentId).
+		Scan(&a.AttachmentId, &a.ContentType, &a.ContentId, &a.UserId, &a.Filename, &a.MimeType, &a.Size, &a.ProcessingStatus, &a.Width, &a.Height, &createdAt, &blobKey)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, "", fmt.Errorf("attachment not found")
+		}
+		return nil, "", err
+	}
+	a.CreatedAt = createdAt.Format(time.RFC3339)
+	return a, blobKey, nil
+}
+
+func (m *AttachmentManager) GetAll(ctx context.Context, req *pb.AttachmentGAReq) (*pb.AttachmentGARes, error) {
+	query := "SELECT " + attachmentColumns + " FROM attachments WHERE content_type = $1 AND content_id = $2 AND deleted_at = 0 ORDER BY created_at"
+	rows, err := conn(ctx, m.Conn).QueryContext(ctx, query, req.ContentType, req.ContentId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attachments := &pb.AttachmentGARes{}
+	for rows.Next() {
+		a, err := scanAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+		attachments.Attachments = append(attachments.Attachments, a)
+		attachments.Count++
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
+
+func (m *AttachmentManager) Delete(ctx context.Context, req *pb.AttachmentGReqOrDReq) (*pb.Void, error) {
+	query := "UPDATE attachments SET deleted_at = EXTRACT(EPOCH FROM NOW()) WHERE attachment_id = $1 AND deleted_at = 0"
+	res, err := conn(ctx, m.Conn).ExecContext(ctx, query, req.AttachmentId)
+	if err != nil {
+		return nil, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, fmt.Errorf("attachment not found")
+	}
+	return &pb.Void{}, nil
+}
+
+// GetVariants lists the stored renditions of an image, smallest first.
+func (m *AttachmentManager) GetVariants(ctx context.Context, attachmentID string) ([]models.AttachmentVariant, error) {
+	query := "SELECT variant, width, height, mime_type, size_bytes, blob_key FROM attachment_variants WHERE attachment_id = $1 ORDER BY width * height"
+	rows, err := conn(ctx, m.Conn).QueryContext(ctx, query, attachmentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var variants []models.AttachmentVariant
+	for rows.Next() {
+		var v models.AttachmentVariant
+		if err := rows.Scan(&v.Name, &v.Width, &v.Height, &v.MimeType, &v.Size, &v.BlobKey); err != nil {
+			return nil, err
+		}
+		variants = append(variants, v)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return variants, nil
+}
+
+// ClaimImage takes the next image due for processing and leases it for lease;
+// a worker that dies mid-job leaves it to be claimed again once the lease
+// runs out. It returns nil when nothing is due.
+func (m *AttachmentManager) ClaimImage(ctx context.Context, lease time.Duration) (*pb.AttachmentRes, string, int, error) {
+	query := `UPDATE attachments SET processing_status = $1, attempts = attempts + 1, next_attempt_at = NOW() + $2 * INTERVAL '1 second'
+		WHERE attachment_id = (
+			SELECT attachment_id FROM attachments
+			WHERE processing_status IN ($3, $1) AND next_attempt_at <= NOW() AND deleted_at = 0
+			ORDER BY next_attempt_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + attachmentColumns + ", blob_key, attempts"
+	a := &pb.AttachmentRes{}
+	var createdAt time.Time
+	var blobKey string
+	var attempts int
+	err := conn(ctx, m.Conn).QueryRowContext(ctx, query, models.ProcessingInProgress, lease.Seconds(), models.ProcessingPending).
+		Scan(&a.AttachmentId, &a.ContentType, &a.ContentId, &a.UserId, &a.Filename, &a.MimeType, &a.Size, &a.ProcessingStatus, &a.Width, &a.Height, &createdAt, &blobKey, &attempts)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, "", 0, nil
+		}
+		return nil, "", 0, err
+	}
+	a.CreatedAt = createdAt.Format(time.RFC3339)
+	return a, blobKey, attempts, nil
+}
+
+// SaveVariants records a processed image: the cleaned original replaces the
+// upload's type, size and dimensions, and variants replace any earlier ones.
+func (m *AttachmentManager) SaveVariants(ctx context.Context, attachmentID string, original models.AttachmentVariant, variants []models.AttachmentVariant) error {
+	return m.tx.InTx(ctx, func(ctx context.Context) error {
+		tx := conn(ctx, m.Conn)
+		if _, err := tx.ExecContext(ctx, "DELETE FROM attachment_variants WHERE attachment_id = $1", attachmentID); err != nil {
+			return err
+		}
+		for _, v := range variants {
+			_, err := tx.ExecContext(ctx, `INSERT INTO attachment_variants (attachment_id, variant, width, height, mime_type, size_bytes, blob_key)
+				VALUES ($1, $2, $3, $4, $5, $6, $7)`, attachmentID, v.Name, v.Width, v.Height, v.MimeType, v.Size, v.BlobKey)
+			if err != nil {
+				return err
+			}
+		}
+		_, err := tx.ExecContext(ctx, `UPDATE attachments SET processing_status = $1, mime_type = $2, size_bytes = $3, width = $4, height = $5, last_error = ''
+			WHERE attachment_id = $6`, models.ProcessingReady, original.MimeType, original.Size, original.Width, original.Height, attachmentID)
+		return err
+	})
+}
+
+// RetryProcessing records a failed attempt and schedules the next one after
+// delay.
+func (m *AttachmentManager) RetryProcessing(ctx context.Context, attachmentID, reason string, delay time.Duration) error {
+	query := "UPDATE attachments SET processing_status = $1, last_error = $2, next_attempt_at = NOW() + $3 * INTERVAL '1 second' WHERE attachment_id = $4"
+	_, err := conn(ctx, m.Conn).ExecContext(ctx, query, models.ProcessingPending, reason, delay.Seconds(), attachmentID)
+	return err
+}
+
+// FailProcessing gives up on an image after its last attempt.
+func (m *AttachmentManager) FailProcessing(ctx context.Context, attachmentID, reason string) error {
+	query := "UPDATE attachments SET processing_status = $1, last_error = $2 WHERE attachment_id = $3"
+	_, err := conn(ctx, m.Conn).ExecContext(ctx, query, models.ProcessingFailed, reason, attachmentID)
+	return err
+}
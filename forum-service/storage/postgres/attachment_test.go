@@ -0,0 +1,72 @@
+package managers_test
+
+import (
+	"fmt"
+	pb "forum-service/forum-protos/genprotos"
+	"forum-service/models"
+	managers "forum-service/storage/postgres"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetAttachmentByID(t *testing.T) {
+	fmt.Println("Testing get attachment...")
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	attachmentManager := managers.NewAttachmentManager(db)
+
+	createdAt := time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("SELECT attachment_id, content_type, content_id, user_id, filename, mime_type, size_bytes, processing_status, width, height, created_at, blob_key FROM attachments").
+		WithArgs("att1").
+		WillReturnRows(sqlmock.NewRows([]string{"attachment_id", "content_type", "content_id", "user_id", "filename", "mime_type", "size_bytes", "processing_status", "width", "height", "created_at", "blob_key"}).
+			AddRow("att1", "post", "post1", "user1", "dmesg.log", "text/plain", 2048, "skipped", 0, 0, createdAt, "attachments/att1"))
+	mock.ExpectQuery("SELECT attachment_id, content_type, content_id, user_id, filename, mime_type, size_bytes, processing_status, width, height, created_at, blob_key FROM attachments").
+		WithArgs("missing").
+		WillReturnRows(sqlmock.NewRows([]string{"attachment_id"}))
+
+	a, key, err := attachmentManager.GetByID(ctx, &pb.AttachmentGReqOrDReq{AttachmentId: "att1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "attachments/att1", key)
+	assert.Equal(t, "text/plain", a.MimeType)
+	assert.Equal(t, int64(2048), a.Size)
+	assert.Equal(t, "2024-07-01T12:00:00Z", a.CreatedAt)
+
+	_, _, err = attachmentManager.GetByID(ctx, &pb.AttachmentGReqOrDReq{AttachmentId: "missing"})
+	assert.EqualError(t, err, "attachment not found")
+	assert.NoError(t, mock.ExpectationsWereMet())
+	fmt.Println("OK. Attachment retrieved.")
+}
+
+func TestClaimImageRetry(t *testing.T) {
+	fmt.Println("Testing image processing queue...")
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	attachmentManager := managers.NewAttachmentManager(db)
+
+	mock.ExpectQuery("UPDATE attachments SET processing_status").
+		WithArgs(models.ProcessingInProgress, float64(300), models.ProcessingPending).
+		WillReturnRows(sqlmock.NewRows([]string{"attachment_id"}))
+	mock.ExpectExec("UPDATE attachments SET processing_status").
+		WithArgs(models.ProcessingPending, "decode failed", float64(60), "att1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	a, _, _, err := attachmentManager.ClaimImage(ctx, 5*time.Minute)
+	assert.NoError(t, err)
+	assert.Nil(t, a)
+
+	err = attachmentManager.RetryProcessing(ctx, "att1", "decode failed", time.Minute)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	fmt.Println("OK. Image queue claims and retries.")
+}
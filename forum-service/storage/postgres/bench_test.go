@@ -0,0 +1,209 @@
+package managers_test
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	pb "forum-service/forum-protos/genprotos"
+	"forum-service/migrations"
+	"forum-service/models"
+	managers "forum-service/storage/postgres"
+)
+
+// The benchmarks run the listing queries against a seeded forum:
+//
+//	go test ./storage/postgres -run '^$' -bench . -benchtime 200x
+//
+// BENCH_POSTS sets the volume, 50000 posts by default, with four comments
+// and two tags per post on average. The data goes into its own schema,
+// forum_bench, migrated like the real one, so the tables the other tests
+// use stay small. It is seeded once and kept so later runs start at once;
+// `make bench_clean` drops it, which is also how to change the volume.
+const benchSchema = "forum_bench"
+
+var (
+	seedOnce     sync.Once
+	seedErr      error
+	benchDB      *sql.DB
+	benchCatID   string
+	benchUserID  string
+	benchHotPost string
+)
+
+const (
+	benchCategories = 20
+	benchUsers      = 2000
+)
+
+func seedBench(b *testing.B) {
+	seedOnce.Do(func() {
+		posts := 50000
+		if v, err := strconv.Atoi(os.Getenv("BENCH_POSTS")); err == nil && v > 0 {
+			posts = v
+		}
+		if seedErr = openBench(); seedErr == nil {
+			seedErr = seed(posts)
+		}
+	})
+	if seedErr != nil {
+		b.Fatalf("could not seed the benchmark data: %v", seedErr)
+	}
+}
+
+// openBench connects to the bench schema, creating and migrating it first.
+// Extensions may live in public, so it stays on the search path.
+func openBench() error {
+	if _, err := db.Exec("CREATE SCHEMA IF NOT EXISTS " + benchSchema); err != nil {
+		return err
+	}
+	var err error
+	benchDB, err = sql.Open("postgres", connStr+" search_path="+benchSchema+",public")
+	if err != nil {
+		return err
+	}
+	m, err := migrations.New(benchDB)
+	if err != nil {
+		return err
+	}
+	m.Logf = func(string, ...interface{}) {}
+	return m.Up(ctx)
+}
+
+func seed(posts int) error {
+	var seeded bool
+	if err := benchDB.QueryRow("SELECT EXISTS (SELECT 1 FROM categories WHERE name = 'bench-1')").Scan(&seeded); err != nil {
+		return err
+	}
+	if !seeded {
+		fmt.Printf("Seeding %d posts for the benchmarks...\n", posts)
+		steps := []string{
+			`INSERT INTO categories (category_id, name, qa)
+				SELECT md5('bench-category-' || i)::uuid, 'bench-' || i, i % 5 = 0 FROM generate_series(1, $1) i`,
+			// Most posts are live and published; every 20th is deleted, every
+			// 40th waits for review, a few are pinned. Tags follow a long tail.
+			`INSERT INTO posts (post_id, user_id, title, body, category_id, tags, status, pinned, created_at, deleted_at)
+				SELECT md5('bench-post-' || i)::uuid, md5('bench-user-' || i % $2)::uuid, 'bench post ' || i, repeat('lorem ipsum ', 20),
+					md5('bench-category-' || 1 + i % $3)::uuid,
+					'tag' || floor(pow(random(), 3) * 500)::int || ',tag' || floor(pow(random(), 3) * 500)::int,
+					CASE WHEN i % 40 = 0 THEN 'pending' ELSE 'published' END,
+					i % 500 = 0,
+					NOW() - make_interval(secs => i * 60),
+					CASE WHEN i % 20 = 0 THEN EXTRACT(EPOCH FROM NOW())::bigint ELSE 0 END
+				FROM generate_series(1, $1) i`,
+			`INSERT INTO tags (tag, post_id)
+				SELECT t, post_id FROM posts, unnest(string_to_array(tags, ',')) t WHERE title LIKE 'bench post %'`,
+			// Every 100th comment goes to the first post, a long hot thread.
+			`INSERT INTO comments (comment_id, user_id, post_id, body, created_at, deleted_at)
+				SELECT md5('bench-comment-' || i)::uuid, md5('bench-user-' || i % $2)::uuid,
+					md5('bench-post-' || CASE WHEN i % 100 = 0 THEN 1 ELSE 1 + i % $1 END)::uuid,
+					'bench comment ' || i,
+					NOW() - make_interval(secs => $1 * 4 * 60 - i),
+					CASE WHEN i % 25 = 0 THEN EXTRACT(EPOCH FROM NOW())::bigint ELSE 0 END
+				FROM generate_series(1, $1 * 4) i`,
+			"ANALYZE categories, posts, comments, tags",
+		}
+		args := [][]interface{}{
+			{benchCategories},
+			{posts, benchUsers, benchCategories},
+			nil,
+			{posts, benchUsers},
+			nil,
+		}
+		tx, err := benchDB.Begin()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+		for i, query := range steps {
+			if _, err := tx.Exec(query, args[i]...); err != nil {
+				return err
+			}
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		// The rows went in behind the managers' backs.
+		if _, err := managers.NewCounterManager(benchDB).Repair(ctx); err != nil {
+			return err
+		}
+	}
+
+	return benchDB.QueryRow("SELECT md5('bench-category-1')::uuid, md5('bench-user-1')::uuid, md5('bench-post-1')::uuid").
+		Scan(&benchCatID, &benchUserID, &benchHotPost)
+}
+
+// benchQuery runs query b.N times and reports the median and 95th
+// percentile latency next to the mean.
+func benchQuery(b *testing.B, query func() error) {
+	seedBench(b)
+	latencies := make([]time.Duration, 0, b.N)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		start := time.Now()
+		if err := query(); err != nil {
+			b.Fatal(err)
+		}
+		latencies = append(latencies, time.Since(start))
+	}
+	b.StopTimer()
+
+	slices.Sort(latencies)
+	percentile := func(p int) float64 {
+		return float64(latencies[(len(latencies)-1)*p/100].Microseconds()) / 1000
+	}
+	b.ReportMetric(percentile(50), "p50-ms")
+	b.ReportMetric(percentile(95), "p95-ms")
+}
+
+func benchPosts(b *testing.B, filter *pb.PostFilter, pagination *pb.Pagination) {
+	m := managers.NewPostManager(benchDB, nil, nil)
+	benchQuery(b, func() error {
+		_, err := m.GetAll(ctx, &pb.PostGAReq{Filter: filter, Pagination: pagination})
+		return err
+	})
+}
+
+func BenchmarkGetAllPostsNewest(b *testing.B) {
+	benchPosts(b, &pb.PostFilter{}, &pb.Pagination{Limit: 20})
+}
+
+func BenchmarkGetAllPostsDeepPage(b *testing.B) {
+	benchPosts(b, &pb.PostFilter{}, &pb.Pagination{Limit: 20, Offset: 2000})
+}
+
+func BenchmarkGetAllPostsByCategory(b *testing.B) {
+	seedBench(b)
+	benchPosts(b, &pb.PostFilter{CategoryId: benchCatID}, &pb.Pagination{Limit: 20})
+}
+
+func BenchmarkGetAllPostsByUser(b *testing.B) {
+	seedBench(b)
+	benchPosts(b, &pb.PostFilter{UserId: benchUserID}, &pb.Pagination{Limit: 20})
+}
+
+func BenchmarkGetAllPostsByTag(b *testing.B) {
+	benchPosts(b, &pb.PostFilter{Tags: "tag42"}, &pb.Pagination{Limit: 20})
+}
+
+func BenchmarkGetAllCommentsHotThread(b *testing.B) {
+	seedBench(b)
+	m := managers.NewCommentManager(benchDB)
+	benchQuery(b, func() error {
+		_, err := m.GetAll(ctx, &pb.CommentGAReq{Filter: &pb.CommentFilter{PostId: benchHotPost}, Pagination: &pb.Pagination{Limit: 50}}, models.Viewer{})
+		return err
+	})
+}
+
+func BenchmarkGetPopularTags(b *testing.B) {
+	m := managers.NewTagManager(benchDB)
+	benchQuery(b, func() error {
+		_, err := m.GetPopular(ctx, &pb.Pagination{Limit: 10})
+		return err
+	})
+}
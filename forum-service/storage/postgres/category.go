@@ -1,43 +1,46 @@
 package managers
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	pb "forum-service/forum-protos/genprotos"
 )
 
 type CategoryManager struct {
-	Conn *sql.DB
+	Conn     *sql.DB
+	Replicas *Replicas // for reads, if set
 }
 
 func NewCategoryManager(conn *sql.DB) *CategoryManager {
 	return &CategoryManager{Conn: conn}
 }
 
-func (m *CategoryManager) Create(category *pb.CategoryCReqOrCResOrGResOrUReqOrURes) (*pb.CategoryCReqOrCResOrGResOrUReqOrURes, error) {
-	query := "INSERT INTO categories (category_id, name) VALUES ($1, $2) RETURNING category_id, name"
+func (m *CategoryManager) Create(ctx context.Context, category *pb.CategoryCReqOrCResOrGResOrUReqOrURes) (*pb.CategoryCReqOrCResOrGResOrUReqOrURes, error) {
+	query := "INSERT INTO categories (category_id, name, qa) VALUES ($1, $2, $3) RETURNING category_id, name, qa"
 	cat := &pb.CategoryCReqOrCResOrGResOrUReqOrURes{}
-	err := m.Conn.QueryRow(query, category.CategoryId, category.Name).Scan(&cat.CategoryId, &cat.Name)
+	err := conn(ctx, m.Conn).QueryRowContext(ctx, query, category.CategoryId, category.Name, category.Qa).Scan(&cat.CategoryId, &cat.Name, &cat.Qa)
 	if err != nil {
 		return nil, err
 	}
 	return cat, nil
 }
 
-func (m *CategoryManager) Update(category *pb.CategoryCReqOrCResOrGResOrUReqOrURes) (*pb.CategoryCReqOrCResOrGResOrUReqOrURes, error) {
-	query := "UPDATE categories SET name = $1, updated_at = NOW() WHERE category_id = $2 RETURNING category_id, name"
+// Update renames a category. qa is left as it is unless the request sets it.
+func (m *CategoryManager) Update(ctx context.Context, category *pb.CategoryUReq) (*pb.CategoryCReqOrCResOrGResOrUReqOrURes, error) {
+	query := "UPDATE categories SET name = $1, qa = COALESCE($2, qa), updated_at = NOW() WHERE category_id = $3 RETURNING category_id, name, qa"
 	cat := &pb.CategoryCReqOrCResOrGResOrUReqOrURes{}
-	err := m.Conn.QueryRow(query, category.Name, category.CategoryId).Scan(&cat.CategoryId, &cat.Name)
+	err := conn(ctx, m.Conn).QueryRowContext(ctx, query, category.Name, category.Qa, category.CategoryId).Scan(&cat.CategoryId, &cat.Name, &cat.Qa)
 	if err != nil {
 		return nil, err
 	}
 	return cat, nil
 }
 
-func (m *CategoryManager) GetByID(req *pb.CategoryGReqOrDReq) (*pb.CategoryCReqOrCResOrGResOrUReqOrURes, error) {
-	query := "SELECT category_id, name FROM categories WHERE category_id = $1 AND deleted_at = 0"
+func (m *CategoryManager) GetByID(ctx context.Context, req *pb.CategoryGReqOrDReq) (*pb.CategoryCReqOrCResOrGResOrUReqOrURes, error) {
+	query := "SELECT category_id, name, qa FROM categories WHERE category_id = $1 AND deleted_at = 0"
 	cat := &pb.CategoryCReqOrCResOrGResOrUReqOrURes{}
-	err := m.Conn.QueryRow(query, req.CategoryId).Scan(&cat.CategoryId, &cat.Name)
+	err := reader(ctx, m.Conn, m.Replicas).QueryRowContext(ctx, query, req.CategoryId).Scan(&cat.CategoryId, &cat.Name, &cat.Qa)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("category not found")
@@ -47,17 +50,17 @@ func (m *CategoryManager) GetByID(req *pb.CategoryGReqOrDReq) (*pb.CategoryCReqO
 	return cat, nil
 }
 
-func (m *CategoryManager) Delete(req *pb.CategoryGReqOrDReq) (*pb.Void, error) {
+func (m *CategoryManager) Delete(ctx context.Context, req *pb.CategoryGReqOrDReq) (*pb.Void, error) {
 	query := "UPDATE categories SET deleted_at = EXTRACT(EPOCH FROM NOW()) WHERE category_id = $1"
-	_, err := m.Conn.Exec(query, req.CategoryId)
+	_, err := conn(ctx, m.Conn).ExecContext(ctx, query, req.CategoryId)
 	if err != nil {
 		return nil, err
 	}
 	return nil, nil
 }
 
-func (m *CategoryManager) GetAll(req *pb.CategoryGAReq) (*pb.CategoryGARes, error) {
-	query := "SELECT category_id, name FROM categories WHERE deleted_at = 0"
+func (m *CategoryManager) GetAll(ctx context.Context, req *pb.CategoryGAReq) (*pb.CategoryGARes, error) {
+	query := "SELECT category_id, name, qa FROM categories WHERE deleted_at = 0"
 	var args []interface{}
 	var paramInex = 1
 	if req.Filter.CategoryId != "" {
@@ -76,7 +79,7 @@ func (m *CategoryManager) GetAll(req *pb.CategoryGAReq) (*pb.CategoryGARes, erro
 		args = append(args, req.Pagination.Offset)
 		paramInex++
 	}
-	rows, err := m.Conn.Query(query, args...)
+	rows, err := reader(ctx, m.Conn, m.Replicas).QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -85,7 +88,7 @@ func (m *CategoryManager) GetAll(req *pb.CategoryGAReq) (*pb.CategoryGARes, erro
 	categories := &pb.CategoryGARes{}
 	for rows.Next() {
 		cat := &pb.CategoryCReqOrCResOrGResOrUReqOrURes{}
-		if err := rows.Scan(&cat.CategoryId, &cat.Name); err != nil {
+		if err := rows.Scan(&cat.CategoryId, &cat.Name, &cat.Qa); err != nil {
 			return nil, err
 		}
 		categories.Categories = append(categories.Categories, cat)
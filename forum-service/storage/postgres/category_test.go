@@ -1,6 +1,7 @@
 package managers_test
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
@@ -10,11 +11,14 @@ import (
 	pb "forum-service/forum-protos/genprotos"
 	managers "forum-service/storage/postgres"
 
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/google/uuid"
 	_ "github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 )
 
+var ctx = context.Background()
+var connStr = fmt.Sprintf("host=%s user=%s dbname=%s password=%s port=%d sslmode=disable", "localhost", "mrbek", "forum_db", "QodirovCoder", 5432)
 var db *sql.DB
 var categoryManager *managers.CategoryManager
 var commentManager *managers.CommentManager
@@ -27,7 +31,6 @@ var postId string
 
 func TestMain(m *testing.M) {
 	fmt.Println("Connecting to the database...")
-	connStr := fmt.Sprintf("host=%s user=%s dbname=%s password=%s port=%d sslmode=disable", "localhost", "mrbek", "forum_db", "QodirovCoder", 5432)
 	var err error
 	db, err = sql.Open("postgres", connStr)
 	if err != nil {
@@ -55,7 +58,7 @@ func TestCreateCategory(t *testing.T) {
 		Name:       "Test Category",
 	}
 
-	cat, err := categoryManager.Create(category)
+	cat, err := categoryManager.Create(ctx, category)
 	assert.NoError(t, err)
 	assert.NotNil(t, cat)
 	fmt.Println("OK. Category created successfully")
@@ -63,12 +66,12 @@ func TestCreateCategory(t *testing.T) {
 
 func TestUpdateCategory(t *testing.T) {
 	fmt.Println("Testing update category...")
-	category := &pb.CategoryCReqOrCResOrGResOrUReqOrURes{
+	category := &pb.CategoryUReq{
 		CategoryId: categoryId,
 		Name:       "Updated Category",
 	}
 
-	cat, err := categoryManager.Update(category)
+	cat, err := categoryManager.Update(ctx, category)
 	assert.NoError(t, err)
 	assert.NotNil(t, cat)
 	assert.Equal(t, category.CategoryId, cat.CategoryId)
@@ -76,13 +79,43 @@ func TestUpdateCategory(t *testing.T) {
 	fmt.Println("OK. Category updated successfully")
 }
 
+func TestUpdateCategoryKeepsQa(t *testing.T) {
+	fmt.Println("Testing a rename of a Q&A category...")
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	categoryManager := managers.NewCategoryManager(db)
+
+	mock.ExpectQuery("UPDATE categories SET name = \\$1, qa = COALESCE\\(\\$2, qa\\)").
+		WithArgs("Renamed", nil, "category1").
+		WillReturnRows(sqlmock.NewRows([]string{"category_id", "name", "qa"}).AddRow("category1", "Renamed", true))
+
+	cat, err := categoryManager.Update(ctx, &pb.CategoryUReq{CategoryId: "category1", Name: "Renamed"})
+	assert.NoError(t, err)
+	assert.True(t, cat.Qa)
+
+	qa := false
+	mock.ExpectQuery("UPDATE categories SET name = \\$1, qa = COALESCE\\(\\$2, qa\\)").
+		WithArgs("Renamed", false, "category1").
+		WillReturnRows(sqlmock.NewRows([]string{"category_id", "name", "qa"}).AddRow("category1", "Renamed", false))
+
+	cat, err = categoryManager.Update(ctx, &pb.CategoryUReq{CategoryId: "category1", Name: "Renamed", Qa: &qa})
+	assert.NoError(t, err)
+	assert.False(t, cat.Qa)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	fmt.Println("OK. qa only changed when it was sent.")
+}
+
 func TestGetCategoryByID(t *testing.T) {
 	fmt.Println("Testing get category by ID...")
 	req := &pb.CategoryGReqOrDReq{
 		CategoryId: categoryId,
 	}
 
-	cat, err := categoryManager.GetByID(req)
+	cat, err := categoryManager.GetByID(ctx, req)
 	assert.NoError(t, err)
 	assert.NotNil(t, cat)
 	assert.Equal(t, req.CategoryId, cat.CategoryId)
@@ -95,10 +128,10 @@ func TestDeleteCategory(t *testing.T) {
 		CategoryId: categoryId,
 	}
 
-	_, err := categoryManager.Delete(req)
+	_, err := categoryManager.Delete(ctx, req)
 	assert.NoError(t, err)
 
-	cat, err := categoryManager.GetByID(req)
+	cat, err := categoryManager.GetByID(ctx, req)
 	assert.Error(t, err)
 	assert.Nil(t, cat)
 	fmt.Println("OK. Category deleted successfully")
@@ -114,7 +147,7 @@ func TestGetAllCategories(t *testing.T) {
 		},
 	}
 
-	cats, err := categoryManager.GetAll(req)
+	cats, err := categoryManager.GetAll(ctx, req)
 	assert.NoError(t, err)
 	assert.NotNil(t, cats)
 	assert.True(t, cats.Count > 0)
@@ -1,43 +1,84 @@
 package managers
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	pb "forum-service/forum-protos/genprotos"
+	"forum-service/models"
 )
 
+// acceptedColumn is true for the comment a question's author accepted.
+const acceptedColumn = "EXISTS (SELECT 1 FROM posts p WHERE p.post_id = comments.post_id AND p.accepted_comment_id = comments.comment_id) AS accepted"
+
 type CommentManager struct {
-	Conn *sql.DB
+	Conn     *sql.DB
+	Replicas *Replicas // for reads, if set
+	tx       *Transactor
 }
 
 func NewCommentManager(conn *sql.DB) *CommentManager {
-	return &CommentManager{Conn: conn}
+	return &CommentManager{Conn: conn, tx: NewTransactor(conn)}
 }
 
-func (m *CommentManager) Create(comment *pb.CommentCReqOrCResOrGResOrURes) (*pb.CommentCReqOrCResOrGResOrURes, error) {
-	query := "INSERT INTO comments (comment_id, user_id, post_id, body) VALUES ($1, $2, $3, $4) RETURNING comment_id, user_id, post_id, body"
+func (m *CommentManager) Create(ctx context.Context, comment *pb.CommentCReqOrCResOrGResOrURes) (*pb.CommentCReqOrCResOrGResOrURes, error) {
+	status := comment.Status
+	if status == "" {
+		status = models.StatusPublished
+	}
 	com := &pb.CommentCReqOrCResOrGResOrURes{}
-	err := m.Conn.QueryRow(query, comment.CommentId, comment.UserId, comment.PostId, comment.Body).Scan(&com.CommentId, &com.UserId, &com.PostId, &com.Body)
+	err := m.tx.InTx(ctx, func(ctx context.Context) error {
+		// The share lock holds off a moderator locking the post until the
+		// comment is in.
+		var locked bool
+		err := conn(ctx, m.Conn).QueryRowContext(ctx, "SELECT locked FROM posts WHERE post_id = $1 AND deleted_at = 0 FOR SHARE", comment.PostId).Scan(&locked)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("post not found")
+			}
+			return err
+		}
+		if locked {
+			return models.ErrPostLocked
+		}
+		query := "INSERT INTO comments (comment_id, user_id, post_id, body, status) VALUES ($1, $2, $3, $4, $5) RETURNING comment_id, user_id, post_id, body, status, revision"
+		err = conn(ctx, m.Conn).QueryRowContext(ctx, query, comment.CommentId, comment.UserId, comment.PostId, comment.Body, status).Scan(&com.CommentId, &com.UserId, &com.PostId, &com.Body, &com.Status, &com.Revision)
+		if err != nil {
+			return err
+		}
+		// Held comments count once they are approved.
+		if com.Status != models.StatusPublished {
+			return nil
+		}
+		return addComments(ctx, conn(ctx, m.Conn), com.PostId, 1)
+	})
 	if err != nil {
 		return nil, err
 	}
 	return com, nil
 }
 
-func (m *CommentManager) Update(comment *pb.CommentUReq) (*pb.CommentCReqOrCResOrGResOrURes, error) {
-	query := "UPDATE comments SET body = $1, updated_at = NOW() WHERE comment_id = $2 RETURNING comment_id, user_id, post_id, body"
+// Update replaces a comment's body and stores the status moderation gave
+// the new text, keeping the post's comment count right.
+func (m *CommentManager) Update(ctx context.Context, comment *pb.CommentUReq, status string) (*pb.CommentCReqOrCResOrGResOrURes, error) {
 	com := &pb.CommentCReqOrCResOrGResOrURes{}
-	err := m.Conn.QueryRow(query, comment.Body, comment.CommentId).Scan(&com.CommentId, &com.UserId, &com.PostId, &com.Body)
+	err := m.tx.InTx(ctx, func(ctx context.Context) error {
+		tx := conn(ctx, m.Conn)
+		return keepCommentCount(ctx, tx, comment.CommentId, func() error {
+			query := "UPDATE comments SET body = $1, status = $2, revision = CASE WHEN body = $1 THEN revision ELSE revision + 1 END, updated_at = NOW() WHERE comment_id = $3 RETURNING comment_id, user_id, post_id, body, status, revision"
+			return tx.QueryRowContext(ctx, query, comment.Body, status, comment.CommentId).Scan(&com.CommentId, &com.UserId, &com.PostId, &com.Body, &com.Status, &com.Revision)
+		})
+	})
 	if err != nil {
 		return nil, err
 	}
 	return com, nil
 }
 
-func (m *CommentManager) GetByID(req *pb.CommentGReqOrDReq) (*pb.CommentCReqOrCResOrGResOrURes, error) {
-	query := "SELECT comment_id, user_id, post_id, body FROM comments WHERE comment_id = $1"
+func (m *CommentManager) GetByID(ctx context.Context, req *pb.CommentGReqOrDReq) (*pb.CommentCReqOrCResOrGResOrURes, error) {
+	query := "SELECT comment_id, user_id, post_id, body, status, revision, " + acceptedColumn + " FROM comments WHERE comment_id = $1 AND deleted_at = 0"
 	com := &pb.CommentCReqOrCResOrGResOrURes{}
-	err := m.Conn.QueryRow(query, req.CommentId).Scan(&com.CommentId, &com.UserId, &com.PostId, &com.Body)
+	err := reader(ctx, m.Conn, m.Replicas).QueryRowContext(ctx, query, req.CommentId).Scan(&com.CommentId, &com.UserId, &com.PostId, &com.Body, &com.Status, &com.Revision, &com.Accepted)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("comment not found")
@@ -47,58 +88,129 @@ func (m *CommentManager) GetByID(req *pb.CommentGReqOrDReq) (*pb.CommentCReqOrCR
 	return com, nil
 }
 
-func (m *CommentManager) DeleteByPostID(tx *sql.Tx, req *pb.CommentGReqOrDReqByPostID) (*pb.Void, error) {
-	query := "UPDATE comments SET deleted_at = EXTRACT(EPOCH FROM NOW()) WHERE post_id = $1"
-	_, err := tx.Exec(query, req.PostId)
+func (m *CommentManager) DeleteByPostID(ctx context.Context, req *pb.CommentGReqOrDReqByPostID) (*pb.Void, error) {
+	err := m.tx.InTx(ctx, func(ctx context.Context) error {
+		query := "UPDATE comments SET deleted_at = EXTRACT(EPOCH FROM NOW()) WHERE post_id = $1 AND deleted_at = 0"
+		if _, err := conn(ctx, m.Conn).ExecContext(ctx, query, req.PostId); err != nil {
+			return err
+		}
+		query = "UPDATE posts SET comment_count = 0, last_activity_at = " + lastActivity + " WHERE post_id = $2"
+		_, err := conn(ctx, m.Conn).ExecContext(ctx, query, models.StatusPublished, req.PostId)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 	return nil, nil
 }
 
-func (m *CommentManager) Delete(tx *sql.Tx, req *pb.CommentGReqOrDReq) (*pb.Void, error) {
-	query := "UPDATE comments SET deleted_at = EXTRACT(EPOCH FROM NOW()) WHERE comment_id = $1"
-	_, err := tx.Exec(query, req.CommentId)
+func (m *CommentManager) Delete(ctx context.Context, req *pb.CommentGReqOrDReq) (*pb.Void, error) {
+	err := m.tx.InTx(ctx, func(ctx context.Context) error {
+		var postID, status string
+		query := "UPDATE comments SET deleted_at = EXTRACT(EPOCH FROM NOW()) WHERE comment_id = $1 AND deleted_at = 0 RETURNING COALESCE(post_id::text, ''), status"
+		err := conn(ctx, m.Conn).QueryRowContext(ctx, query, req.CommentId).Scan(&postID, &status)
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if status == models.StatusPublished {
+			if err := addComments(ctx, conn(ctx, m.Conn), postID, -1); err != nil {
+				return err
+			}
+		}
+		// A deleted comment no longer answers its question.
+		_, err = conn(ctx, m.Conn).ExecContext(ctx, "UPDATE posts SET accepted_comment_id = NULL WHERE accepted_comment_id = $1", req.CommentId)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 	return &pb.Void{}, nil
 }
 
-func (m *CommentManager) GetAll(req *pb.CommentGAReq) (*pb.CommentGARes, error) {
-	query := "SELECT comment_id, user_id, post_id, body FROM comments WHERE deleted_at = 0"
+func (m *CommentManager) GetAll(ctx context.Context, req *pb.CommentGAReq, viewer models.Viewer) (*pb.CommentGARes, error) {
+	status := req.Filter.Status
+	if status == "" {
+		status = models.StatusPublished
+	}
+	limit, offset := req.Pagination.Limit, req.Pagination.Offset
+	comments := &pb.CommentGARes{}
+
+	// The accepted answer leads its thread. It is read on its own so the rest
+	// of the thread pages by created_at alone, in comments_post_listing_idx
+	// order, instead of sorting the whole thread for every page.
+	var acceptedID string
+	if req.Filter.PostId != "" {
+		accepted, err := m.accepted(ctx, req.Filter, status, viewer)
+		if err != nil {
+			return nil, err
+		}
+		if accepted != nil {
+			acceptedID = accepted.CommentId
+			if offset == 0 {
+				comments.Comments = append(comments.Comments, accepted)
+				comments.Count++
+				if limit == 1 {
+					return comments, nil
+				}
+				if limit > 0 {
+					limit--
+				}
+			} else {
+				offset--
+			}
+		}
+	}
+
+	// Joining the posts once is cheaper than acceptedColumn's lookup per row.
+	query := `SELECT c.comment_id, c.user_id, c.post_id, c.body, c.revision, COALESCE(p.accepted_comment_id = c.comment_id, FALSE) AS accepted
+		FROM comments c JOIN posts p ON p.post_id = c.post_id WHERE c.deleted_at = 0`
 	var args []interface{}
 	paramIndex := 1
+	query += fmt.Sprintf(" AND c.status = $%d", paramIndex)
+	args = append(args, status)
+	paramIndex++
+	visible, visibleArgs := visiblePost(viewer, paramIndex)
+	query += visible
+	args = append(args, visibleArgs...)
+	paramIndex += len(visibleArgs)
 	if req.Filter.PostId != "" {
-		query += fmt.Sprintf(" AND post_id = $%d", paramIndex)
+		query += fmt.Sprintf(" AND c.post_id = $%d", paramIndex)
 		args = append(args, req.Filter.PostId)
 		paramIndex++
 	}
 	if req.Filter.UserId != "" {
-		query += fmt.Sprintf(" AND user_id = $%d", paramIndex)
+		query += fmt.Sprintf(" AND c.user_id = $%d", paramIndex)
 		args = append(args, req.Filter.UserId)
 		paramIndex++
 	}
-	if req.Pagination.Limit != 0 {
+	if acceptedID != "" {
+		query += fmt.Sprintf(" AND c.comment_id <> $%d", paramIndex)
+		args = append(args, acceptedID)
+		paramIndex++
+	}
+	query += " ORDER BY c.created_at"
+	if limit != 0 {
 		query += fmt.Sprintf(" LIMIT $%d", paramIndex)
-		args = append(args, req.Pagination.Limit)
+		args = append(args, limit)
 		paramIndex++
 	}
-	if req.Pagination.Offset != 0 {
+	if offset != 0 {
 		query += fmt.Sprintf(" OFFSET $%d", paramIndex)
-		args = append(args, req.Pagination.Offset)
+		args = append(args, offset)
 		paramIndex++
 	}
-	rows, err := m.Conn.Query(query, args...)
+	rows, err := reader(ctx, m.Conn, m.Replicas).QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	comments := &pb.CommentGARes{}
 	for rows.Next() {
 		com := &pb.CommentCReqOrCResOrGResOrURes{}
-		if err := rows.Scan(&com.CommentId, &com.UserId, &com.PostId, &com.Body); err != nil {
+		if err := rows.Scan(&com.CommentId, &com.UserId, &com.PostId, &com.Body, &com.Revision, &com.Accepted); err != nil {
 			return nil, err
 		}
 		comments.Comments = append(comments.Comments, com)
@@ -110,3 +222,43 @@ func (m *CommentManager) GetAll(req *pb.CommentGAReq) (*pb.CommentGARes, error)
 
 	return comments, nil
 }
+
+// accepted returns the accepted answer of filter's post if it matches the
+// filter, or nil.
+func (m *CommentManager) accepted(ctx context.Context, filter *pb.CommentFilter, status string, viewer models.Viewer) (*pb.CommentCReqOrCResOrGResOrURes, error) {
+	query := `SELECT c.comment_id, c.user_id, c.post_id, c.body, c.revision
+		FROM posts p JOIN comments c ON c.comment_id = p.accepted_comment_id
+		WHERE p.post_id = $1 AND c.deleted_at = 0 AND c.status = $2`
+	args := []interface{}{filter.PostId, status}
+	if filter.UserId != "" {
+		query += " AND c.user_id = $3"
+		args = append(args, filter.UserId)
+	}
+	visible, visibleArgs := visiblePost(viewer, len(args)+1)
+	query += visible
+	args = append(args, visibleArgs...)
+	com := &pb.CommentCReqOrCResOrGResOrURes{Accepted: true}
+	err := reader(ctx, m.Conn, m.Replicas).QueryRowContext(ctx, query, args...).
+		Scan(&com.CommentId, &com.UserId, &com.PostId, &com.Body, &com.Revision)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return com, nil
+}
+
+// visiblePost limits a query over posts p to those viewer can see, with
+// parameters numbered from paramIndex.
+func visiblePost(viewer models.Viewer, paramIndex int) (string, []interface{}) {
+	switch {
+	case viewer.Moderator:
+		return " AND p.deleted_at = 0", nil
+	case viewer.UserID != "":
+		return fmt.Sprintf(" AND p.deleted_at = 0 AND (p.status = $%d OR p.user_id = $%d)", paramIndex, paramIndex+1),
+			[]interface{}{models.StatusPublished, viewer.UserID}
+	default:
+		return fmt.Sprintf(" AND p.deleted_at = 0 AND p.status = $%d", paramIndex), []interface{}{models.StatusPublished}
+	}
+}
@@ -1,11 +1,15 @@
 package managers_test
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
 	pb "forum-service/forum-protos/genprotos"
+	"forum-service/models"
+	managers "forum-service/storage/postgres"
 
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/google/uuid"
 	_ "github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
@@ -25,7 +29,7 @@ func TestCreateComment(t *testing.T) {
 		Body:      "Test Comment",
 	}
 
-	com, err := commentManager.Create(comment)
+	com, err := commentManager.Create(ctx, comment)
 	assert.NoError(t, err)
 	assert.NotNil(t, com)
 	assert.Equal(t, comment.CommentId, com.CommentId)
@@ -42,7 +46,7 @@ func TestUpdateComment(t *testing.T) {
 		Body:      "Updated Comment",
 	}
 
-	com, err := commentManager.Update(comment)
+	com, err := commentManager.Update(ctx, comment, "published")
 	assert.NoError(t, err)
 	assert.NotNil(t, com)
 	assert.Equal(t, comment.CommentId, com.CommentId)
@@ -56,7 +60,7 @@ func TestGetCommentByID(t *testing.T) {
 		CommentId: commentId,
 	}
 
-	com, err := commentManager.GetByID(req)
+	com, err := commentManager.GetByID(ctx, req)
 	assert.NoError(t, err)
 	assert.NotNil(t, com)
 	assert.Equal(t, req.CommentId, com.CommentId)
@@ -68,21 +72,10 @@ func TestDeleteComment(t *testing.T) {
 	req := &pb.CommentGReqOrDReq{
 		CommentId: commentId,
 	}
-	tx, err := commentManager.Conn.Begin()
-	if err != nil {
-		t.Fatalf("could not begin transaction: %v", err)
-	}
-
-	_, err = commentManager.Delete(tx, req)
-	if err != nil {
-		tx.Rollback()
-		t.Fatalf("could not delete comment: %v", err)
-	}
-
-	err = tx.Commit()
-	if err != nil {
-		t.Fatalf("could not commit transaction: %v", err)
-	}
+	err := managers.NewTransactor(db).InTx(ctx, func(ctx context.Context) error {
+		_, err := commentManager.Delete(ctx, req)
+		return err
+	})
 	assert.NoError(t, err)
 	fmt.Println("OK. Comment deleted successfully.")
 }
@@ -97,12 +90,87 @@ func TestGetAllComments(t *testing.T) {
 		},
 	}
 
-	comments, err := commentManager.GetAll(req)
+	comments, err := commentManager.GetAll(ctx, req, models.Viewer{})
 	assert.NoError(t, err)
 	assert.NotNil(t, comments)
 	fmt.Println("OK. Comments retrieved successfully.")
 }
 
+func TestCreateCommentOnLockedPost(t *testing.T) {
+	fmt.Println("Testing a comment on a locked post...")
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT locked FROM posts WHERE post_id = \\$1 AND deleted_at = 0 FOR SHARE").
+		WithArgs("post1").
+		WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(true))
+	mock.ExpectRollback()
+
+	_, err = managers.NewCommentManager(db).Create(ctx, &pb.CommentCReqOrCResOrGResOrURes{CommentId: uuid.NewString(), UserId: "user1", PostId: "post1", Body: "late"})
+	assert.Equal(t, models.ErrPostLocked, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	fmt.Println("OK. The comment was refused.")
+}
+
+func TestGetAllCommentsAcceptedFirst(t *testing.T) {
+	fmt.Println("Testing a thread with an accepted answer...")
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	commentManager := managers.NewCommentManager(db)
+	columns := []string{"comment_id", "user_id", "post_id", "body", "revision", "accepted"}
+	page := func(limit, offset int64) []string {
+		comments, err := commentManager.GetAll(ctx, &pb.CommentGAReq{
+			Filter:     &pb.CommentFilter{PostId: "post1"},
+			Pagination: &pb.Pagination{Limit: limit, Offset: offset},
+		}, models.Viewer{})
+		assert.NoError(t, err)
+		var ids []string
+		for _, c := range comments.Comments {
+			ids = append(ids, c.CommentId)
+		}
+		return ids
+	}
+	expectAccepted := func() {
+		mock.ExpectQuery("SELECT (.+) FROM posts p JOIN comments c ON c.comment_id = p.accepted_comment_id").
+			WithArgs("post1", "published", "published").
+			WillReturnRows(sqlmock.NewRows(columns[:5]).AddRow("c3", "user1", "post1", "answer", 1))
+	}
+
+	// The first page starts with the accepted answer and fills up from the
+	// rest of the thread, oldest first.
+	expectAccepted()
+	mock.ExpectQuery("AND p.deleted_at = 0 AND p.status = \\$2 AND c.post_id = \\$3 AND c.comment_id <> \\$4 ORDER BY c.created_at LIMIT \\$5$").
+		WithArgs("published", "published", "post1", "c3", int64(1)).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow("c1", "user2", "post1", "first", 1, false))
+	assert.Equal(t, []string{"c3", "c1"}, page(2, 0))
+
+	// Later pages are shifted by the answer already shown.
+	expectAccepted()
+	mock.ExpectQuery("AND c.comment_id <> \\$4 ORDER BY c.created_at LIMIT \\$5 OFFSET \\$6$").
+		WithArgs("published", "published", "post1", "c3", int64(2), int64(1)).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow("c2", "user2", "post1", "second", 1, false))
+	assert.Equal(t, []string{"c2"}, page(2, 2))
+
+	mock.ExpectQuery("SELECT (.+) FROM posts p JOIN comments c ON c.comment_id = p.accepted_comment_id").
+		WithArgs("post1", "published", "published").
+		WillReturnRows(sqlmock.NewRows(columns[:5]))
+	mock.ExpectQuery("AND c.post_id = \\$3 ORDER BY c.created_at LIMIT \\$4$").
+		WithArgs("published", "published", "post1", int64(2)).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow("c1", "user2", "post1", "first", 1, false))
+	assert.Equal(t, []string{"c1"}, page(2, 0))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+	fmt.Println("OK. The accepted answer leads and the rest pages in order.")
+}
+
 func createPost(t *testing.T, postId string) {
 	fmt.Println("Creating post...")
 	query := "INSERT INTO posts (post_id, user_id, title, body) VALUES ($1, $2, $3, $4)"
@@ -0,0 +1,123 @@
+package managers
+
+import (
+	"context"
+	"database/sql"
+	"forum-service/models"
+)
+
+// The counter caches are posts.comment_count and posts.last_activity_at, kept
+// by the comment writes, and tag_counts.usage, kept by the tag writes. A
+// post's comment_count counts its live, published comments; last_activity_at
+// is the newest of its creation and those comments.
+
+// lastActivity recomputes a post's last_activity_at in an UPDATE of posts;
+// $1 is the published status.
+const lastActivity = `COALESCE(GREATEST(posts.created_at, (SELECT MAX(c.created_at) FROM comments c
+	WHERE c.post_id = posts.post_id AND c.deleted_at = 0 AND c.status = $1)), posts.last_activity_at)`
+
+// addComments moves a post's comment_count by delta and refreshes its
+// last_activity_at. Moving the count relative to itself, under the post's
+// row lock, keeps concurrent comments from losing each other's change.
+func addComments(ctx context.Context, q DBTX, postID string, delta int) error {
+	if delta == 0 {
+		return nil
+	}
+	query := "UPDATE posts SET comment_count = comment_count + $2, last_activity_at = " + lastActivity + " WHERE post_id = $3"
+	_, err := q.ExecContext(ctx, query, models.StatusPublished, delta, postID)
+	return err
+}
+
+// countedComment locks a comment and tells its post and whether it counts
+// toward the post's comment_count. A missing comment does not count.
+func countedComment(ctx context.Context, q DBTX, commentID string) (string, bool, error) {
+	query := "SELECT COALESCE(post_id::text, ''), deleted_at = 0 AND status = $2 FROM comments WHERE comment_id = $1 FOR UPDATE"
+	var postID string
+	var counted bool
+	err := q.QueryRowContext(ctx, query, commentID, models.StatusPublished).Scan(&postID, &counted)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	return postID, counted, err
+}
+
+// keepCommentCount runs change, which alters one comment's status or
+// deletion, and moves the comment's post's count to match. It must run in a
+// transaction.
+func keepCommentCount(ctx context.Context, q DBTX, commentID string, change func() error) error {
+	postID, before, err := countedComment(ctx, q, commentID)
+	if err != nil {
+		return err
+	}
+	if err := change(); err != nil {
+		return err
+	}
+	_, after, err := countedComment(ctx, q, commentID)
+	if err != nil {
+		return err
+	}
+	switch {
+	case before && !after:
+		return addComments(ctx, q, postID, -1)
+	case !before && after:
+		return addComments(ctx, q, postID, 1)
+	}
+	return nil
+}
+
+type CounterManager struct {
+	Conn *sql.DB
+	tx   *Transactor
+}
+
+func NewCounterManager(conn *sql.DB) *CounterManager {
+	return &CounterManager{Conn: conn, tx: NewTransactor(conn)}
+}
+
+// Repair recomputes every counter cache from the comments and tags tables
+// and tells how many were wrong. Comment and tag writes wait while it runs,
+// so none slips between the count and the fix.
+func (m *CounterManager) Repair(ctx context.Context) (models.CounterRepair, error) {
+	var repair models.CounterRepair
+	err := m.tx.InTx(ctx, func(ctx context.Context) error {
+		tx := conn(ctx, m.Conn)
+		if _, err := tx.ExecContext(ctx, "LOCK TABLE comments, tags IN SHARE MODE"); err != nil {
+			return err
+		}
+
+		query := `UPDATE posts SET comment_count = x.count, last_activity_at = x.last_activity_at
+			FROM (SELECT p.post_id, COUNT(c.comment_id) AS count,
+					COALESCE(GREATEST(p.created_at, MAX(c.created_at)), p.last_activity_at) AS last_activity_at
+				FROM posts p LEFT JOIN comments c ON c.post_id = p.post_id AND c.deleted_at = 0 AND c.status = $1
+				GROUP BY p.post_id) x
+			WHERE posts.post_id = x.post_id
+				AND (posts.comment_count <> x.count OR posts.last_activity_at <> x.last_activity_at)`
+		res, err := tx.ExecContext(ctx, query, models.StatusPublished)
+		if err != nil {
+			return err
+		}
+		if repair.Posts, err = res.RowsAffected(); err != nil {
+			return err
+		}
+
+		query = `INSERT INTO tag_counts (tag, usage) SELECT tag, COUNT(*) FROM tags GROUP BY tag
+			ON CONFLICT (tag) DO UPDATE SET usage = EXCLUDED.usage WHERE tag_counts.usage <> EXCLUDED.usage`
+		if res, err = tx.ExecContext(ctx, query); err != nil {
+			return err
+		}
+		if repair.Tags, err = res.RowsAffected(); err != nil {
+			return err
+		}
+		query = "UPDATE tag_counts SET usage = 0 WHERE usage <> 0 AND NOT EXISTS (SELECT 1 FROM tags WHERE tags.tag = tag_counts.tag)"
+		if res, err = tx.ExecContext(ctx, query); err != nil {
+			return err
+		}
+		stale, err := res.RowsAffected()
+		repair.Tags += stale
+		return err
+	})
+	if err != nil {
+		return models.CounterRepair{}, err
+	}
+	return repair, nil
+}
@@ -0,0 +1,86 @@
+package managers_test
+
+import (
+	"fmt"
+	pb "forum-service/forum-protos/genprotos"
+	"forum-service/models"
+	managers "forum-service/storage/postgres"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeleteCommentCount(t *testing.T) {
+	fmt.Println("Testing comment count on delete...")
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	commentManager := managers.NewCommentManager(db)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("UPDATE comments SET deleted_at").
+		WithArgs("comment1").
+		WillReturnRows(sqlmock.NewRows([]string{"post_id", "status"}).AddRow("post1", models.StatusPublished))
+	mock.ExpectExec("UPDATE posts SET comment_count = comment_count \\+ \\$2").
+		WithArgs(models.StatusPublished, -1, "post1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE posts SET accepted_comment_id = NULL").
+		WithArgs("comment1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	// Deleting it again changes nothing.
+	mock.ExpectBegin()
+	mock.ExpectQuery("UPDATE comments SET deleted_at").
+		WithArgs("comment1").
+		WillReturnRows(sqlmock.NewRows([]string{"post_id", "status"}))
+	mock.ExpectCommit()
+
+	_, err = commentManager.Delete(ctx, &pb.CommentGReqOrDReq{CommentId: "comment1"})
+	assert.NoError(t, err)
+	_, err = commentManager.Delete(ctx, &pb.CommentGReqOrDReq{CommentId: "comment1"})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	fmt.Println("OK. The count dropped once.")
+}
+
+func TestHideCommentCount(t *testing.T) {
+	fmt.Println("Testing comment count on auto hide...")
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	moderationManager := managers.NewModerationManager(db, managers.NewCommentManager(db))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT user_id, deleted_at <> 0 FROM comments").
+		WithArgs("comment1").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "deleted"}).AddRow("author1", false))
+	mock.ExpectQuery("SELECT COALESCE\\(post_id::text, ''\\), deleted_at = 0 AND status = \\$2 FROM comments").
+		WithArgs("comment1", models.StatusPublished).
+		WillReturnRows(sqlmock.NewRows([]string{"post_id", "counted"}).AddRow("post1", true))
+	mock.ExpectExec("UPDATE comments SET status").
+		WithArgs(models.StatusHidden, "comment1", models.StatusPublished).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT COALESCE\\(post_id::text, ''\\), deleted_at = 0 AND status = \\$2 FROM comments").
+		WithArgs("comment1", models.StatusPublished).
+		WillReturnRows(sqlmock.NewRows([]string{"post_id", "counted"}).AddRow("post1", false))
+	mock.ExpectExec("UPDATE posts SET comment_count = comment_count \\+ \\$2").
+		WithArgs(models.StatusPublished, -1, "post1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO moderation_log").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	hidden, err := moderationManager.AutoHide(ctx, models.ContentComment, "comment1")
+	assert.NoError(t, err)
+	assert.True(t, hidden)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	fmt.Println("OK. Hidden comments stop counting.")
+}
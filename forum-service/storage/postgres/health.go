@@ -0,0 +1,88 @@
+package managers
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"sync"
+	"time"
+)
+
+// maxBackoff caps the wait between connection attempts.
+const maxBackoff = 30 * time.Second
+
+// Connect pings db until it answers, up to attempts times, waiting backoff
+// after the first failure and twice as long after each next one. It is meant
+// for startup, when the database may still be coming up.
+func Connect(ctx context.Context, db *sql.DB, attempts int, backoff time.Duration) error {
+	var err error
+	for i := 1; ; i++ {
+		if err = db.PingContext(ctx); err == nil {
+			return nil
+		}
+		if i >= attempts {
+			return err
+		}
+		log.Printf("Database not ready (attempt %d of %d): %v; retrying in %s", i, attempts, err, backoff)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, maxBackoff)
+	}
+}
+
+// Health remembers whether the database answered its last ping, so
+// readiness checks don't each query it.
+type Health struct {
+	db *sql.DB
+
+	mu  sync.RWMutex
+	err error
+}
+
+// NewHealth starts out healthy, for a db that was just connected.
+func NewHealth(db *sql.DB) *Health {
+	return &Health{db: db}
+}
+
+// Check pings the database and records the result.
+func (h *Health) Check(ctx context.Context) error {
+	err := h.db.PingContext(ctx)
+	h.mu.Lock()
+	was := h.err
+	h.err = err
+	h.mu.Unlock()
+	if (was == nil) != (err == nil) {
+		if err == nil {
+			log.Println("Database is reachable again")
+		} else {
+			log.Printf("Database is unreachable: %v", err)
+		}
+	}
+	return err
+}
+
+// Err is the result of the last check.
+func (h *Health) Err() error {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.err
+}
+
+// Run checks the database every interval until ctx is done.
+func (h *Health) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			checkCtx, cancel := context.WithTimeout(ctx, interval)
+			h.Check(checkCtx)
+			cancel()
+		}
+	}
+}
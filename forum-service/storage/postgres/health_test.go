@@ -0,0 +1,56 @@
+package managers_test
+
+import (
+	"errors"
+	"fmt"
+	managers "forum-service/storage/postgres"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConnectRetries(t *testing.T) {
+	fmt.Println("Testing connect retries...")
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	down := errors.New("connection refused")
+	mock.ExpectPing().WillReturnError(down)
+	mock.ExpectPing().WillReturnError(down)
+	mock.ExpectPing()
+	assert.NoError(t, managers.Connect(ctx, db, 3, time.Millisecond))
+
+	mock.ExpectPing().WillReturnError(down)
+	mock.ExpectPing().WillReturnError(down)
+	assert.ErrorIs(t, managers.Connect(ctx, db, 2, time.Millisecond), down)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	fmt.Println("OK. Connect waits for the database.")
+}
+
+func TestHealth(t *testing.T) {
+	fmt.Println("Testing database health...")
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	health := managers.NewHealth(db)
+	assert.NoError(t, health.Err())
+
+	down := errors.New("connection refused")
+	mock.ExpectPing().WillReturnError(down)
+	health.Check(ctx)
+	assert.ErrorIs(t, health.Err(), down)
+
+	mock.ExpectPing()
+	health.Check(ctx)
+	assert.NoError(t, health.Err())
+	assert.NoError(t, mock.ExpectationsWereMet())
+	fmt.Println("OK. Health follows the last ping.")
+}
@@ -0,0 +1,456 @@
+package managers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	pb "forum-service/forum-protos/genprotos"
+	"forum-service/models"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type ModerationManager struct {
+	Conn           *sql.DB
+	CommentManager *CommentManager
+	tx             *Transactor
+}
+
+func NewModerationManager(conn *sql.DB, commentManager *CommentManager) *ModerationManager {
+	return &ModerationManager{Conn: conn, CommentManager: commentManager, tx: NewTransactor(conn)}
+}
+
+// CountRecent counts posts and comments the user created within window.
+func (m *ModerationManager) CountRecent(ctx context.Context, userID string, window time.Duration) (int, error) {
+	query := `SELECT
+		(SELECT COUNT(*) FROM posts WHERE user_id = $1 AND created_at > NOW() - make_interval(secs => $2)) +
+		(SELECT COUNT(*) FROM comments WHERE user_id = $1 AND created_at > NOW() - make_interval(secs => $2))`
+	var count int
+	err := conn(ctx, m.Conn).QueryRowContext(ctx, query, userID, window.Seconds()).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// IsEstablished reports whether the user has published content older than age.
+func (m *ModerationManager) IsEstablished(ctx context.Context, userID string, age time.Duration) (bool, error) {
+	query := `SELECT
+		EXISTS (SELECT 1 FROM posts WHERE user_id = $1 AND status = $2 AND created_at < NOW() - make_interval(secs => $3)) OR
+		EXISTS (SELECT 1 FROM comments WHERE user_id = $1 AND status = $2 AND created_at < NOW() - make_interval(secs => $3))`
+	var established bool
+	err := conn(ctx, m.Conn).QueryRowContext(ctx, query, userID, models.StatusPublished, age.Seconds()).Scan(&established)
+	if err != nil {
+		return false, err
+	}
+	return established, nil
+}
+
+// HasDuplicate reports whether the user posted the same text within window.
+// body must already be normalized: lower case with single spaces.
+func (m *ModerationManager) HasDuplicate(ctx context.Context, userID, body string, window time.Duration) (bool, error) {
+	query := `SELECT
+		EXISTS (SELECT 1 FROM posts WHERE user_id = $1 AND deleted_at = 0 AND created_at > NOW() - make_interval(secs => $3)
+			AND lower(btrim(regexp_replace(body, '\s+', ' ', 'g'))) = $2) OR
+		EXISTS (SELECT 1 FROM comments WHERE user_id = $1 AND deleted_at = 0 AND created_at > NOW() - make_interval(secs => $3)
+			AND lower(btrim(regexp_replace(body, '\s+', ' ', 'g'))) = $2)`
+	var duplicate bool
+	err := conn(ctx, m.Conn).QueryRowContext(ctx, query, userID, body, window.Seconds()).Scan(&duplicate)
+	if err != nil {
+		return false, err
+	}
+	return duplicate, nil
+}
+
+func (m *ModerationManager) Flag(ctx context.Context, contentType, contentID string, flags []models.ModerationFlag) error {
+	return m.tx.InTx(ctx, func(ctx context.Context) error {
+		query := "INSERT INTO moderation_flags (content_type, content_id, check_name, reason) VALUES ($1, $2, $3, $4)"
+		for _, f := range flags {
+			if _, err := conn(ctx, m.Conn).ExecContext(ctx, query, contentType, contentID, f.Check, f.Reason); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (m *ModerationManager) SetPostStatus(ctx context.Context, req *pb.ModerationDecision, status string) (*pb.Void, error) {
+	return m.setStatus(ctx, models.ContentPost, req, status)
+}
+
+func (m *ModerationManager) SetCommentStatus(ctx context.Context, req *pb.ModerationDecision, status string) (*pb.Void, error) {
+	return m.setStatus(ctx, models.ContentComment, req, status)
+}
+
+func (m *ModerationManager) setStatus(ctx context.Context, contentType string, req *pb.ModerationDecision, status string) (*pb.Void, error) {
+	table, idColumn := contentTable(contentType)
+	action := models.ActionApprove
+	if status != models.StatusPublished {
+		action = models.ActionReject
+	}
+
+	err := m.tx.InTx(ctx, func(ctx context.Context) error {
+		tx := conn(ctx, m.Conn)
+		author, deleted, err := contentAuthor(ctx, tx, contentType, req.Id)
+		if err != nil {
+			return err
+		}
+		if deleted {
+			return fmt.Errorf("%s not found", contentType)
+		}
+		err = changeContent(ctx, tx, contentType, req.Id, func() error {
+			query := fmt.Sprintf("UPDATE %s SET status = $1, moderated_by = $2, moderated_at = NOW(), moderation_reason = $3 WHERE %s = $4", table, idColumn)
+			_, err := tx.ExecContext(ctx, query, status, nullable(req.ModeratorId), req.Reason, req.Id)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		return insertLog(ctx, tx, &pb.ModerationLogEntry{
+			ModeratorId: req.ModeratorId,
+			Action:      action,
+			ContentType: contentType,
+			ContentId:   req.Id,
+			UserId:      author,
+			Reason:      req.Reason,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.Void{}, nil
+}
+
+// AutoHide hides published content that collected too many reports. It
+// reports false if the content was not published.
+func (m *ModerationManager) AutoHide(ctx context.Context, contentType, contentID string) (bool, error) {
+	table, idColumn := contentTable(contentType)
+	hidden := false
+	err := m.tx.InTx(ctx, func(ctx context.Context) error {
+		tx := conn(ctx, m.Conn)
+		author, _, err := contentAuthor(ctx, tx, contentType, contentID)
+		if err != nil {
+			return err
+		}
+		var n int64
+		err = changeContent(ctx, tx, contentType, contentID, func() error {
+			query := fmt.Sprintf("UPDATE %s SET status = $1 WHERE %s = $2 AND status = $3 AND deleted_at = 0", table, idColumn)
+			res, err := tx.ExecContext(ctx, query, models.StatusHidden, contentID, models.StatusPublished)
+			if err != nil {
+				return err
+			}
+			n, err = res.RowsAffected()
+			return err
+		})
+		if err != nil || n == 0 {
+			return err
+		}
+		hidden = true
+		return insertLog(ctx, tx, &pb.ModerationLogEntry{
+			Action:      models.ActionAutoHide,
+			ContentType: contentType,
+			ContentId:   contentID,
+			UserId:      author,
+			Reason:      "report threshold reached",
+		})
+	})
+	if err != nil {
+		return false, err
+	}
+	return hidden, nil
+}
+
+// Resolve applies a moderator's action to reported content and closes every
+// open report on it.
+func (m *ModerationManager) Resolve(ctx context.Context, req *pb.ReportResolveReq) (*pb.Void, error) {
+	err := m.tx.InTx(ctx, func(ctx context.Context) error {
+		tx := conn(ctx, m.Conn)
+		var contentType, contentID, reportStatus string
+		query := "SELECT content_type, content_id, status FROM reports WHERE report_id = $1 FOR UPDATE"
+		err := tx.QueryRowContext(ctx, query, req.ReportId).Scan(&contentType, &contentID, &reportStatus)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("report not found")
+			}
+			return err
+		}
+		if reportStatus != models.ReportOpen {
+			return models.ErrReportResolved
+		}
+		author, _, err := contentAuthor(ctx, tx, contentType, contentID)
+		if err != nil {
+			return err
+		}
+
+		table, idColumn := contentTable(contentType)
+		switch req.Action {
+		case models.ActionDismiss:
+			// Only the report threshold's hide is undone; a moderator's
+			// own decision stands.
+			err = changeContent(ctx, tx, contentType, contentID, func() error {
+				query := fmt.Sprintf(`UPDATE %s SET status = $1 WHERE %s = $2 AND status = $3
+					AND (SELECT action FROM moderation_log WHERE content_type = $4 AND content_id = $2 AND action = ANY($5)
+						ORDER BY created_at DESC LIMIT 1) = $6`, table, idColumn)
+				_, err := tx.ExecContext(ctx, query, models.StatusPublished, contentID, models.StatusHidden,
+					contentType, pq.Array(models.StatusActions), models.ActionAutoHide)
+				return err
+			})
+		case models.ActionHide:
+			err = changeContent(ctx, tx, contentType, contentID, func() error {
+				query := fmt.Sprintf("UPDATE %s SET status = $1 WHERE %s = $2", table, idColumn)
+				_, err := tx.ExecContext(ctx, query, models.StatusHidden, contentID)
+				return err
+			})
+		case models.ActionDelete:
+			if contentType == models.ContentPost {
+				_, err = tx.ExecContext(ctx, "UPDATE posts SET deleted_at = EXTRACT(EPOCH FROM NOW()) WHERE post_id = $1", contentID)
+				if err == nil {
+					_, err = m.CommentManager.DeleteByPostID(ctx, &pb.CommentGReqOrDReqByPostID{PostId: contentID})
+				}
+			} else {
+				_, err = m.CommentManager.Delete(ctx, &pb.CommentGReqOrDReq{CommentId: contentID})
+			}
+		case models.ActionWarn:
+			// The warning itself is the log entry.
+		default:
+			err = fmt.Errorf("unknown action %q", req.Action)
+		}
+		if err != nil {
+			return err
+		}
+
+		query = `UPDATE reports SET status = $1, resolution = $2, resolved_by = $3, resolved_at = NOW()
+			WHERE content_type = $4 AND content_id = $5 AND status = $6`
+		_, err = tx.ExecContext(ctx, query, models.ReportResolved, req.Action, nullable(req.ModeratorId), contentType, contentID, models.ReportOpen)
+		if err != nil {
+			return err
+		}
+		return insertLog(ctx, tx, &pb.ModerationLogEntry{
+			ModeratorId: req.ModeratorId,
+			Action:      req.Action,
+			ContentType: contentType,
+			ContentId:   contentID,
+			UserId:      author,
+			ReportId:    req.ReportId,
+			Reason:      req.Note,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.Void{}, nil
+}
+
+func (m *ModerationManager) GetLog(ctx context.Context, req *pb.ModerationLogGAReq) (*pb.ModerationLogGARes, error) {
+	query := "SELECT log_id, moderator_id, action, content_type, content_id, user_id, report_id, reason, created_at FROM moderation_log WHERE TRUE"
+	var args []interface{}
+	paramIndex := 1
+	if req.Filter.ModeratorId != "" {
+		query += fmt.Sprintf(" AND moderator_id = $%d", paramIndex)
+		args = append(args, req.Filter.ModeratorId)
+		paramIndex++
+	}
+	if req.Filter.UserId != "" {
+		query += fmt.Sprintf(" AND user_id = $%d", paramIndex)
+		args = append(args, req.Filter.UserId)
+		paramIndex++
+	}
+	if req.Filter.ContentId != "" {
+		query += fmt.Sprintf(" AND content_id = $%d", paramIndex)
+		args = append(args, req.Filter.ContentId)
+		paramIndex++
+	}
+	if req.Filter.Action != "" {
+		query += fmt.Sprintf(" AND action = $%d", paramIndex)
+		args = append(args, req.Filter.Action)
+		paramIndex++
+	}
+	query += " ORDER BY created_at DESC"
+	if req.Pagination.Limit != 0 {
+		query += fmt.Sprintf(" LIMIT $%d", paramIndex)
+		args = append(args, req.Pagination.Limit)
+		paramIndex++
+	}
+	if req.Pagination.Offset != 0 {
+		query += fmt.Sprintf(" OFFSET $%d", paramIndex)
+		args = append(args, req.Pagination.Offset)
+		paramIndex++
+	}
+	rows, err := conn(ctx, m.Conn).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := &pb.ModerationLogGARes{}
+	for rows.Next() {
+		e := &pb.ModerationLogEntry{}
+		var moderatorID, reportID sql.NullString
+		var createdAt time.Time
+		if err := rows.Scan(&e.LogId, &moderatorID, &e.Action, &e.ContentType, &e.ContentId, &e.UserId, &reportID, &e.Reason, &createdAt); err != nil {
+			return nil, err
+		}
+		e.ModeratorId = moderatorID.String
+		e.ReportId = reportID.String
+		e.CreatedAt = createdAt.Format(time.RFC3339)
+		entries.Entries = append(entries.Entries, e)
+		entries.Count++
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// Mute makes the user read-only until expiresAt, or for good if it is nil.
+// Muting a muted user replaces the mute.
+func (m *ModerationManager) Mute(ctx context.Context, req *pb.MuteReq, expiresAt *time.Time) (*pb.Void, error) {
+	err := m.tx.InTx(ctx, func(ctx context.Context) error {
+		tx := conn(ctx, m.Conn)
+		query := `INSERT INTO mutes (user_id, reason, muted_by, expires_at) VALUES ($1, $2, $3, $4)
+			ON CONFLICT (user_id) DO UPDATE SET reason = $2, muted_by = $3, expires_at = $4, created_at = NOW()`
+		if _, err := tx.ExecContext(ctx, query, req.UserId, req.Reason, nullable(req.ModeratorId), expiresAt); err != nil {
+			return err
+		}
+		return insertLog(ctx, tx, &pb.ModerationLogEntry{
+			ModeratorId: req.ModeratorId,
+			Action:      models.ActionMute,
+			ContentType: models.ContentUser,
+			ContentId:   req.UserId,
+			UserId:      req.UserId,
+			Reason:      req.Reason,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.Void{}, nil
+}
+
+func (m *ModerationManager) Unmute(ctx context.Context, req *pb.MuteReq) (*pb.Void, error) {
+	err := m.tx.InTx(ctx, func(ctx context.Context) error {
+		tx := conn(ctx, m.Conn)
+		res, err := tx.ExecContext(ctx, "DELETE FROM mutes WHERE user_id = $1 AND (expires_at IS NULL OR expires_at > NOW())", req.UserId)
+		if err != nil {
+			return err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return models.ErrNotMuted
+		}
+		return insertLog(ctx, tx, &pb.ModerationLogEntry{
+			ModeratorId: req.ModeratorId,
+			Action:      models.ActionUnmute,
+			ContentType: models.ContentUser,
+			ContentId:   req.UserId,
+			UserId:      req.UserId,
+			Reason:      req.Reason,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.Void{}, nil
+}
+
+func (m *ModerationManager) IsMuted(ctx context.Context, userID string) (bool, error) {
+	query := "SELECT EXISTS (SELECT 1 FROM mutes WHERE user_id = $1 AND (expires_at IS NULL OR expires_at > NOW()))"
+	var muted bool
+	err := conn(ctx, m.Conn).QueryRowContext(ctx, query, userID).Scan(&muted)
+	if err != nil {
+		return false, err
+	}
+	return muted, nil
+}
+
+// SetPostFlag sets one of the locked, pinned or featured flags of a post
+// and logs action.
+func (m *ModerationManager) SetPostFlag(ctx context.Context, req *pb.PostFlagReq, flag, action string) (*pb.PostCReqOrCResOrGResOrUResp, error) {
+	switch flag {
+	case models.FlagLocked, models.FlagPinned, models.FlagFeatured:
+	default:
+		return nil, fmt.Errorf("unknown post flag %q", flag)
+	}
+
+	var p *pb.PostCReqOrCResOrGResOrUResp
+	err := m.tx.InTx(ctx, func(ctx context.Context) error {
+		tx := conn(ctx, m.Conn)
+		query := fmt.Sprintf("UPDATE posts SET %s = $1 WHERE post_id = $2 AND deleted_at = 0 RETURNING %s", flag, postColumns)
+		var err error
+		p, err = scanPost(tx.QueryRowContext(ctx, query, req.Value, req.PostId))
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("post not found")
+			}
+			return err
+		}
+		return insertLog(ctx, tx, &pb.ModerationLogEntry{
+			ModeratorId: req.ModeratorId,
+			Action:      action,
+			ContentType: models.ContentPost,
+			ContentId:   req.PostId,
+			UserId:      p.UserId,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// contentTable maps a content type to its table and key column.
+func contentTable(contentType string) (string, string) {
+	if contentType == models.ContentComment {
+		return "comments", "comment_id"
+	}
+	return "posts", "post_id"
+}
+
+// changeContent runs change, which alters a post's or comment's status, and
+// keeps the comment counts right when it is a comment.
+func changeContent(ctx context.Context, q DBTX, contentType, contentID string, change func() error) error {
+	if contentType == models.ContentComment {
+		return keepCommentCount(ctx, q, contentID, change)
+	}
+	return change()
+}
+
+// contentAuthor looks up who wrote a post or comment and whether it has
+// been deleted.
+func contentAuthor(ctx context.Context, q DBTX, contentType, contentID string) (string, bool, error) {
+	if contentType != models.ContentPost && contentType != models.ContentComment {
+		return "", false, fmt.Errorf("unknown content type %q", contentType)
+	}
+	table, idColumn := contentTable(contentType)
+	query := fmt.Sprintf("SELECT user_id, deleted_at <> 0 FROM %s WHERE %s = $1", table, idColumn)
+	var author string
+	var deleted bool
+	err := q.QueryRowContext(ctx, query, contentID).Scan(&author, &deleted)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", false, fmt.Errorf("%s not found", contentType)
+		}
+		return "", false, err
+	}
+	return author, deleted, nil
+}
+
+func insertLog(ctx context.Context, tx DBTX, e *pb.ModerationLogEntry) error {
+	query := `INSERT INTO moderation_log (moderator_id, action, content_type, content_id, user_id, report_id, reason)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	_, err := tx.ExecContext(ctx, query, nullable(e.ModeratorId), e.Action, e.ContentType, e.ContentId, e.UserId, nullable(e.ReportId), e.Reason)
+	return err
+}
+
+// nullable stores empty IDs as NULL.
+func nullable(id string) interface{} {
+	if id == "" {
+		return nil
+	}
+	return id
+}
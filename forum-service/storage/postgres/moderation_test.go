@@ -0,0 +1,115 @@
+package managers_test
+
+import (
+	"fmt"
+	pb "forum-service/forum-protos/genprotos"
+	"forum-service/models"
+	managers "forum-service/storage/postgres"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCountRecent(t *testing.T) {
+	fmt.Println("Testing count recent activity...")
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	moderationManager := managers.NewModerationManager(db, managers.NewCommentManager(db))
+
+	mock.ExpectQuery("SELECT").
+		WithArgs("user1", float64(600)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+	count, err := moderationManager.CountRecent(ctx, "user1", 10*time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, count)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	fmt.Println("OK. Recent activity counted succesfully.")
+}
+
+func TestSetPostStatus(t *testing.T) {
+	fmt.Println("Testing set post status...")
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	moderationManager := managers.NewModerationManager(db, managers.NewCommentManager(db))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT user_id, deleted_at <> 0 FROM posts").
+		WithArgs("post1").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "deleted"}).AddRow("author1", false))
+	mock.ExpectExec("UPDATE posts SET status").
+		WithArgs(models.StatusPublished, "mod1", "", "post1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO moderation_log").
+		WithArgs("mod1", models.ActionApprove, models.ContentPost, "post1", "author1", nil, "").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT user_id, deleted_at <> 0 FROM posts").
+		WithArgs("missing").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "deleted"}))
+	mock.ExpectRollback()
+
+	_, err = moderationManager.SetPostStatus(ctx, &pb.ModerationDecision{Id: "post1", ModeratorId: "mod1"}, models.StatusPublished)
+	assert.NoError(t, err)
+
+	_, err = moderationManager.SetPostStatus(ctx, &pb.ModerationDecision{Id: "missing", Reason: "spam"}, models.StatusRejected)
+	assert.EqualError(t, err, "post not found")
+	assert.NoError(t, mock.ExpectationsWereMet())
+	fmt.Println("OK. Post status set succesfully.")
+}
+
+func TestResolveResolvedReport(t *testing.T) {
+	fmt.Println("Testing resolve of a resolved report...")
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	moderationManager := managers.NewModerationManager(db, managers.NewCommentManager(db))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT content_type, content_id, status FROM reports").
+		WithArgs("report1").
+		WillReturnRows(sqlmock.NewRows([]string{"content_type", "content_id", "status"}).
+			AddRow(models.ContentPost, "post1", models.ReportResolved))
+	mock.ExpectRollback()
+
+	_, err = moderationManager.Resolve(ctx, &pb.ReportResolveReq{ReportId: "report1", ModeratorId: "mod1", Action: models.ActionHide})
+	assert.ErrorIs(t, err, models.ErrReportResolved)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	fmt.Println("OK. Resolved report left alone.")
+}
+
+func TestIsMuted(t *testing.T) {
+	fmt.Println("Testing is muted...")
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	moderationManager := managers.NewModerationManager(db, managers.NewCommentManager(db))
+
+	mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM mutes").
+		WithArgs("user1").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+	muted, err := moderationManager.IsMuted(ctx, "user1")
+	assert.NoError(t, err)
+	assert.True(t, muted)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	fmt.Println("OK. Mute checked succesfully.")
+}
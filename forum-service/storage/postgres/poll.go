@@ -0,0 +1,154 @@
+package managers
+
+import (
+	"context"
+	"database/sql"
+	pb "forum-service/forum-protos/genprotos"
+	"forum-service/models"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type PollManager struct {
+	Conn *sql.DB
+	tx   *Transactor
+}
+
+func NewPollManager(conn *sql.DB) *PollManager {
+	return &PollManager{Conn: conn, tx: NewTransactor(conn)}
+}
+
+// Create adds a poll to a post. A post has at most one poll.
+func (m *PollManager) Create(ctx context.Context, req *pb.PollCReq, closesAt *time.Time) (*pb.PollRes, error) {
+	err := m.tx.InTx(ctx, func(ctx context.Context) error {
+		tx := conn(ctx, m.Conn)
+		pollID := uuid.NewString()
+		query := `INSERT INTO polls (poll_id, post_id, question, multiple, hide_results, closes_at) VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (post_id) DO NOTHING`
+		res, err := tx.ExecContext(ctx, query, pollID, req.PostId, req.Question, req.Multiple, req.HideResults, closesAt)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return models.ErrPollExists
+		}
+		for i, text := range req.Options {
+			_, err = tx.ExecContext(ctx, "INSERT INTO poll_options (poll_id, position, text) VALUES ($1, $2, $3)", pollID, i, text)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return m.Get(ctx, &pb.PollGReq{PostId: req.PostId, UserId: req.UserId})
+}
+
+// Get returns the poll of a post with its current counts and whether
+// req.UserId has voted.
+func (m *PollManager) Get(ctx context.Context, req *pb.PollGReq) (*pb.PollRes, error) {
+	query := `SELECT poll_id, post_id, question, multiple, hide_results, closes_at, voter_count,
+			closed_at IS NOT NULL OR (closes_at IS NOT NULL AND closes_at <= NOW()),
+			EXISTS (SELECT 1 FROM poll_ballots b WHERE b.poll_id = polls.poll_id AND b.user_id::text = $2)
+		FROM polls WHERE post_id = $1`
+	p := &pb.PollRes{}
+	var closesAt sql.NullTime
+	err := conn(ctx, m.Conn).QueryRowContext(ctx, query, req.PostId, req.UserId).
+		Scan(&p.PollId, &p.PostId, &p.Question, &p.Multiple, &p.HideResults, &closesAt, &p.Voters, &p.Closed, &p.Voted)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrPollNotFound
+		}
+		return nil, err
+	}
+	if closesAt.Valid {
+		p.ClosesAt = closesAt.Time.Format(time.RFC3339)
+	}
+
+	rows, err := conn(ctx, m.Conn).QueryContext(ctx, "SELECT option_id, text, vote_count FROM poll_options WHERE poll_id = $1 ORDER BY position", p.PollId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		o := &pb.PollOption{}
+		if err := rows.Scan(&o.OptionId, &o.Text, &o.Votes); err != nil {
+			return nil, err
+		}
+		p.Options = append(p.Options, o)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Vote casts req.UserId's single ballot. Counts are kept on the options so
+// reads never aggregate votes.
+func (m *PollManager) Vote(ctx context.Context, req *pb.PollVoteReq) error {
+	return m.tx.InTx(ctx, func(ctx context.Context) error {
+		tx := conn(ctx, m.Conn)
+		var pollID string
+		var multiple, closed bool
+		query := `SELECT poll_id, multiple, closed_at IS NOT NULL OR (closes_at IS NOT NULL AND closes_at <= NOW())
+			FROM polls WHERE post_id = $1 FOR SHARE`
+		err := tx.QueryRowContext(ctx, query, req.PostId).Scan(&pollID, &multiple, &closed)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return models.ErrPollNotFound
+			}
+			return err
+		}
+		if closed {
+			return models.ErrPollClosed
+		}
+		if len(req.OptionIds) == 0 || (!multiple && len(req.OptionIds) > 1) {
+			return models.ErrInvalidVote
+		}
+
+		var matched int
+		err = tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM poll_options WHERE poll_id = $1 AND option_id::text = ANY($2)", pollID, pq.Array(req.OptionIds)).Scan(&matched)
+		if err != nil {
+			return err
+		}
+		if matched != len(req.OptionIds) {
+			return models.ErrInvalidVote
+		}
+
+		res, err := tx.ExecContext(ctx, "INSERT INTO poll_ballots (poll_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING", pollID, req.UserId)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return models.ErrAlreadyVoted
+		}
+		for _, optionID := range req.OptionIds {
+			_, err = tx.ExecContext(ctx, "INSERT INTO poll_votes (poll_id, option_id, user_id) VALUES ($1, $2, $3)", pollID, optionID, req.UserId)
+			if err != nil {
+				return err
+			}
+		}
+		_, err = tx.ExecContext(ctx, "UPDATE poll_options SET vote_count = vote_count + 1 WHERE poll_id = $1 AND option_id::text = ANY($2)", pollID, pq.Array(req.OptionIds))
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, "UPDATE polls SET voter_count = voter_count + 1 WHERE poll_id = $1", pollID)
+		return err
+	})
+}
+
+// Close ends voting now. Closing a closed poll is a no-op.
+func (m *PollManager) Close(ctx context.Context, postID string) error {
+	res, err := conn(ctx, m.Conn).ExecContext(ctx, "UPDATE polls SET closed_at = COALESCE(closed_at, NOW()) WHERE post_id = $1", postID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return models.ErrPollNotFound
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package managers_test
+
+import (
+	"fmt"
+	pb "forum-service/forum-protos/genprotos"
+	"forum-service/models"
+	managers "forum-service/storage/postgres"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVoteTwice(t *testing.T) {
+	fmt.Println("Testing repeated poll vote...")
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	pollManager := managers.NewPollManager(db)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT poll_id, multiple").
+		WithArgs("post1").
+		WillReturnRows(sqlmock.NewRows([]string{"poll_id", "multiple", "closed"}).AddRow("poll1", false, false))
+	mock.ExpectQuery("SELECT COUNT").
+		WithArgs("poll1", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectExec("INSERT INTO poll_ballots").
+		WithArgs("poll1", "user1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	err = pollManager.Vote(ctx, &pb.PollVoteReq{PostId: "post1", UserId: "user1", OptionIds: []string{"opt1"}})
+	assert.ErrorIs(t, err, models.ErrAlreadyVoted)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	fmt.Println("OK. Second ballot refused.")
+}
+
+func TestVoteSingleChoice(t *testing.T) {
+	fmt.Println("Testing single choice poll...")
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	pollManager := managers.NewPollManager(db)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT poll_id, multiple").
+		WithArgs("post1").
+		WillReturnRows(sqlmock.NewRows([]string{"poll_id", "multiple", "closed"}).AddRow("poll1", false, false))
+	mock.ExpectRollback()
+
+	err = pollManager.Vote(ctx, &pb.PollVoteReq{PostId: "post1", UserId: "user1", OptionIds: []string{"opt1", "opt2"}})
+	assert.ErrorIs(t, err, models.ErrInvalidVote)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	fmt.Println("OK. Second option refused.")
+}
@@ -1,91 +1,104 @@
 package managers
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	pb "forum-service/forum-protos/genprotos"
+	"forum-service/models"
 	"strings"
+	"time"
 )
 
 type PostManager struct {
 	Conn           *sql.DB
+	Replicas       *Replicas // for reads, if set
 	TagManager     *TagManager
 	CommentManager *CommentManager
+	tx             *Transactor
 }
 
 func NewPostManager(conn *sql.DB, tagManager *TagManager, commentManager *CommentManager) *PostManager {
-	return &PostManager{Conn: conn, TagManager: tagManager, CommentManager: commentManager}
+	return &PostManager{Conn: conn, TagManager: tagManager, CommentManager: commentManager, tx: NewTransactor(conn)}
 }
 
-func (m *PostManager) Create(post *pb.PostCReqOrCResOrGResOrUResp, tags []string) (*pb.PostCReqOrCResOrGResOrUResp, error) {
-	tx, err := m.Conn.Begin()
-	if err != nil {
-		return nil, err
-	}
-	query := "INSERT INTO posts (post_id, user_id, title, body, category_id, tags) VALUES ($1, $2, $3, $4, $5, $6) RETURNING post_id, user_id, title, body, category_id, tags"
+const postColumns = "post_id, user_id, title, body, category_id, tags, status, locked, pinned, featured, revision, COALESCE(accepted_comment_id::text, ''), comment_count, last_activity_at"
+
+func scanPost(row interface{ Scan(...interface{}) error }) (*pb.PostCReqOrCResOrGResOrUResp, error) {
 	p := &pb.PostCReqOrCResOrGResOrUResp{}
-	err = tx.QueryRow(query, post.PostId, post.UserId, post.Title, post.Body, post.CategoryId, post.Tags).Scan(&p.PostId, &p.UserId, &p.Title, &p.Body, &p.CategoryId, &p.Tags)
+	var lastActivityAt time.Time
+	err := row.Scan(&p.PostId, &p.UserId, &p.Title, &p.Body, &p.CategoryId, &p.Tags, &p.Status, &p.Locked, &p.Pinned, &p.Featured, &p.Revision, &p.AcceptedCommentId, &p.CommentCount, &lastActivityAt)
 	if err != nil {
-		tx.Rollback()
 		return nil, err
 	}
-	for _, tag := range tags {
-		newTag := &pb.TagCReqOrCRes{
-			Tag:    tag,
-			PostId: post.PostId,
-		}
-		_, err := m.TagManager.Create(tx, newTag)
+	p.LastActivityAt = lastActivityAt.Format(time.RFC3339)
+	return p, nil
+}
+
+func (m *PostManager) Create(ctx context.Context, post *pb.PostCReqOrCResOrGResOrUResp, tags []string) (*pb.PostCReqOrCResOrGResOrUResp, error) {
+	status := post.Status
+	if status == "" {
+		status = models.StatusPublished
+	}
+	var p *pb.PostCReqOrCResOrGResOrUResp
+	err := m.tx.InTx(ctx, func(ctx context.Context) error {
+		query := "INSERT INTO posts (post_id, user_id, title, body, category_id, tags, status) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING " + postColumns
+		var err error
+		p, err = scanPost(conn(ctx, m.Conn).QueryRowContext(ctx, query, post.PostId, post.UserId, post.Title, post.Body, post.CategoryId, post.Tags, status))
 		if err != nil {
-			tx.Rollback()
-			return nil, err
+			return err
 		}
-	}
-	err = tx.Commit()
+		for _, tag := range tags {
+			newTag := &pb.TagCReqOrCRes{
+				Tag:    tag,
+				PostId: post.PostId,
+			}
+			if _, err := m.TagManager.Create(ctx, newTag); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
 	return p, nil
 }
 
-func (m *PostManager) Update(post *pb.PostUReq, tags []string) (*pb.PostCReqOrCResOrGResOrUResp, error) {
-	tx, err := m.Conn.Begin()
-	if err != nil {
-		return nil, err
-	}
-	_, err = m.TagManager.Delete(tx, &pb.TagGReqOrDReq{PostId: post.PostId})
-	if err != nil {
-		tx.Rollback()
-		return nil, err
-	}
-	query := "UPDATE posts SET title = $1, body = $2, category_id = $3, tags = $4, updated_at = NOW() WHERE post_id = $5 RETURNING post_id, user_id, title, body, category_id, tags"
-	p := &pb.PostCReqOrCResOrGResOrUResp{}
-	err = tx.QueryRow(query, post.Title, post.Body, post.CategoryId, post.Tags, post.PostId).Scan(&p.PostId, &p.UserId, &p.Title, &p.Body, &p.CategoryId, &p.Tags)
-	if err != nil {
-		tx.Rollback()
-		return nil, err
-	}
-	for _, tag := range tags {
-		newTag := &pb.TagCReqOrCRes{
-			Tag:    tag,
-			PostId: post.PostId,
+// Update replaces a post's text, category and tags and stores the status
+// moderation gave the new text.
+func (m *PostManager) Update(ctx context.Context, post *pb.PostUReq, tags []string, status string) (*pb.PostCReqOrCResOrGResOrUResp, error) {
+	var p *pb.PostCReqOrCResOrGResOrUResp
+	err := m.tx.InTx(ctx, func(ctx context.Context) error {
+		if _, err := m.TagManager.Delete(ctx, &pb.TagGReqOrDReq{PostId: post.PostId}); err != nil {
+			return err
 		}
-		_, err := m.TagManager.Create(tx, newTag)
+		query := "UPDATE posts SET title = $1, body = $2, category_id = $3, tags = $4, status = $5, revision = CASE WHEN body = $2 THEN revision ELSE revision + 1 END, updated_at = NOW() WHERE post_id = $6 RETURNING " + postColumns
+		var err error
+		p, err = scanPost(conn(ctx, m.Conn).QueryRowContext(ctx, query, post.Title, post.Body, post.CategoryId, post.Tags, status, post.PostId))
 		if err != nil {
-			tx.Rollback()
-			return nil, err
+			return err
 		}
-	}
-	err = tx.Commit()
+		for _, tag := range tags {
+			newTag := &pb.TagCReqOrCRes{
+				Tag:    tag,
+				PostId: post.PostId,
+			}
+			if _, err := m.TagManager.Create(ctx, newTag); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
 	return p, nil
 }
 
-func (m *PostManager) GetByID(req *pb.PostGReqOrDReq) (*pb.PostCReqOrCResOrGResOrUResp, error) {
-	query := "SELECT post_id, user_id, title, body, category_id, tags FROM posts WHERE post_id = $1"
-	p := &pb.PostCReqOrCResOrGResOrUResp{}
-	err := m.Conn.QueryRow(query, req.PostId).Scan(&p.PostId, &p.UserId, &p.Title, &p.Body, &p.CategoryId, &p.Tags)
+func (m *PostManager) GetByID(ctx context.Context, req *pb.PostGReqOrDReq) (*pb.PostCReqOrCResOrGResOrUResp, error) {
+	query := "SELECT " + postColumns + " FROM posts WHERE post_id = $1 AND deleted_at = 0"
+	p, err := scanPost(reader(ctx, m.Conn, m.Replicas).QueryRowContext(ctx, query, req.PostId))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("post not found")
@@ -95,33 +108,32 @@ func (m *PostManager) GetByID(req *pb.PostGReqOrDReq) (*pb.PostCReqOrCResOrGResO
 	return p, nil
 }
 
-func (m *PostManager) Delete(req *pb.PostGReqOrDReq) (*pb.Void, error) {
-	tx, err := m.Conn.Begin()
-	if err != nil {
-		return nil, err
-	}
-	query := "UPDATE posts SET deleted_at = EXTRACT(EPOCH FROM NOW()) WHERE post_id = $1"
-	_, err = tx.Exec(query, req.PostId)
-	if err != nil {
-		tx.Rollback()
-		return nil, err
-	}
-	_, err = m.CommentManager.DeleteByPostID(tx, &pb.CommentGReqOrDReqByPostID{PostId: req.PostId})
-	if err != nil {
-		tx.Rollback()
-		return nil, err
-	}
-	err = tx.Commit()
+func (m *PostManager) Delete(ctx context.Context, req *pb.PostGReqOrDReq) (*pb.Void, error) {
+	err := m.tx.InTx(ctx, func(ctx context.Context) error {
+		query := "UPDATE posts SET deleted_at = EXTRACT(EPOCH FROM NOW()) WHERE post_id = $1"
+		if _, err := conn(ctx, m.Conn).ExecContext(ctx, query, req.PostId); err != nil {
+			return err
+		}
+		_, err := m.CommentManager.DeleteByPostID(ctx, &pb.CommentGReqOrDReqByPostID{PostId: req.PostId})
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 	return &pb.Void{}, nil
 }
 
-func (m *PostManager) GetAll(req *pb.PostGAReq) (*pb.PostGARes, error) {
-	query := "SELECT post_id, user_id, title, body, category_id, tags FROM posts WHERE deleted_at = 0"
+func (m *PostManager) GetAll(ctx context.Context, req *pb.PostGAReq) (*pb.PostGARes, error) {
+	query := "SELECT " + postColumns + " FROM posts WHERE deleted_at = 0"
 	var args []interface{}
 	paramIndex := 1
+	status := req.Filter.Status
+	if status == "" {
+		status = models.StatusPublished
+	}
+	query += fmt.Sprintf(" AND status = $%d", paramIndex)
+	args = append(args, status)
+	paramIndex++
 	if req.Filter.UserId != "" {
 		query += fmt.Sprintf(" AND user_id = $%d", paramIndex)
 		args = append(args, req.Filter.UserId)
@@ -147,6 +159,22 @@ func (m *PostManager) GetAll(req *pb.PostGAReq) (*pb.PostGARes, error) {
 		args = append(args, req.Filter.Title)
 		paramIndex++
 	}
+	if req.Filter.Featured {
+		query += " AND featured"
+	}
+	// Answered only applies to questions, i.e. posts in Q&A categories.
+	switch req.Filter.Answered {
+	case "true":
+		query += " AND accepted_comment_id IS NOT NULL AND category_id IN (SELECT category_id FROM categories WHERE qa)"
+	case "false":
+		query += " AND accepted_comment_id IS NULL AND category_id IN (SELECT category_id FROM categories WHERE qa)"
+	}
+	// Pinned posts lead their category.
+	if req.Filter.CategoryId != "" {
+		query += " ORDER BY pinned DESC, created_at DESC"
+	} else {
+		query += " ORDER BY created_at DESC"
+	}
 	if req.Pagination.Limit != 0 {
 		query += fmt.Sprintf(" LIMIT $%d", paramIndex)
 		args = append(args, req.Pagination.Limit)
@@ -157,15 +185,15 @@ func (m *PostManager) GetAll(req *pb.PostGAReq) (*pb.PostGARes, error) {
 		args = append(args, req.Pagination.Offset)
 		paramIndex++
 	}
-	rows, err := m.Conn.Query(query, args...)
+	rows, err := reader(ctx, m.Conn, m.Replicas).QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 	posts := &pb.PostGARes{}
 	for rows.Next() {
-		p := &pb.PostCReqOrCResOrGResOrUResp{}
-		if err := rows.Scan(&p.PostId, &p.UserId, &p.Title, &p.Body, &p.CategoryId, &p.Tags); err != nil {
+		p, err := scanPost(rows)
+		if err != nil {
 			return nil, err
 		}
 		posts.Posts = append(posts.Posts, p)
@@ -177,3 +205,18 @@ func (m *PostManager) GetAll(req *pb.PostGAReq) (*pb.PostGARes, error) {
 
 	return posts, nil
 }
+
+// SetAcceptedAnswer marks commentID as the answer to a question, or clears
+// the answer when commentID is empty.
+func (m *PostManager) SetAcceptedAnswer(ctx context.Context, postID, commentID string) (*pb.PostCReqOrCResOrGResOrUResp, error) {
+	query := `UPDATE posts SET accepted_comment_id = NULLIF($1, '')::uuid WHERE post_id = $2 AND deleted_at = 0
+		RETURNING ` + postColumns
+	p, err := scanPost(conn(ctx, m.Conn).QueryRowContext(ctx, query, commentID, postID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("post not found")
+		}
+		return nil, err
+	}
+	return p, nil
+}
@@ -1,10 +1,12 @@
 package managers_test
 
 import (
+	"context"
 	"fmt"
 	pb "forum-service/forum-protos/genprotos"
 	managers "forum-service/storage/postgres"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/google/uuid"
@@ -28,7 +30,7 @@ func TestCreatePost(t *testing.T) {
 	}
 
 	tags := []string{"tag1", "tag2"}
-	p, err := postManager.Create(post, tags)
+	p, err := postManager.Create(ctx, post, tags)
 	assert.NoError(t, err)
 	assert.NotNil(t, p)
 	assert.Equal(t, post.PostId, p.PostId)
@@ -51,7 +53,7 @@ func TestUpdatePost(t *testing.T) {
 	}
 
 	tags := []string{"tag1", "tag3"}
-	p, err := postManager.Update(post, tags)
+	p, err := postManager.Update(ctx, post, tags, "published")
 	assert.NoError(t, err)
 	assert.NotNil(t, p)
 	assert.Equal(t, post.PostId, p.PostId)
@@ -68,7 +70,7 @@ func TestGetPostByID(t *testing.T) {
 		PostId: postId,
 	}
 
-	p, err := postManager.GetByID(req)
+	p, err := postManager.GetByID(ctx, req)
 	assert.NoError(t, err)
 	assert.NotNil(t, p)
 	assert.Equal(t, req.PostId, p.PostId)
@@ -81,7 +83,7 @@ func TestDeletePost(t *testing.T) {
 		PostId: postId,
 	}
 
-	_, err := postManager.Delete(req)
+	_, err := postManager.Delete(ctx, req)
 	assert.NoError(t, err)
 	fmt.Println("OK. Post deleted succesfully.")
 }
@@ -96,11 +98,11 @@ func TestGetAllPosts(t *testing.T) {
 
 	postManager := managers.NewPostManager(db, nil, nil)
 
-	rows := sqlmock.NewRows([]string{"post_id", "user_id", "title", "body", "category_id", "tags"}).
-		AddRow("1", "user1", "Title 1", "Body 1", "cat1", "tag1").
-		AddRow("2", "user2", "Title 2", "Body 2", "cat2", "tag2")
+	rows := sqlmock.NewRows([]string{"post_id", "user_id", "title", "body", "category_id", "tags", "status", "locked", "pinned", "featured", "revision", "accepted_comment_id", "comment_count", "last_activity_at"}).
+		AddRow("1", "user1", "Title 1", "Body 1", "cat1", "tag1", "published", false, true, false, 1, "", 3, time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)).
+		AddRow("2", "user2", "Title 2", "Body 2", "cat2", "tag2", "published", true, false, true, 2, "", 0, time.Date(2024, 4, 30, 9, 0, 0, 0, time.UTC))
 
-	mock.ExpectQuery("SELECT post_id, user_id, title, body, category_id, tags FROM posts WHERE deleted_at = 0").
+	mock.ExpectQuery("SELECT post_id, user_id, title, body, category_id, tags, status, locked, pinned, featured, revision, COALESCE").
 		WillReturnRows(rows)
 
 	req := &pb.PostGAReq{
@@ -111,7 +113,7 @@ func TestGetAllPosts(t *testing.T) {
 		},
 	}
 
-	posts, err := postManager.GetAll(req)
+	posts, err := postManager.GetAll(ctx, req)
 
 	assert.NoError(t, err)
 	assert.NotNil(t, posts)
@@ -123,6 +125,11 @@ func TestGetAllPosts(t *testing.T) {
 	assert.Equal(t, "Body 1", posts.Posts[0].Body)
 	assert.Equal(t, "cat1", posts.Posts[0].CategoryId)
 	assert.Equal(t, "tag1", posts.Posts[0].Tags)
+	assert.True(t, posts.Posts[0].Pinned)
+	assert.True(t, posts.Posts[1].Locked)
+	assert.True(t, posts.Posts[1].Featured)
+	assert.Equal(t, int64(3), posts.Posts[0].CommentCount)
+	assert.Equal(t, "2024-05-01T12:00:00Z", posts.Posts[0].LastActivityAt)
 	fmt.Println("OK. All posts retrieved succesfully.")
 }
 
@@ -135,3 +142,85 @@ func createCategory(t *testing.T, categoryId string) {
 	}
 	fmt.Println("OK. Category created succesfully.")
 }
+
+func TestSetAcceptedAnswer(t *testing.T) {
+	fmt.Println("Testing accepted answer...")
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	postManager := managers.NewPostManager(db, nil, nil)
+
+	mock.ExpectQuery("UPDATE posts SET accepted_comment_id").
+		WithArgs("comment1", "post1").
+		WillReturnRows(sqlmock.NewRows([]string{"post_id", "user_id", "title", "body", "category_id", "tags", "status", "locked", "pinned", "featured", "revision", "accepted_comment_id", "comment_count", "last_activity_at"}).
+			AddRow("post1", "user1", "How to open a door?", "Body", "cat1", "", "published", false, false, false, 1, "comment1", 1, time.Now()))
+	mock.ExpectQuery("UPDATE posts SET accepted_comment_id").
+		WithArgs("", "missing").
+		WillReturnRows(sqlmock.NewRows([]string{"post_id"}))
+
+	post, err := postManager.SetAcceptedAnswer(ctx, "post1", "comment1")
+	assert.NoError(t, err)
+	assert.Equal(t, "comment1", post.AcceptedCommentId)
+
+	_, err = postManager.SetAcceptedAnswer(ctx, "missing", "")
+	assert.EqualError(t, err, "post not found")
+	assert.NoError(t, mock.ExpectationsWereMet())
+	fmt.Println("OK. Accepted answer set.")
+}
+
+func TestGetAllPostsCancelled(t *testing.T) {
+	fmt.Println("Testing cancelled get all posts...")
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	postManager := managers.NewPostManager(db, nil, nil)
+
+	mock.ExpectQuery("SELECT post_id, user_id, title, body, category_id, tags, status, locked, pinned, featured, revision, COALESCE").
+		WillDelayFor(5 * time.Second).
+		WillReturnRows(sqlmock.NewRows([]string{"post_id"}))
+
+	// The client goes away while the query is running.
+	cancelCtx, cancel := context.WithCancel(ctx)
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	posts, err := postManager.GetAll(cancelCtx, &pb.PostGAReq{Filter: &pb.PostFilter{}, Pagination: &pb.Pagination{}})
+	assert.Error(t, err)
+	assert.Nil(t, posts)
+	assert.Less(t, time.Since(start), time.Second)
+	fmt.Println("OK. Cancelled query aborted.")
+}
+
+func TestCreatePostDeadlineRollsBack(t *testing.T) {
+	fmt.Println("Testing create post past its deadline...")
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	postManager := managers.NewPostManager(db, managers.NewTagManager(db), nil)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO posts").
+		WillDelayFor(5 * time.Second).
+		WillReturnRows(sqlmock.NewRows([]string{"post_id"}))
+	mock.ExpectRollback()
+
+	deadlineCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err = postManager.Create(deadlineCtx, &pb.PostCReqOrCResOrGResOrUResp{PostId: "1"}, []string{"tag1"})
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+	// database/sql rolls the transaction back once its context is done.
+	assert.Eventually(t, func() bool { return mock.ExpectationsWereMet() == nil }, time.Second, 10*time.Millisecond)
+	fmt.Println("OK. Transaction rolled back at the deadline.")
+}
@@ -0,0 +1,69 @@
+package managers
+
+import (
+	"context"
+	"database/sql"
+	"forum-service/render"
+
+	"github.com/lib/pq"
+)
+
+type RenderManager struct {
+	Conn *sql.DB
+}
+
+func NewRenderManager(conn *sql.DB) *RenderManager {
+	return &RenderManager{Conn: conn}
+}
+
+// GetAll returns the cached renderings of keys that are still current, in
+// one query.
+func (m *RenderManager) GetAll(ctx context.Context, contentType string, version int, keys []render.Key) (map[render.Key]string, error) {
+	ids, revisions := make([]string, len(keys)), make([]int64, len(keys))
+	for i, k := range keys {
+		ids[i], revisions[i] = k.ContentID, k.Revision
+	}
+	query := `SELECT r.content_id, r.revision, r.html FROM rendered_bodies r
+		JOIN unnest($3::uuid[], $4::bigint[]) AS k (content_id, revision) ON r.content_id = k.content_id AND r.revision = k.revision
+		WHERE r.content_type = $1 AND r.renderer = $2`
+	rows, err := conn(ctx, m.Conn).QueryContext(ctx, query, contentType, version, pq.Array(ids), pq.Array(revisions))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	html := map[render.Key]string{}
+	for rows.Next() {
+		var k render.Key
+		var h string
+		if err := rows.Scan(&k.ContentID, &k.Revision, &h); err != nil {
+			return nil, err
+		}
+		html[k] = h
+	}
+	return html, rows.Err()
+}
+
+// PutAll replaces the cached renderings, except where a newer revision is
+// already stored.
+func (m *RenderManager) PutAll(ctx context.Context, contentType string, version int, html map[render.Key]string) error {
+	// One row per content ID: an upsert can't touch the same row twice.
+	latest := map[string]render.Key{}
+	for k := range html {
+		if l, ok := latest[k.ContentID]; !ok || k.Revision > l.Revision {
+			latest[k.ContentID] = k
+		}
+	}
+	var ids, bodies []string
+	var revisions []int64
+	for id, k := range latest {
+		ids, revisions, bodies = append(ids, id), append(revisions, k.Revision), append(bodies, html[k])
+	}
+	query := `INSERT INTO rendered_bodies (content_type, content_id, revision, renderer, html)
+		SELECT $1, k.content_id, k.revision, $2, k.html FROM unnest($3::uuid[], $4::bigint[], $5::text[]) AS k (content_id, revision, html)
+		ON CONFLICT (content_type, content_id) DO UPDATE
+		SET revision = EXCLUDED.revision, renderer = EXCLUDED.renderer, html = EXCLUDED.html, rendered_at = NOW()
+		WHERE rendered_bodies.revision <= EXCLUDED.revision`
+	_, err := conn(ctx, m.Conn).ExecContext(ctx, query, contentType, version, pq.Array(ids), pq.Array(revisions), pq.Array(bodies))
+	return err
+}
@@ -0,0 +1,77 @@
+package managers_test
+
+import (
+	"errors"
+	"fmt"
+	"forum-service/models"
+	"forum-service/render"
+	managers "forum-service/storage/postgres"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderCachedPerRevision(t *testing.T) {
+	fmt.Println("Testing cached rendering...")
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	renderer := render.NewRenderer(managers.NewRenderManager(db))
+	source := "[link](https://example.com) <script>alert(1)</script>\n\n```go\nfmt.Println()\n```"
+
+	mock.ExpectQuery("SELECT r.content_id, r.revision, r.html FROM rendered_bodies").
+		WithArgs(models.ContentPost, render.Version, pq.Array([]string{"post1"}), pq.Array([]int64{2})).
+		WillReturnRows(sqlmock.NewRows([]string{"content_id", "revision", "html"}))
+	mock.ExpectExec("INSERT INTO rendered_bodies").
+		WithArgs(models.ContentPost, render.Version, pq.Array([]string{"post1"}), pq.Array([]int64{2}), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT r.content_id, r.revision, r.html FROM rendered_bodies").
+		WithArgs(models.ContentPost, render.Version, pq.Array([]string{"post1"}), pq.Array([]int64{2})).
+		WillReturnRows(sqlmock.NewRows([]string{"content_id", "revision", "html"}).AddRow("post1", 2, "<p>cached</p>"))
+
+	html, err := renderer.Render(ctx, models.ContentPost, "post1", 2, source)
+	assert.NoError(t, err)
+	assert.Contains(t, html, `rel="nofollow`)
+	assert.Contains(t, html, `<code class="language-go">`)
+	assert.NotContains(t, html, "<script>")
+
+	html, err = renderer.Render(ctx, models.ContentPost, "post1", 2, source)
+	assert.NoError(t, err)
+	assert.Equal(t, "<p>cached</p>", html)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	fmt.Println("OK. Rendering cached per revision.")
+}
+
+func TestRenderPageInOneQuery(t *testing.T) {
+	fmt.Println("Testing cached rendering of a page...")
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	renderer := render.NewRenderer(managers.NewRenderManager(db))
+	bodies := []render.Body{
+		{Key: render.Key{ContentID: "post1", Revision: 1}, Source: "one"},
+		{Key: render.Key{ContentID: "post2", Revision: 3}, Source: "two"},
+		{Key: render.Key{ContentID: "post3", Revision: 1}, Source: "three"},
+	}
+
+	mock.ExpectQuery("SELECT r.content_id, r.revision, r.html FROM rendered_bodies").
+		WithArgs(models.ContentPost, render.Version, pq.Array([]string{"post1", "post2", "post3"}), pq.Array([]int64{1, 3, 1})).
+		WillReturnRows(sqlmock.NewRows([]string{"content_id", "revision", "html"}).AddRow("post2", 3, "<p>cached two</p>"))
+	mock.ExpectExec("INSERT INTO rendered_bodies").
+		WithArgs(models.ContentPost, render.Version, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnError(errors.New("connection reset"))
+
+	html, err := renderer.RenderAll(ctx, models.ContentPost, bodies)
+	assert.NoError(t, err, "a failing cache only costs re-renders")
+	assert.Equal(t, []string{"<p>one</p>\n", "<p>cached two</p>", "<p>three</p>\n"}, html)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	fmt.Println("OK. The page was read from the cache in one query.")
+}
@@ -0,0 +1,147 @@
+package managers
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+type primaryKey struct{}
+
+// WithPrimary makes reads with the returned context go to the primary, for
+// a caller that has to see its own writes.
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// UsesPrimary tells whether reads with ctx must see current data: they run
+// in a transaction or after WithPrimary.
+func UsesPrimary(ctx context.Context) bool {
+	_, inTx := ctx.Value(txKey{}).(*txState)
+	return inTx || ctx.Value(primaryKey{}) != nil
+}
+
+// reader returns where a read runs: the transaction ctx runs in, the primary
+// when ctx asks for it or there are no replicas, or else a healthy replica.
+func reader(ctx context.Context, db *sql.DB, replicas *Replicas) DBTX {
+	if s, ok := ctx.Value(txKey{}).(*txState); ok {
+		return s.tx
+	}
+	if replicas == nil || ctx.Value(primaryKey{}) != nil {
+		return db
+	}
+	return replicas.pick(db)
+}
+
+// LagFunc measures how far a replica is behind its primary.
+type LagFunc func(ctx context.Context, db *sql.DB) (time.Duration, error)
+
+// ReplayLag asks a streaming replica how old the last transaction it
+// replayed is. A replica that has replayed everything it received is not
+// behind, however long ago the primary last wrote.
+func ReplayLag(ctx context.Context, db *sql.DB) (time.Duration, error) {
+	query := `SELECT CASE WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+		ELSE COALESCE(EXTRACT(EPOCH FROM NOW() - pg_last_xact_replay_timestamp()), 0) END`
+	var seconds float64
+	if err := db.QueryRowContext(ctx, query).Scan(&seconds); err != nil {
+		return 0, err
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+type replica struct {
+	name    string
+	db      *sql.DB
+	healthy atomic.Bool
+}
+
+// Replicas spreads reads over read replicas in turn. A replica is only used
+// while its last check found it reachable and no more than MaxLag behind;
+// with none healthy, reads go to the primary.
+type Replicas struct {
+	MaxLag time.Duration
+	Lag    LagFunc
+
+	replicas []*replica
+	next     atomic.Uint64
+}
+
+func NewReplicas(maxLag time.Duration) *Replicas {
+	return &Replicas{MaxLag: maxLag, Lag: ReplayLag}
+}
+
+// Add adds a replica's pool under name, e.g. its host. It stays unused
+// until the next Check finds it healthy.
+func (r *Replicas) Add(name string, db *sql.DB) {
+	r.replicas = append(r.replicas, &replica{name: name, db: db})
+}
+
+// Check pings every replica and measures its lag, and marks it healthy or
+// not. It returns how many are healthy.
+func (r *Replicas) Check(ctx context.Context) int {
+	healthy := 0
+	for _, rp := range r.replicas {
+		ok := r.check(ctx, rp)
+		if was := rp.healthy.Swap(ok); was != ok {
+			if ok {
+				log.Printf("Replica %s is healthy again", rp.name)
+			} else {
+				log.Printf("Replica %s is unhealthy; its reads go elsewhere", rp.name)
+			}
+		}
+		if ok {
+			healthy++
+		}
+	}
+	return healthy
+}
+
+func (r *Replicas) check(ctx context.Context, rp *replica) bool {
+	if err := rp.db.PingContext(ctx); err != nil {
+		return false
+	}
+	lag, err := r.Lag(ctx, rp.db)
+	return err == nil && lag <= r.MaxLag
+}
+
+// Run checks the replicas every interval until ctx is done.
+func (r *Replicas) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			checkCtx, cancel := context.WithTimeout(ctx, interval)
+			r.Check(checkCtx)
+			cancel()
+		}
+	}
+}
+
+// pick returns the next healthy replica, or primary.
+func (r *Replicas) pick(primary *sql.DB) *sql.DB {
+	n := len(r.replicas)
+	start := r.next.Add(1)
+	for i := 0; i < n; i++ {
+		rp := r.replicas[(start+uint64(i))%uint64(n)]
+		if rp.healthy.Load() {
+			return rp.db
+		}
+	}
+	return primary
+}
+
+// Close closes the replicas' pools.
+func (r *Replicas) Close() error {
+	var first error
+	for _, rp := range r.replicas {
+		if err := rp.db.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
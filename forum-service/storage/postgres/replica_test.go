@@ -0,0 +1,65 @@
+package managers_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	pb "forum-service/forum-protos/genprotos"
+	managers "forum-service/storage/postgres"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadReplicaRouting(t *testing.T) {
+	fmt.Println("Testing read replica routing...")
+	primary, primaryMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer primary.Close()
+	replica, replicaMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer replica.Close()
+
+	// The replica's lag is simulated rather than measured.
+	lag := time.Duration(0)
+	replicas := managers.NewReplicas(time.Second)
+	replicas.Lag = func(context.Context, *sql.DB) (time.Duration, error) { return lag, nil }
+	replicas.Add("replica", replica)
+
+	categoryManager := managers.NewCategoryManager(primary)
+	categoryManager.Replicas = replicas
+	get := func(ctx context.Context) {
+		t.Helper()
+		_, err := categoryManager.GetByID(ctx, &pb.CategoryGReqOrDReq{CategoryId: "cat1"})
+		assert.NoError(t, err)
+	}
+	row := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"category_id", "name", "qa"}).AddRow("cat1", "General", false)
+	}
+
+	// Until checked, the replica is not trusted.
+	primaryMock.ExpectQuery("SELECT category_id, name, qa FROM categories").WillReturnRows(row())
+	get(ctx)
+
+	assert.Equal(t, 1, replicas.Check(ctx))
+	replicaMock.ExpectQuery("SELECT category_id, name, qa FROM categories").WillReturnRows(row())
+	get(ctx)
+
+	primaryMock.ExpectQuery("SELECT category_id, name, qa FROM categories").WillReturnRows(row())
+	get(managers.WithPrimary(ctx))
+
+	lag = 10 * time.Second
+	assert.Equal(t, 0, replicas.Check(ctx))
+	primaryMock.ExpectQuery("SELECT category_id, name, qa FROM categories").WillReturnRows(row())
+	get(ctx)
+
+	assert.NoError(t, primaryMock.ExpectationsWereMet())
+	assert.NoError(t, replicaMock.ExpectationsWereMet())
+	fmt.Println("OK. Reads follow the healthy replicas.")
+}
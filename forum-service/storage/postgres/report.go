@@ -0,0 +1,128 @@
+package managers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	pb "forum-service/forum-protos/genprotos"
+	"forum-service/models"
+	"time"
+)
+
+type ReportManager struct {
+	Conn *sql.DB
+}
+
+func NewReportManager(conn *sql.DB) *ReportManager {
+	return &ReportManager{Conn: conn}
+}
+
+// Create files a report. A user can report the same content only once.
+func (m *ReportManager) Create(ctx context.Context, req *pb.ReportCReq) (*pb.ReportRes, error) {
+	_, deleted, err := contentAuthor(ctx, conn(ctx, m.Conn), req.ContentType, req.ContentId)
+	if err != nil {
+		return nil, err
+	}
+	if deleted {
+		return nil, fmt.Errorf("%s not found", req.ContentType)
+	}
+
+	query := `INSERT INTO reports (content_type, content_id, reporter_id, reason) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (content_type, content_id, reporter_id) DO NOTHING
+		RETURNING report_id, content_type, content_id, reporter_id, reason, status, created_at`
+	r := &pb.ReportRes{}
+	var createdAt time.Time
+	err = conn(ctx, m.Conn).QueryRowContext(ctx, query, req.ContentType, req.ContentId, req.ReporterId, req.Reason).
+		Scan(&r.ReportId, &r.ContentType, &r.ContentId, &r.ReporterId, &r.Reason, &r.Status, &createdAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrAlreadyReported
+		}
+		return nil, err
+	}
+	r.CreatedAt = createdAt.Format(time.RFC3339)
+	return r, nil
+}
+
+func (m *ReportManager) GetByID(ctx context.Context, reportID string) (*pb.ReportRes, error) {
+	query := "SELECT report_id, content_type, content_id, reporter_id, reason, status, resolution, created_at FROM reports WHERE report_id = $1"
+	r := &pb.ReportRes{}
+	var resolution sql.NullString
+	var createdAt time.Time
+	err := conn(ctx, m.Conn).QueryRowContext(ctx, query, reportID).
+		Scan(&r.ReportId, &r.ContentType, &r.ContentId, &r.ReporterId, &r.Reason, &r.Status, &resolution, &createdAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("report not found")
+		}
+		return nil, err
+	}
+	r.Resolution = resolution.String
+	r.CreatedAt = createdAt.Format(time.RFC3339)
+	return r, nil
+}
+
+func (m *ReportManager) CountOpen(ctx context.Context, contentType, contentID string) (int, error) {
+	query := "SELECT COUNT(*) FROM reports WHERE content_type = $1 AND content_id = $2 AND status = $3"
+	var count int
+	err := conn(ctx, m.Conn).QueryRowContext(ctx, query, contentType, contentID, models.ReportOpen).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (m *ReportManager) GetAll(ctx context.Context, req *pb.ReportGAReq) (*pb.ReportGARes, error) {
+	query := "SELECT report_id, content_type, content_id, reporter_id, reason, status, resolution, created_at FROM reports WHERE status = $1"
+	status := req.Filter.Status
+	if status == "" {
+		status = models.ReportOpen
+	}
+	args := []interface{}{status}
+	paramIndex := 2
+	if req.Filter.ContentType != "" {
+		query += fmt.Sprintf(" AND content_type = $%d", paramIndex)
+		args = append(args, req.Filter.ContentType)
+		paramIndex++
+	}
+	if req.Filter.ContentId != "" {
+		query += fmt.Sprintf(" AND content_id = $%d", paramIndex)
+		args = append(args, req.Filter.ContentId)
+		paramIndex++
+	}
+	query += " ORDER BY created_at"
+	if req.Pagination.Limit != 0 {
+		query += fmt.Sprintf(" LIMIT $%d", paramIndex)
+		args = append(args, req.Pagination.Limit)
+		paramIndex++
+	}
+	if req.Pagination.Offset != 0 {
+		query += fmt.Sprintf(" OFFSET $%d", paramIndex)
+		args = append(args, req.Pagination.Offset)
+		paramIndex++
+	}
+	rows, err := conn(ctx, m.Conn).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reports := &pb.ReportGARes{}
+	for rows.Next() {
+		r := &pb.ReportRes{}
+		var resolution sql.NullString
+		var createdAt time.Time
+		if err := rows.Scan(&r.ReportId, &r.ContentType, &r.ContentId, &r.ReporterId, &r.Reason, &r.Status, &resolution, &createdAt); err != nil {
+			return nil, err
+		}
+		r.Resolution = resolution.String
+		r.CreatedAt = createdAt.Format(time.RFC3339)
+		reports.Reports = append(reports.Reports, r)
+		reports.Count++
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return reports, nil
+}
@@ -0,0 +1,40 @@
+package managers_test
+
+import (
+	"fmt"
+	pb "forum-service/forum-protos/genprotos"
+	"forum-service/models"
+	managers "forum-service/storage/postgres"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateDuplicateReport(t *testing.T) {
+	fmt.Println("Testing duplicate report...")
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	reportManager := managers.NewReportManager(db)
+
+	mock.ExpectQuery("SELECT user_id, deleted_at <> 0 FROM comments").
+		WithArgs("comment1").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "deleted"}).AddRow("author1", false))
+	mock.ExpectQuery("INSERT INTO reports").
+		WithArgs(models.ContentComment, "comment1", "user1", "spam").
+		WillReturnRows(sqlmock.NewRows([]string{"report_id", "content_type", "content_id", "reporter_id", "reason", "status", "created_at"}))
+
+	_, err = reportManager.Create(ctx, &pb.ReportCReq{
+		ContentType: models.ContentComment,
+		ContentId:   "comment1",
+		ReporterId:  "user1",
+		Reason:      "spam",
+	})
+	assert.ErrorIs(t, err, models.ErrAlreadyReported)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	fmt.Println("OK. Duplicate report refused.")
+}
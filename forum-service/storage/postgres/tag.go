@@ -1,45 +1,55 @@
 package managers
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	pb "forum-service/forum-protos/genprotos"
 )
 
 type TagManager struct {
-	Conn *sql.DB
+	Conn     *sql.DB
+	Replicas *Replicas // for reads, if set
+	tx       *Transactor
 }
 
 func NewTagManager(conn *sql.DB) *TagManager {
-	return &TagManager{Conn: conn}
+	return &TagManager{Conn: conn, tx: NewTransactor(conn)}
 }
 
-func (m *TagManager) Create(tx *sql.Tx, tag *pb.TagCReqOrCRes) (*pb.TagCReqOrCRes, error) {
-	query := "INSERT INTO tags (tag, post_id) VALUES ($1, $2) RETURNING tag, post_id"
+func (m *TagManager) Create(ctx context.Context, tag *pb.TagCReqOrCRes) (*pb.TagCReqOrCRes, error) {
 	t := &pb.TagCReqOrCRes{}
-	err := tx.QueryRow(query, tag.Tag, tag.PostId).Scan(&t.Tag, &t.PostId)
+	err := m.tx.InTx(ctx, func(ctx context.Context) error {
+		query := "INSERT INTO tags (tag, post_id) VALUES ($1, $2) RETURNING tag, post_id"
+		if err := conn(ctx, m.Conn).QueryRowContext(ctx, query, tag.Tag, tag.PostId).Scan(&t.Tag, &t.PostId); err != nil {
+			return err
+		}
+		query = "INSERT INTO tag_counts (tag, usage) VALUES ($1, 1) ON CONFLICT (tag) DO UPDATE SET usage = tag_counts.usage + 1"
+		_, err := conn(ctx, m.Conn).ExecContext(ctx, query, t.Tag)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 	return t, nil
 }
 
-func (m *TagManager) Delete(tx *sql.Tx, req *pb.TagGReqOrDReq) (*pb.Void, error) {
-	query := "DELETE FROM tags WHERE post_id = $1"
-	_, err := tx.Exec(query, req.PostId)
+// Delete removes a post's tags and takes them off the usage counts in the
+// same statement.
+func (m *TagManager) Delete(ctx context.Context, req *pb.TagGReqOrDReq) (*pb.Void, error) {
+	query := `WITH deleted AS (DELETE FROM tags WHERE post_id = $1 RETURNING tag)
+		UPDATE tag_counts SET usage = usage - d.count
+		FROM (SELECT tag, COUNT(*) AS count FROM deleted GROUP BY tag) d
+		WHERE tag_counts.tag = d.tag`
+	_, err := conn(ctx, m.Conn).ExecContext(ctx, query, req.PostId)
 	if err != nil {
 		return nil, err
 	}
 	return &pb.Void{}, nil
 }
 
-func (m *TagManager) GetPopular(req *pb.Pagination) (*pb.TagPopularRes, error) {
-	query := `
-		SELECT tag, COUNT(*) as count
-		FROM tags
-		GROUP BY tag
-		ORDER BY count DESC
-	`
+func (m *TagManager) GetPopular(ctx context.Context, req *pb.Pagination) (*pb.TagPopularRes, error) {
+	query := "SELECT tag, usage FROM tag_counts WHERE usage > 0 ORDER BY usage DESC, tag"
 	var args []interface{}
 	paramIndex := 1
 	if req.Limit > 0 {
@@ -52,7 +62,7 @@ func (m *TagManager) GetPopular(req *pb.Pagination) (*pb.TagPopularRes, error) {
 		args = append(args, req.Offset)
 		paramIndex++
 	}
-	rows, err := m.Conn.Query(query, args...)
+	rows, err := reader(ctx, m.Conn, m.Replicas).QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
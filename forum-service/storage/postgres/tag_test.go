@@ -1,8 +1,10 @@
 package managers_test
 
 import (
+	"context"
 	"fmt"
 	pb "forum-service/forum-protos/genprotos"
+	managers "forum-service/storage/postgres"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -10,27 +12,21 @@ import (
 
 func TestCreateTag(t *testing.T) {
 	fmt.Println("Testing create tag...")
-	tx, err := db.Begin()
-	if err != nil {
-		t.Fatalf("Failed to begin transaction: %v", err)
-	}
-	defer tx.Rollback()
-
 	tag := &pb.TagCReqOrCRes{
 		Tag:    "test-tag",
 		PostId: postId,
 	}
 
-	createdTag, err := tagManager.Create(tx, tag)
+	var createdTag *pb.TagCReqOrCRes
+	err := managers.NewTransactor(db).InTx(ctx, func(ctx context.Context) error {
+		var err error
+		createdTag, err = tagManager.Create(ctx, tag)
+		return err
+	})
 	if err != nil {
 		t.Fatalf("Failed to create tag: %v", err)
 	}
 
-	err = tx.Commit()
-	if err != nil {
-		t.Fatalf("Failed to commit transaction: %v", err)
-	}
-
 	assert.Equal(t, tag.Tag, createdTag.Tag)
 	assert.Equal(t, tag.PostId, createdTag.PostId)
 	fmt.Println("OK. Tag created successfully")
@@ -54,7 +50,7 @@ func TestCreateTag(t *testing.T) {
 // 		PostId: postId,
 // 	}
 
-// 	_, err = tagManager.Delete(tx, deleteReq)
+// 	_, err = tagManager.Delete(ctx, deleteReq)
 // 	if err != nil {
 // 		t.Fatalf("Failed to delete tag: %v", err)
 // 	}
@@ -0,0 +1,151 @@
+package managers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// DefaultTxRetries is how often a transaction that hit a serialization
+// failure or deadlock is run again before the error is returned.
+const DefaultTxRetries = 3
+
+// DBTX is what *sql.DB and *sql.Tx have in common.
+type DBTX interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+type txKey struct{}
+
+// txState is the transaction a context runs in.
+type txState struct {
+	tx          *sql.Tx
+	savepoints  int
+	afterCommit []func()
+}
+
+// conn returns the transaction ctx runs in, or db outside of one. Managers
+// run every statement through it so they join their caller's unit of work.
+func conn(ctx context.Context, db *sql.DB) DBTX {
+	if s, ok := ctx.Value(txKey{}).(*txState); ok {
+		return s.tx
+	}
+	return db
+}
+
+// Transactor runs units of work: functions whose repository calls, made with
+// the context they are given, commit or roll back together.
+type Transactor struct {
+	Conn       *sql.DB
+	MaxRetries int
+	Backoff    time.Duration // before the first retry, growing linearly
+}
+
+func NewTransactor(conn *sql.DB) *Transactor {
+	return &Transactor{Conn: conn, MaxRetries: DefaultTxRetries, Backoff: 10 * time.Millisecond}
+}
+
+// InTx runs fn in a transaction with the database's default isolation level.
+func (t *Transactor) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return t.InTxWith(ctx, nil, fn)
+}
+
+// InTxWith runs fn in a transaction with opts. fn may be run again after a
+// serialization failure or deadlock, so it must not have effects outside
+// the database.
+//
+// Inside another unit of work fn runs under a savepoint instead: an error
+// undoes only fn's statements, and opts and retries are left to the
+// outermost unit.
+func (t *Transactor) InTxWith(ctx context.Context, opts *sql.TxOptions, fn func(ctx context.Context) error) error {
+	if s, ok := ctx.Value(txKey{}).(*txState); ok {
+		return s.savepoint(ctx, fn)
+	}
+	for attempt := 0; ; attempt++ {
+		err := t.run(ctx, opts, fn)
+		if err == nil || !retryable(err) || attempt >= t.MaxRetries {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(time.Duration(attempt+1) * t.Backoff):
+		}
+	}
+}
+
+func (t *Transactor) run(ctx context.Context, opts *sql.TxOptions, fn func(ctx context.Context) error) error {
+	tx, err := t.Conn.BeginTx(ctx, opts)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+	s := &txState{tx: tx}
+	if err := fn(context.WithValue(ctx, txKey{}, s)); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	for _, fn := range s.afterCommit {
+		fn()
+	}
+	return nil
+}
+
+// AfterCommit runs fn once the transaction ctx runs in has committed, or
+// right away outside of one. fn is dropped if its statements are rolled
+// back, and runs once however often the transaction is retried.
+func (t *Transactor) AfterCommit(ctx context.Context, fn func()) {
+	if s, ok := ctx.Value(txKey{}).(*txState); ok {
+		s.afterCommit = append(s.afterCommit, fn)
+		return
+	}
+	fn()
+}
+
+// InUnit tells whether ctx runs in a transaction.
+func (t *Transactor) InUnit(ctx context.Context) bool {
+	_, ok := ctx.Value(txKey{}).(*txState)
+	return ok
+}
+
+func (s *txState) savepoint(ctx context.Context, fn func(ctx context.Context) error) error {
+	s.savepoints++
+	name := fmt.Sprintf("sp_%d", s.savepoints)
+	if _, err := s.tx.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		return err
+	}
+	hooks := len(s.afterCommit)
+	if err := fn(ctx); err != nil {
+		s.afterCommit = s.afterCommit[:hooks]
+		if _, rbErr := s.tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name); rbErr != nil {
+			return errors.Join(err, rbErr)
+		}
+		return err
+	}
+	_, err := s.tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name)
+	return err
+}
+
+// retryable reports whether err is a serialization failure or deadlock, after
+// which the whole transaction may succeed if run again.
+func retryable(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	return pqErr.Code == "40001" || pqErr.Code == "40P01"
+}
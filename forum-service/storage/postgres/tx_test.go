@@ -0,0 +1,148 @@
+package managers_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	pb "forum-service/forum-protos/genprotos"
+	managers "forum-service/storage/postgres"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInTxRetriesSerializationFailure(t *testing.T) {
+	fmt.Println("Testing retry after a serialization failure...")
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	tagManager := managers.NewTagManager(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM tags").WithArgs("post1").WillReturnError(&pq.Error{Code: "40001"})
+	mock.ExpectRollback()
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM tags").WithArgs("post1").WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	runs := 0
+	err = managers.NewTransactor(db).InTx(ctx, func(ctx context.Context) error {
+		runs++
+		_, err := tagManager.Delete(ctx, &pb.TagGReqOrDReq{PostId: "post1"})
+		return err
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, runs)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	fmt.Println("OK. Transaction retried.")
+}
+
+func TestInTxDoesNotRetryOtherErrors(t *testing.T) {
+	fmt.Println("Testing no retry after an ordinary error...")
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	failed := errors.New("validation failed")
+	runs := 0
+	err = managers.NewTransactor(db).InTx(ctx, func(ctx context.Context) error {
+		runs++
+		return failed
+	})
+	assert.ErrorIs(t, err, failed)
+	assert.Equal(t, 1, runs)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	fmt.Println("OK. Error returned without retry.")
+}
+
+func TestInTxNestedSavepoint(t *testing.T) {
+	fmt.Println("Testing nested unit of work...")
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	tagManager := managers.NewTagManager(db)
+	transactor := managers.NewTransactor(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM tags").WithArgs("post1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("SAVEPOINT sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("DELETE FROM tags").WithArgs("post2").WillReturnError(errors.New("boom"))
+	mock.ExpectExec("ROLLBACK TO SAVEPOINT sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	err = transactor.InTx(ctx, func(ctx context.Context) error {
+		if _, err := tagManager.Delete(ctx, &pb.TagGReqOrDReq{PostId: "post1"}); err != nil {
+			return err
+		}
+		// Only the inner unit is undone; the outer one still commits.
+		inner := transactor.InTx(ctx, func(ctx context.Context) error {
+			_, err := tagManager.Delete(ctx, &pb.TagGReqOrDReq{PostId: "post2"})
+			return err
+		})
+		assert.Error(t, inner)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	fmt.Println("OK. Savepoint rolled back, transaction committed.")
+}
+
+func TestAfterCommit(t *testing.T) {
+	fmt.Println("Testing hooks run after commit...")
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	transactor := managers.NewTransactor(db)
+
+	var ran []string
+	transactor.AfterCommit(ctx, func() { ran = append(ran, "now") })
+	assert.Equal(t, []string{"now"}, ran, "outside a transaction the hook runs right away")
+
+	mock.ExpectBegin()
+	mock.ExpectExec("SAVEPOINT sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("ROLLBACK TO SAVEPOINT sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	ran = nil
+	err = transactor.InTx(ctx, func(ctx context.Context) error {
+		transactor.AfterCommit(ctx, func() { ran = append(ran, "outer") })
+		inner := transactor.InTx(ctx, func(ctx context.Context) error {
+			transactor.AfterCommit(ctx, func() { ran = append(ran, "undone") })
+			return errors.New("boom")
+		})
+		assert.Error(t, inner)
+		assert.Empty(t, ran, "nothing runs before the commit")
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"outer"}, ran)
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	ran = nil
+	err = transactor.InTx(ctx, func(ctx context.Context) error {
+		transactor.AfterCommit(ctx, func() { ran = append(ran, "rolled back") })
+		return errors.New("boom")
+	})
+	assert.Error(t, err)
+	assert.Empty(t, ran, "a rolled back transaction runs no hooks")
+	assert.NoError(t, mock.ExpectationsWereMet())
+	fmt.Println("OK. Hooks ran after the commit only.")
+}
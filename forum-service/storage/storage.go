@@ -1,44 +1,134 @@
 package storage
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
+	"forum-service/config"
 	pb "forum-service/forum-protos/genprotos"
+	"forum-service/models"
+	"forum-service/render"
+	"time"
 )
 
+// New builds the storage selected by STORAGE_BACKEND.
+func New(cfg config.Config) (*Storage, error) {
+	switch cfg.STORAGE_BACKEND {
+	case "postgres":
+		return NewPostgresStorage(cfg)
+	case "memory":
+		return NewMemoryStorage(), nil
+	}
+	return nil, fmt.Errorf("unknown storage backend %q", cfg.STORAGE_BACKEND)
+}
+
 type StorageI interface {
 	Post() PostI
 	Comment() CommentI
 	Category() CategoryI
 	Tag() TagI
+	Moderation() ModerationI
+	Report() ReportI
+	Render() RenderI
+	Attachment() AttachmentI
+	Poll() PollI
+	Counter() CounterI
+}
+
+// TransactorI runs units of work. Repository calls made with the context
+// passed to fn share one transaction; a unit of work started inside another
+// becomes a savepoint.
+type TransactorI interface {
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
+	InTxWith(ctx context.Context, opts *sql.TxOptions, fn func(ctx context.Context) error) error
+	// AfterCommit runs fn once the outermost unit of work ctx runs in has
+	// committed, or right away outside of one.
+	AfterCommit(ctx context.Context, fn func())
+	// InUnit tells whether ctx runs in a unit of work.
+	InUnit(ctx context.Context) bool
 }
 
 type PostI interface {
-	Create(*pb.PostCReqOrCResOrGResOrUResp, []string) (*pb.PostCReqOrCResOrGResOrUResp, error)
-	GetByID(*pb.PostGReqOrDReq) (*pb.PostCReqOrCResOrGResOrUResp, error)
-	GetAll(*pb.PostGAReq) (*pb.PostGARes, error)
-	Update(*pb.PostUReq, []string) (*pb.PostCReqOrCResOrGResOrUResp, error)
-	Delete(*pb.PostGReqOrDReq) (*pb.Void, error)
+	Create(context.Context, *pb.PostCReqOrCResOrGResOrUResp, []string) (*pb.PostCReqOrCResOrGResOrUResp, error)
+	GetByID(context.Context, *pb.PostGReqOrDReq) (*pb.PostCReqOrCResOrGResOrUResp, error)
+	GetAll(context.Context, *pb.PostGAReq) (*pb.PostGARes, error)
+	Update(ctx context.Context, req *pb.PostUReq, tags []string, status string) (*pb.PostCReqOrCResOrGResOrUResp, error)
+	Delete(context.Context, *pb.PostGReqOrDReq) (*pb.Void, error)
+	SetAcceptedAnswer(ctx context.Context, postID, commentID string) (*pb.PostCReqOrCResOrGResOrUResp, error)
 }
 
 type CommentI interface {
-	Create(*pb.CommentCReqOrCResOrGResOrURes) (*pb.CommentCReqOrCResOrGResOrURes, error)
-	GetByID(*pb.CommentGReqOrDReq) (*pb.CommentCReqOrCResOrGResOrURes, error)
-	GetAll(*pb.CommentGAReq) (*pb.CommentGARes, error)
-	Update(*pb.CommentUReq) (*pb.CommentCReqOrCResOrGResOrURes, error)
-	Delete(*sql.Tx, *pb.CommentGReqOrDReq) (*pb.Void, error)
-	DeleteByPostID(*sql.Tx, *pb.CommentGReqOrDReqByPostID) (*pb.Void, error)
+	Create(context.Context, *pb.CommentCReqOrCResOrGResOrURes) (*pb.CommentCReqOrCResOrGResOrURes, error)
+	GetByID(context.Context, *pb.CommentGReqOrDReq) (*pb.CommentCReqOrCResOrGResOrURes, error)
+	// GetAll lists comments under the posts viewer can see.
+	GetAll(ctx context.Context, req *pb.CommentGAReq, viewer models.Viewer) (*pb.CommentGARes, error)
+	Update(ctx context.Context, req *pb.CommentUReq, status string) (*pb.CommentCReqOrCResOrGResOrURes, error)
+	Delete(context.Context, *pb.CommentGReqOrDReq) (*pb.Void, error)
+	DeleteByPostID(context.Context, *pb.CommentGReqOrDReqByPostID) (*pb.Void, error)
 }
 
 type CategoryI interface {
-	Create(*pb.CategoryCReqOrCResOrGResOrUReqOrURes) (*pb.CategoryCReqOrCResOrGResOrUReqOrURes, error)
-	GetByID(*pb.CategoryGReqOrDReq) (*pb.CategoryCReqOrCResOrGResOrUReqOrURes, error)
-	GetAll(*pb.CategoryGAReq) (*pb.CategoryGARes, error)
-	Update(*pb.CategoryCReqOrCResOrGResOrUReqOrURes) (*pb.CategoryCReqOrCResOrGResOrUReqOrURes, error)
-	Delete(*pb.CategoryGReqOrDReq) (*pb.Void, error)
+	Create(context.Context, *pb.CategoryCReqOrCResOrGResOrUReqOrURes) (*pb.CategoryCReqOrCResOrGResOrUReqOrURes, error)
+	GetByID(context.Context, *pb.CategoryGReqOrDReq) (*pb.CategoryCReqOrCResOrGResOrUReqOrURes, error)
+	GetAll(context.Context, *pb.CategoryGAReq) (*pb.CategoryGARes, error)
+	Update(context.Context, *pb.CategoryUReq) (*pb.CategoryCReqOrCResOrGResOrUReqOrURes, error)
+	Delete(context.Context, *pb.CategoryGReqOrDReq) (*pb.Void, error)
 }
 
 type TagI interface {
-	Create(*sql.Tx, *pb.TagCReqOrCRes) (*pb.TagCReqOrCRes, error)
-	Delete(*sql.Tx, *pb.TagGReqOrDReq) (*pb.Void, error)
-	GetPopular(*pb.Pagination) (*pb.TagPopularRes, error)
+	Create(context.Context, *pb.TagCReqOrCRes) (*pb.TagCReqOrCRes, error)
+	Delete(context.Context, *pb.TagGReqOrDReq) (*pb.Void, error)
+	GetPopular(context.Context, *pb.Pagination) (*pb.TagPopularRes, error)
+}
+
+type ModerationI interface {
+	CountRecent(ctx context.Context, userID string, window time.Duration) (int, error)
+	IsEstablished(ctx context.Context, userID string, age time.Duration) (bool, error)
+	HasDuplicate(ctx context.Context, userID, body string, window time.Duration) (bool, error)
+	Flag(ctx context.Context, contentType, contentID string, flags []models.ModerationFlag) error
+	SetPostStatus(context.Context, *pb.ModerationDecision, string) (*pb.Void, error)
+	SetCommentStatus(context.Context, *pb.ModerationDecision, string) (*pb.Void, error)
+	AutoHide(ctx context.Context, contentType, contentID string) (bool, error)
+	Resolve(context.Context, *pb.ReportResolveReq) (*pb.Void, error)
+	GetLog(context.Context, *pb.ModerationLogGAReq) (*pb.ModerationLogGARes, error)
+	Mute(context.Context, *pb.MuteReq, *time.Time) (*pb.Void, error)
+	Unmute(context.Context, *pb.MuteReq) (*pb.Void, error)
+	IsMuted(ctx context.Context, userID string) (bool, error)
+	SetPostFlag(ctx context.Context, req *pb.PostFlagReq, flag, action string) (*pb.PostCReqOrCResOrGResOrUResp, error)
+}
+
+type ReportI interface {
+	Create(context.Context, *pb.ReportCReq) (*pb.ReportRes, error)
+	GetByID(ctx context.Context, reportID string) (*pb.ReportRes, error)
+	CountOpen(ctx context.Context, contentType, contentID string) (int, error)
+	GetAll(context.Context, *pb.ReportGAReq) (*pb.ReportGARes, error)
+}
+
+type RenderI interface {
+	GetAll(ctx context.Context, contentType string, version int, keys []render.Key) (map[render.Key]string, error)
+	PutAll(ctx context.Context, contentType string, version int, html map[render.Key]string) error
+}
+
+type AttachmentI interface {
+	Create(context.Context, *pb.AttachmentRes, string) (*pb.AttachmentRes, error)
+	GetByID(context.Context, *pb.AttachmentGReqOrDReq) (*pb.AttachmentRes, string, error)
+	GetAll(context.Context, *pb.AttachmentGAReq) (*pb.AttachmentGARes, error)
+	Delete(context.Context, *pb.AttachmentGReqOrDReq) (*pb.Void, error)
+	GetVariants(ctx context.Context, attachmentID string) ([]models.AttachmentVariant, error)
+	ClaimImage(ctx context.Context, lease time.Duration) (*pb.AttachmentRes, string, int, error)
+	SaveVariants(ctx context.Context, attachmentID string, original models.AttachmentVariant, variants []models.AttachmentVariant) error
+	RetryProcessing(ctx context.Context, attachmentID, reason string, delay time.Duration) error
+	FailProcessing(ctx context.Context, attachmentID, reason string) error
+}
+
+type PollI interface {
+	Create(ctx context.Context, req *pb.PollCReq, closesAt *time.Time) (*pb.PollRes, error)
+	Get(context.Context, *pb.PollGReq) (*pb.PollRes, error)
+	Vote(context.Context, *pb.PollVoteReq) error
+	Close(ctx context.Context, postID string) error
+}
+
+// CounterI repairs the cached comment and tag counts.
+type CounterI interface {
+	Repair(context.Context) (models.CounterRepair, error)
 }
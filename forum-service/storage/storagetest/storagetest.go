@@ -0,0 +1,419 @@
+// Package storagetest checks that a storage backend behaves like the others.
+// Every backend's tests run the same suite, so the in-memory storage keeps
+// the PostgreSQL semantics the services rely on.
+//
+// The suite creates its own rows under fresh IDs and only looks at those, so
+// it can run against a database that already holds data.
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	pb "forum-service/forum-protos/genprotos"
+	"forum-service/models"
+	"forum-service/storage"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var ctx = context.Background()
+
+// Run runs the conformance suite against st.
+func Run(t *testing.T, st *storage.Storage) {
+	t.Run("Categories", func(t *testing.T) { testCategories(t, st) })
+	t.Run("Posts", func(t *testing.T) { testPosts(t, st) })
+	t.Run("PostFilters", func(t *testing.T) { testPostFilters(t, st) })
+	t.Run("Comments", func(t *testing.T) { testComments(t, st) })
+	t.Run("PopularTags", func(t *testing.T) { testPopularTags(t, st) })
+	t.Run("Counters", func(t *testing.T) { testCounters(t, st) })
+	t.Run("Transactions", func(t *testing.T) { testTransactions(t, st) })
+	t.Run("Reports", func(t *testing.T) { testReports(t, st) })
+}
+
+func testCategories(t *testing.T, st *storage.Storage) {
+	fmt.Println("Testing categories...")
+	id := newCategory(t, st, "Conformance")
+
+	qa := true
+	cat, err := st.CategoryS.Update(ctx, &pb.CategoryUReq{CategoryId: id, Name: "Renamed", Qa: &qa})
+	require.NoError(t, err)
+	assert.Equal(t, "Renamed", cat.Name)
+	assert.True(t, cat.Qa)
+
+	cat, err = st.CategoryS.Update(ctx, &pb.CategoryUReq{CategoryId: id, Name: "Renamed again"})
+	require.NoError(t, err)
+	assert.Equal(t, "Renamed again", cat.Name)
+	assert.True(t, cat.Qa, "a rename without qa keeps the flag")
+
+	all, err := st.CategoryS.GetAll(ctx, &pb.CategoryGAReq{Filter: &pb.CategoryFilter{CategoryId: id}, Pagination: &pb.Pagination{}})
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), all.Count)
+
+	_, err = st.CategoryS.Delete(ctx, &pb.CategoryGReqOrDReq{CategoryId: id})
+	require.NoError(t, err)
+	_, err = st.CategoryS.GetByID(ctx, &pb.CategoryGReqOrDReq{CategoryId: id})
+	assert.Error(t, err, "deleted categories are not found")
+	all, err = st.CategoryS.GetAll(ctx, &pb.CategoryGAReq{Filter: &pb.CategoryFilter{CategoryId: id}, Pagination: &pb.Pagination{}})
+	require.NoError(t, err)
+	assert.Zero(t, all.Count)
+	fmt.Println("OK. Categories behave the same")
+}
+
+func testPosts(t *testing.T, st *storage.Storage) {
+	fmt.Println("Testing posts...")
+	categoryID := newCategory(t, st, "Posts")
+	userID := uuid.NewString()
+	post := newPost(ctx, t, st, userID, categoryID, "First", "go,sql")
+	assert.Equal(t, "published", post.Status)
+	assert.Equal(t, int64(1), post.Revision)
+
+	updated, err := st.PostS.Update(ctx, &pb.PostUReq{PostId: post.PostId, Title: "First", Body: "changed", CategoryId: categoryID, Tags: "go"}, []string{"go"}, "published")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), updated.Revision, "a new body is a new revision")
+	updated, err = st.PostS.Update(ctx, &pb.PostUReq{PostId: post.PostId, Title: "Retitled", Body: "changed", CategoryId: categoryID, Tags: "go"}, []string{"go"}, "pending")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), updated.Revision, "the same body keeps its revision")
+	assert.Equal(t, "pending", updated.Status, "an edit stores the status moderation gave it")
+
+	_, err = st.PostS.Update(ctx, &pb.PostUReq{PostId: uuid.NewString(), CategoryId: categoryID}, nil, "published")
+	assert.Error(t, err)
+
+	comment := newComment(t, st, uuid.NewString(), post.PostId)
+	_, err = st.PostS.Delete(ctx, &pb.PostGReqOrDReq{PostId: post.PostId})
+	require.NoError(t, err)
+
+	_, err = st.PostS.GetByID(ctx, &pb.PostGReqOrDReq{PostId: post.PostId})
+	assert.Error(t, err, "deleted posts are not found")
+	posts, err := st.PostS.GetAll(ctx, &pb.PostGAReq{Filter: &pb.PostFilter{UserId: userID}, Pagination: &pb.Pagination{}})
+	require.NoError(t, err)
+	assert.Zero(t, posts.Count, "deleted posts are not listed")
+	comments, err := st.CommentS.GetAll(ctx, &pb.CommentGAReq{Filter: &pb.CommentFilter{PostId: post.PostId}, Pagination: &pb.Pagination{}}, models.Viewer{Moderator: true})
+	require.NoError(t, err)
+	assert.Zero(t, comments.Count, "a post's comments go with it")
+	_, err = st.CommentS.GetByID(ctx, &pb.CommentGReqOrDReq{CommentId: comment.CommentId})
+	assert.Error(t, err, "deleted comments are not found")
+
+	_, err = st.PostS.GetByID(ctx, &pb.PostGReqOrDReq{PostId: uuid.NewString()})
+	assert.Error(t, err)
+
+	// Held posts are read with their status, which the services show only
+	// to the author and moderators; nobody lists them.
+	held, err := st.PostS.Create(ctx, &pb.PostCReqOrCResOrGResOrUResp{
+		PostId:     uuid.NewString(),
+		UserId:     userID,
+		Title:      "Held",
+		Body:       "Held for review",
+		CategoryId: categoryID,
+		Status:     "pending",
+	}, nil)
+	require.NoError(t, err)
+	got, err := st.PostS.GetByID(ctx, &pb.PostGReqOrDReq{PostId: held.PostId})
+	require.NoError(t, err)
+	assert.Equal(t, "pending", got.Status)
+	posts, err = st.PostS.GetAll(ctx, &pb.PostGAReq{Filter: &pb.PostFilter{UserId: userID}, Pagination: &pb.Pagination{}})
+	require.NoError(t, err)
+	assert.Zero(t, posts.Count, "held posts are not listed")
+	fmt.Println("OK. Posts behave the same")
+}
+
+func testPostFilters(t *testing.T, st *storage.Storage) {
+	fmt.Println("Testing post filters and pagination...")
+	categoryID := newCategory(t, st, "Filters")
+	userID := uuid.NewString()
+	first := newPost(ctx, t, st, userID, categoryID, "One", "Go,Testing")
+	second := newPost(ctx, t, st, userID, categoryID, "Two", "rust")
+	third := newPost(ctx, t, st, userID, categoryID, "Three", "go")
+	_, err := st.ModerationS.SetPostFlag(ctx, &pb.PostFlagReq{PostId: first.PostId, Value: true}, "pinned", "pin")
+	require.NoError(t, err)
+
+	list := func(f *pb.PostFilter, p *pb.Pagination) []string {
+		t.Helper()
+		res, err := st.PostS.GetAll(ctx, &pb.PostGAReq{Filter: f, Pagination: p})
+		require.NoError(t, err)
+		var ids []string
+		for _, p := range res.Posts {
+			ids = append(ids, p.PostId)
+		}
+		assert.Equal(t, int32(len(ids)), res.Count)
+		return ids
+	}
+
+	assert.Equal(t, []string{third.PostId, second.PostId, first.PostId}, list(&pb.PostFilter{UserId: userID}, &pb.Pagination{}),
+		"newest first")
+	assert.Equal(t, []string{first.PostId, third.PostId, second.PostId}, list(&pb.PostFilter{CategoryId: categoryID}, &pb.Pagination{}),
+		"pinned posts lead their category")
+	assert.Equal(t, []string{second.PostId}, list(&pb.PostFilter{UserId: userID}, &pb.Pagination{Limit: 1, Offset: 1}))
+	assert.Equal(t, []string{third.PostId, first.PostId}, list(&pb.PostFilter{UserId: userID, Tags: "GO"}, &pb.Pagination{}),
+		"tags match case-insensitively")
+	assert.Equal(t, []string{second.PostId}, list(&pb.PostFilter{UserId: userID, Title: "Two"}, &pb.Pagination{}))
+	assert.Empty(t, list(&pb.PostFilter{UserId: userID, Status: "pending"}, &pb.Pagination{}))
+	fmt.Println("OK. Post filters behave the same")
+}
+
+func testComments(t *testing.T, st *storage.Storage) {
+	fmt.Println("Testing comments...")
+	post := newPost(ctx, t, st, uuid.NewString(), newCategory(t, st, "Comments"), "Question", "")
+	first := newComment(t, st, uuid.NewString(), post.PostId)
+	second := newComment(t, st, uuid.NewString(), post.PostId)
+
+	list := func() []*pb.CommentCReqOrCResOrGResOrURes {
+		t.Helper()
+		res, err := st.CommentS.GetAll(ctx, &pb.CommentGAReq{Filter: &pb.CommentFilter{PostId: post.PostId}, Pagination: &pb.Pagination{}}, models.Viewer{})
+		require.NoError(t, err)
+		return res.Comments
+	}
+
+	comments := list()
+	require.Len(t, comments, 2)
+	assert.Equal(t, first.CommentId, comments[0].CommentId, "oldest first")
+
+	_, err := st.PostS.SetAcceptedAnswer(ctx, post.PostId, second.CommentId)
+	require.NoError(t, err)
+	comments = list()
+	require.Len(t, comments, 2)
+	assert.Equal(t, second.CommentId, comments[0].CommentId, "the accepted answer leads")
+	assert.True(t, comments[0].Accepted)
+
+	_, err = st.CommentS.Delete(ctx, &pb.CommentGReqOrDReq{CommentId: second.CommentId})
+	require.NoError(t, err)
+	got, err := st.PostS.GetByID(ctx, &pb.PostGReqOrDReq{PostId: post.PostId})
+	require.NoError(t, err)
+	assert.Empty(t, got.AcceptedCommentId, "a deleted comment no longer answers")
+	assert.Len(t, list(), 1)
+
+	// Comments are listed only under posts the viewer can see, whatever
+	// the filter.
+	_, err = st.ModerationS.SetPostStatus(ctx, &pb.ModerationDecision{Id: post.PostId}, "hidden")
+	require.NoError(t, err)
+	byUser := func(viewer models.Viewer) int32 {
+		t.Helper()
+		res, err := st.CommentS.GetAll(ctx, &pb.CommentGAReq{Filter: &pb.CommentFilter{UserId: first.UserId}, Pagination: &pb.Pagination{}}, viewer)
+		require.NoError(t, err)
+		return res.Count
+	}
+	assert.Zero(t, byUser(models.Viewer{}))
+	assert.Zero(t, byUser(models.Viewer{UserID: first.UserId}), "the commenter doesn't own the post")
+	assert.Equal(t, int32(1), byUser(models.Viewer{UserID: post.UserId}))
+	assert.Equal(t, int32(1), byUser(models.Viewer{Moderator: true}))
+	fmt.Println("OK. Comments behave the same")
+}
+
+func testPopularTags(t *testing.T, st *storage.Storage) {
+	fmt.Println("Testing popular tags...")
+	categoryID := newCategory(t, st, "Tags")
+	common, rare := "common-"+uuid.NewString(), "rare-"+uuid.NewString()
+	newPost(ctx, t, st, uuid.NewString(), categoryID, "A", common)
+	newPost(ctx, t, st, uuid.NewString(), categoryID, "B", common+","+rare)
+	deleted := newPost(ctx, t, st, uuid.NewString(), categoryID, "C", common)
+	_, err := st.PostS.Delete(ctx, &pb.PostGReqOrDReq{PostId: deleted.PostId})
+	require.NoError(t, err)
+
+	res, err := st.TagS.GetPopular(ctx, &pb.Pagination{})
+	require.NoError(t, err)
+	counts, order := map[string]int64{}, map[string]int{}
+	for i, tag := range res.Tags {
+		counts[tag.Tag], order[tag.Tag] = tag.Count, i
+	}
+	assert.Equal(t, int64(3), counts[common], "tags of deleted posts still count")
+	assert.Equal(t, int64(1), counts[rare])
+	assert.Less(t, order[common], order[rare], "most used first")
+
+	res, err = st.TagS.GetPopular(ctx, &pb.Pagination{Limit: 1})
+	require.NoError(t, err)
+	assert.Len(t, res.Tags, 1)
+	fmt.Println("OK. Popular tags behave the same")
+}
+
+func testCounters(t *testing.T, st *storage.Storage) {
+	fmt.Println("Testing counter caches...")
+	categoryID := newCategory(t, st, "Counters")
+	post := newPost(ctx, t, st, uuid.NewString(), categoryID, "Counted", "")
+	assert.Zero(t, post.CommentCount)
+	assert.NotEmpty(t, post.LastActivityAt)
+
+	count := func() int64 {
+		t.Helper()
+		res, err := st.PostS.GetAll(ctx, &pb.PostGAReq{Filter: &pb.PostFilter{CategoryId: categoryID}, Pagination: &pb.Pagination{}})
+		require.NoError(t, err)
+		require.Len(t, res.Posts, 1)
+		return res.Posts[0].CommentCount
+	}
+
+	first := newComment(t, st, uuid.NewString(), post.PostId)
+	second := newComment(t, st, uuid.NewString(), post.PostId)
+	held, err := st.CommentS.Create(ctx, &pb.CommentCReqOrCResOrGResOrURes{
+		CommentId: uuid.NewString(),
+		UserId:    uuid.NewString(),
+		PostId:    post.PostId,
+		Body:      "Held for review",
+		Status:    "pending",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count(), "held comments don't count")
+
+	_, err = st.ModerationS.SetCommentStatus(ctx, &pb.ModerationDecision{Id: held.CommentId}, "published")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), count(), "approved comments count")
+
+	hidden, err := st.ModerationS.AutoHide(ctx, "comment", held.CommentId)
+	require.NoError(t, err)
+	assert.True(t, hidden)
+	assert.Equal(t, int64(2), count(), "hidden comments don't count")
+
+	edited, err := st.CommentS.Update(ctx, &pb.CommentUReq{CommentId: second.CommentId, Body: "Edited"}, "pending")
+	require.NoError(t, err)
+	assert.Equal(t, "pending", edited.Status)
+	assert.Equal(t, int64(1), count(), "an edit held for review stops counting")
+	_, err = st.CommentS.Update(ctx, &pb.CommentUReq{CommentId: second.CommentId, Body: "Edited"}, "published")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count())
+
+	for i := 0; i < 2; i++ {
+		_, err = st.CommentS.Delete(ctx, &pb.CommentGReqOrDReq{CommentId: first.CommentId})
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int64(1), count(), "a comment stops counting once")
+
+	tag := "counted-" + uuid.NewString()
+	usage := func() int64 {
+		t.Helper()
+		res, err := st.TagS.GetPopular(ctx, &pb.Pagination{})
+		require.NoError(t, err)
+		for _, popular := range res.Tags {
+			if popular.Tag == tag {
+				return popular.Count
+			}
+		}
+		return 0
+	}
+	tagged := newPost(ctx, t, st, uuid.NewString(), categoryID, "Tagged", tag)
+	assert.Equal(t, int64(1), usage())
+	_, err = st.PostS.Update(ctx, &pb.PostUReq{PostId: tagged.PostId, Title: "Tagged", Body: "untagged", CategoryId: categoryID}, nil, "published")
+	require.NoError(t, err)
+	assert.Zero(t, usage(), "unused tags drop out")
+
+	_, err = st.CounterS.Repair(ctx)
+	require.NoError(t, err)
+	got, err := st.PostS.GetByID(ctx, &pb.PostGReqOrDReq{PostId: post.PostId})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), got.CommentCount, "a repair keeps correct counts")
+	fmt.Println("OK. Counter caches behave the same")
+}
+
+func testTransactions(t *testing.T, st *storage.Storage) {
+	fmt.Println("Testing units of work...")
+	categoryID := newCategory(t, st, "Transactions")
+	userID := uuid.NewString()
+	errAbort := errors.New("abort")
+
+	err := st.Tx.InTx(ctx, func(ctx context.Context) error {
+		newPost(ctx, t, st, userID, categoryID, "Rolled back", "")
+		return errAbort
+	})
+	assert.ErrorIs(t, err, errAbort)
+
+	var ran []string
+	err = st.Tx.InTx(ctx, func(ctx context.Context) error {
+		newPost(ctx, t, st, userID, categoryID, "Kept", "")
+		st.Tx.AfterCommit(ctx, func() { ran = append(ran, "outer") })
+		inner := st.Tx.InTx(ctx, func(ctx context.Context) error {
+			newPost(ctx, t, st, userID, categoryID, "Undone", "")
+			st.Tx.AfterCommit(ctx, func() { ran = append(ran, "undone") })
+			return errAbort
+		})
+		assert.ErrorIs(t, inner, errAbort)
+		assert.Empty(t, ran, "nothing runs before the commit")
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"outer"}, ran, "only work that was kept runs after the commit")
+
+	res, err := st.PostS.GetAll(ctx, &pb.PostGAReq{Filter: &pb.PostFilter{UserId: userID}, Pagination: &pb.Pagination{}})
+	require.NoError(t, err)
+	require.Len(t, res.Posts, 1, "only the outer unit's own post is kept")
+	assert.Equal(t, "Kept", res.Posts[0].Title)
+	fmt.Println("OK. Units of work behave the same")
+}
+
+func testReports(t *testing.T, st *storage.Storage) {
+	fmt.Println("Testing report resolution...")
+	post := newPost(ctx, t, st, uuid.NewString(), newCategory(t, st, "Reports"), "Reported", "")
+	moderatorID := uuid.NewString()
+	report := func() string {
+		t.Helper()
+		r, err := st.ReportS.Create(ctx, &pb.ReportCReq{ContentType: "post", ContentId: post.PostId, ReporterId: uuid.NewString(), Reason: "spam"})
+		require.NoError(t, err)
+		return r.ReportId
+	}
+	resolve := func(reportID, action string) {
+		t.Helper()
+		_, err := st.ModerationS.Resolve(ctx, &pb.ReportResolveReq{ReportId: reportID, ModeratorId: moderatorID, Action: action})
+		require.NoError(t, err)
+	}
+	status := func() string {
+		t.Helper()
+		got, err := st.PostS.GetByID(ctx, &pb.PostGReqOrDReq{PostId: post.PostId})
+		require.NoError(t, err)
+		return got.Status
+	}
+
+	reportID := report()
+	hidden, err := st.ModerationS.AutoHide(ctx, "post", post.PostId)
+	require.NoError(t, err)
+	require.True(t, hidden)
+	resolve(reportID, "dismiss")
+	assert.Equal(t, "published", status(), "dismissing undoes the automatic hide")
+
+	resolve(report(), "hide")
+	reportID = report()
+	hidden, err = st.ModerationS.AutoHide(ctx, "post", post.PostId)
+	require.NoError(t, err)
+	assert.False(t, hidden, "already hidden")
+	resolve(reportID, "dismiss")
+	assert.Equal(t, "hidden", status(), "a moderator's hide stands")
+	fmt.Println("OK. Reports resolve the same")
+}
+
+func newCategory(t *testing.T, st *storage.Storage, name string) string {
+	t.Helper()
+	cat, err := st.CategoryS.Create(ctx, &pb.CategoryCReqOrCResOrGResOrUReqOrURes{CategoryId: uuid.NewString(), Name: name})
+	require.NoError(t, err)
+	return cat.CategoryId
+}
+
+// newPost creates a post with comma separated tags.
+func newPost(ctx context.Context, t *testing.T, st *storage.Storage, userID, categoryID, title, tags string) *pb.PostCReqOrCResOrGResOrUResp {
+	t.Helper()
+	var tagList []string
+	if tags != "" {
+		tagList = strings.Split(tags, ",")
+	}
+	post, err := st.PostS.Create(ctx, &pb.PostCReqOrCResOrGResOrUResp{
+		PostId:     uuid.NewString(),
+		UserId:     userID,
+		Title:      title,
+		Body:       "Body of " + title,
+		CategoryId: categoryID,
+		Tags:       tags,
+	}, tagList)
+	require.NoError(t, err)
+	return post
+}
+
+func newComment(t *testing.T, st *storage.Storage, userID, postID string) *pb.CommentCReqOrCResOrGResOrURes {
+	t.Helper()
+	comment, err := st.CommentS.Create(ctx, &pb.CommentCReqOrCResOrGResOrURes{
+		CommentId: uuid.NewString(),
+		UserId:    userID,
+		PostId:    postID,
+		Body:      "A comment",
+	})
+	require.NoError(t, err)
+	return comment
+}
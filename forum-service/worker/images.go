@@ -0,0 +1,139 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"forum-service/blob"
+	"forum-service/config"
+	pb "forum-service/forum-protos/genprotos"
+	"forum-service/imaging"
+	"forum-service/models"
+	st "forum-service/storage"
+	"io"
+	"log"
+	"time"
+)
+
+// lease is how long a claimed image stays with one worker before another may
+// pick it up again.
+const lease = 5 * time.Minute
+
+// permanentError marks failures retrying cannot fix, such as an undecodable
+// file.
+type permanentError struct{ err error }
+
+func (e permanentError) Error() string { return e.err.Error() }
+
+// ImageWorker strips metadata from uploaded images and generates their
+// thumbnails in the background.
+type ImageWorker struct {
+	attachments st.AttachmentI
+	blobs       blob.Store
+	sizes       []int
+	maxPixels   int
+	interval    time.Duration
+	maxAttempts int
+	backoff     time.Duration
+}
+
+func NewImageWorker(attachments st.AttachmentI, blobs blob.Store, cfg config.Config) *ImageWorker {
+	return &ImageWorker{
+		attachments: attachments,
+		blobs:       blobs,
+		sizes:       cfg.IMAGE_THUMBNAIL_SIZES,
+		maxPixels:   cfg.IMAGE_MAX_PIXELS,
+		interval:    cfg.IMAGE_WORKER_INTERVAL,
+		maxAttempts: cfg.IMAGE_MAX_ATTEMPTS,
+		backoff:     cfg.IMAGE_RETRY_BACKOFF,
+	}
+}
+
+// Run processes due images until ctx is cancelled, polling every interval
+// once the queue is empty.
+func (w *ImageWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		for {
+			worked, err := w.ProcessNext(ctx)
+			if err != nil {
+				log.Println("image worker:", err)
+			}
+			if !worked || err != nil {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessNext handles one due image. It reports whether there was one.
+func (w *ImageWorker) ProcessNext(ctx context.Context) (bool, error) {
+	a, key, attempts, err := w.attachments.ClaimImage(ctx, lease)
+	if err != nil || a == nil {
+		return false, err
+	}
+
+	err = w.process(ctx, a, key)
+	if err == nil {
+		return true, nil
+	}
+	var permanent permanentError
+	if errors.As(err, &permanent) || attempts >= w.maxAttempts {
+		log.Printf("image worker: giving up on %s after %d attempts: %v", a.AttachmentId, attempts, err)
+		return true, w.attachments.FailProcessing(ctx, a.AttachmentId, err.Error())
+	}
+	// Back off exponentially: backoff, 2*backoff, 4*backoff, ...
+	delay := w.backoff << (attempts - 1)
+	log.Printf("image worker: %s failed (attempt %d), retrying in %s: %v", a.AttachmentId, attempts, delay, err)
+	return true, w.attachments.RetryProcessing(ctx, a.AttachmentId, err.Error(), delay)
+}
+
+func (w *ImageWorker) process(ctx context.Context, a *pb.AttachmentRes, key string) error {
+	r, err := w.blobs.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	data, err := io.ReadAll(r)
+	r.Close()
+	if err != nil {
+		return err
+	}
+
+	res, err := imaging.Process(data, a.MimeType, w.sizes, w.maxPixels)
+	if err != nil {
+		return permanentError{err}
+	}
+
+	var variants []models.AttachmentVariant
+	for _, t := range res.Thumbnails {
+		v := variant(fmt.Sprintf("thumbnails/%s/%s", a.AttachmentId, t.Name), t.Name, t.Image)
+		if err := w.blobs.Put(ctx, v.BlobKey, bytes.NewReader(t.Data), v.Size, v.MimeType); err != nil {
+			return err
+		}
+		variants = append(variants, v)
+	}
+	// Overwrite the upload last: until then a retry still starts from it.
+	original := variant(key, "original", res.Original)
+	if err := w.blobs.Put(ctx, key, bytes.NewReader(res.Original.Data), original.Size, original.MimeType); err != nil {
+		return err
+	}
+	return w.attachments.SaveVariants(ctx, a.AttachmentId, original, variants)
+}
+
+func variant(key, name string, img imaging.Image) models.AttachmentVariant {
+	return models.AttachmentVariant{
+		Name:     name,
+		Width:    img.Width,
+		Height:   img.Height,
+		MimeType: img.MimeType,
+		Size:     int64(len(img.Data)),
+		BlobKey:  key,
+	}
+}
@@ -0,0 +1,113 @@
+package worker_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"forum-service/blob"
+	"forum-service/config"
+	pb "forum-service/forum-protos/genprotos"
+	"forum-service/models"
+	"forum-service/storage"
+	"forum-service/worker"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var ctx = context.Background()
+
+// upload stores a fixture as a pending image attachment and returns its ID
+// and blob key.
+func upload(t *testing.T, st *storage.Storage, blobs blob.Store, fixture string) (string, string) {
+	t.Helper()
+	data, err := os.ReadFile("../imaging/testdata/" + fixture)
+	require.NoError(t, err)
+	id := uuid.NewString()
+	key := "attachments/" + id
+	require.NoError(t, blobs.Put(ctx, key, bytes.NewReader(data), int64(len(data)), "image/jpeg"))
+	_, err = st.AttachmentS.Create(ctx, &pb.AttachmentRes{
+		AttachmentId:     id,
+		ContentType:      models.ContentPost,
+		ContentId:        uuid.NewString(),
+		UserId:           uuid.NewString(),
+		Filename:         fixture,
+		MimeType:         "image/jpeg",
+		Size:             int64(len(data)),
+		ProcessingStatus: models.ProcessingPending,
+	}, key)
+	require.NoError(t, err)
+	return id, key
+}
+
+func newWorker(t *testing.T) (*worker.ImageWorker, *storage.Storage, blob.Store) {
+	t.Helper()
+	st := storage.NewMemoryStorage()
+	blobs, err := blob.NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+	w := worker.NewImageWorker(st.AttachmentS, blobs, config.Config{
+		IMAGE_THUMBNAIL_SIZES: []int{16, 320},
+		IMAGE_MAX_ATTEMPTS:    3,
+		IMAGE_RETRY_BACKOFF:   time.Minute,
+	})
+	return w, st, blobs
+}
+
+func TestProcessNextCleansImage(t *testing.T) {
+	w, st, blobs := newWorker(t)
+	id, key := upload(t, st, blobs, "exif_gps_orientation6.jpg")
+
+	worked, err := w.ProcessNext(ctx)
+	require.NoError(t, err)
+	assert.True(t, worked)
+
+	a, _, err := st.AttachmentS.GetByID(ctx, &pb.AttachmentGReqOrDReq{AttachmentId: id})
+	require.NoError(t, err)
+	assert.Equal(t, models.ProcessingReady, a.ProcessingStatus)
+	assert.Equal(t, [2]int32{20, 40}, [2]int32{a.Width, a.Height}, "stored upright")
+
+	r, err := blobs.Get(ctx, key)
+	require.NoError(t, err)
+	stored, err := io.ReadAll(r)
+	r.Close()
+	require.NoError(t, err)
+	assert.False(t, bytes.Contains(stored, []byte("Exif")), "the upload was replaced by the stripped image")
+
+	variants, err := st.AttachmentS.GetVariants(ctx, id)
+	require.NoError(t, err)
+	byName := map[string]models.AttachmentVariant{}
+	for _, v := range variants {
+		byName[v.Name] = v
+	}
+	require.Len(t, byName, 1, "the 320 box is larger than the image")
+	thumb := byName["thumb_16"]
+	assert.Equal(t, [2]int{8, 16}, [2]int{thumb.Width, thumb.Height})
+	_, err = blobs.Get(ctx, thumb.BlobKey)
+	assert.NoError(t, err, "the thumbnail was stored")
+
+	worked, err = w.ProcessNext(ctx)
+	require.NoError(t, err)
+	assert.False(t, worked, "nothing else is due")
+}
+
+func TestProcessNextFailsCorruptImage(t *testing.T) {
+	w, st, blobs := newWorker(t)
+	id, _ := upload(t, st, blobs, "truncated.jpg")
+
+	worked, err := w.ProcessNext(ctx)
+	require.NoError(t, err)
+	assert.True(t, worked)
+
+	a, _, err := st.AttachmentS.GetByID(ctx, &pb.AttachmentGReqOrDReq{AttachmentId: id})
+	require.NoError(t, err)
+	assert.Equal(t, models.ProcessingFailed, a.ProcessingStatus, "a corrupt file fails on the first attempt")
+
+	worked, err = w.ProcessNext(ctx)
+	require.NoError(t, err)
+	assert.False(t, worked, "it is not retried")
+}
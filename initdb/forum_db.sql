@@ -0,0 +1,2 @@
+-- forum-service keeps its schema in its own database.
+CREATE DATABASE forum_db;